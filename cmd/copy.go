@@ -0,0 +1,444 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/FalcoSuessgott/vkv/pkg/utils"
+	"github.com/FalcoSuessgott/vkv/pkg/vault"
+	"github.com/spf13/cobra"
+)
+
+// copyOptions holds all available commandline options of the cp and mv subcommands.
+type copyOptions struct {
+	Path       string `env:"PATH"`
+	EnginePath string `env:"ENGINE_PATH"`
+	Namespace  string `env:"NS"`
+
+	TargetPath       string `env:"TARGET_PATH"`
+	TargetEnginePath string `env:"TARGET_ENGINE_PATH"`
+	TargetNamespace  string `env:"TARGET_NS"`
+
+	WithMetadata bool `env:"WITH_METADATA"`
+	AllVersions  bool `env:"ALL_VERSIONS"`
+
+	Force      bool `env:"FORCE"`
+	DryRun     bool `env:"DRY_RUN"`
+	CASRetries int  `env:"CAS_RETRIES"`
+
+	SkipErrors  bool `env:"SKIP_ERRORS" envDefault:"false"`
+	Concurrency int
+
+	move bool
+}
+
+// copySecret is a single secret to be copied, with the versions to be written oldest first.
+type copySecret struct {
+	source         string
+	target         string
+	versions       []map[string]interface{}
+	customMetadata map[string]interface{}
+}
+
+// copyLocation is the source or target of a copy.
+type copyLocation struct {
+	client   *vault.Vault
+	ns       string
+	rootPath string
+	subPath  string
+}
+
+// NewCopyCmd cp subcommand.
+func NewCopyCmd() *cobra.Command {
+	return newCopyCmd(false)
+}
+
+// NewMoveCmd mv subcommand.
+func NewMoveCmd() *cobra.Command {
+	return newCopyCmd(true)
+}
+
+//nolint:lll
+func newCopyCmd(move bool) *cobra.Command {
+	o := &copyOptions{move: move}
+
+	use, short, envPrefix := "cp", "recursively copy secrets to another path, engine or namespace", envVarCopyPrefix
+	if move {
+		use, short, envPrefix = "mv", "recursively move secrets to another path, engine or namespace", envVarMovePrefix
+	}
+
+	if err := utils.ParseEnvs(envPrefix, o); err != nil {
+		log.Fatal(err)
+	}
+
+	o.Concurrency = vault.DefaultConcurrency()
+
+	cmd := &cobra.Command{
+		Use:           use,
+		Short:         short,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PreRunE:       o.validateFlags,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			vaultClient.SetConcurrency(o.Concurrency)
+
+			source := o.location(o.EnginePath, o.Path, o.Namespace)
+			target := o.location(o.TargetEnginePath, o.TargetPath, o.TargetNamespace)
+
+			if source.contains(target) {
+				return fmt.Errorf("cannot %s \"%s\" into itself", o.verb(), source)
+			}
+
+			secrets, err := o.read(source, target)
+			if err != nil {
+				return err
+			}
+
+			if len(secrets) == 0 {
+				fmt.Fprintf(writer, "no secrets found in \"%s\"\n", source)
+
+				return nil
+			}
+
+			if err := o.prepareTarget(target, secrets); err != nil {
+				return err
+			}
+
+			for _, s := range secrets {
+				from, to := path.Join(source.ns, source.rootPath, s.source), path.Join(target.ns, target.rootPath, s.target)
+
+				if o.DryRun {
+					fmt.Fprintf(writer, "%s secret \"%s\" to \"%s\"%s\n", o.verb(), from, to, o.versionsLabel(s))
+
+					continue
+				}
+
+				if err := o.write(target, s); err != nil {
+					return fmt.Errorf("error writing secret \"%s\": %w", to, err)
+				}
+
+				fmt.Fprintf(writer, "%s secret \"%s\" to \"%s\"%s\n", o.pastTense(), from, to, o.versionsLabel(s))
+			}
+
+			if o.DryRun {
+				fmt.Fprintln(writer, "")
+				fmt.Fprintf(writer, "%s the secrets by omitting the --dry-run flag\n", o.verb())
+
+				return nil
+			}
+
+			// the source secrets are only removed once all secrets have been written
+			if o.move {
+				for _, s := range secrets {
+					if err := source.remove(s.source); err != nil {
+						return fmt.Errorf("error deleting source secret \"%s\": %w", path.Join(source.ns, source.rootPath, s.source), err)
+					}
+				}
+			}
+
+			fmt.Fprintf(writer, "successfully %s %d secrets from \"%s\" to \"%s\"\n", o.pastTense(), len(secrets), source, target)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().SortFlags = false
+
+	// Source
+	cmd.Flags().StringVarP(&o.Path, "path", "p", o.Path, fmt.Sprintf("KV Engine path of the source (env: %sPATH)", envPrefix))
+	cmd.Flags().StringVarP(&o.EnginePath, "engine-path", "e", o.EnginePath, fmt.Sprintf("engine path of the source in case your KV-engine contains special characters such as \"/\", the path (-p) flag will then be appended if specified (\"<engine-path>/<path>\") (env: %sENGINE_PATH)", envPrefix))
+	cmd.Flags().StringVarP(&o.Namespace, "namespace", "n", o.Namespace, fmt.Sprintf("namespace of the source (env: %sNS)", envPrefix))
+
+	// Target
+	cmd.Flags().StringVarP(&o.TargetPath, "target-path", "t", o.TargetPath, fmt.Sprintf("KV Engine path of the target (env: %sTARGET_PATH)", envPrefix))
+	cmd.Flags().StringVar(&o.TargetEnginePath, "target-engine-path", o.TargetEnginePath, fmt.Sprintf("engine path of the target, the target path (-t) flag will then be appended if specified (env: %sTARGET_ENGINE_PATH)", envPrefix))
+	cmd.Flags().StringVar(&o.TargetNamespace, "target-namespace", o.TargetNamespace, fmt.Sprintf("namespace of the target (env: %sTARGET_NS)", envPrefix))
+
+	cmd.Flags().BoolVar(&o.SkipErrors, "skip-errors", o.SkipErrors, fmt.Sprintf("don't exit on errors while reading the source secrets (permission denied, deleted secrets) (env: %sSKIP_ERRORS)", envPrefix))
+	cmd.Flags().IntVar(&o.Concurrency, "concurrency", o.Concurrency, "maximum number of concurrent requests sent to Vault while reading secrets (env: VKV_CONCURRENCY)")
+
+	// Options
+	cmd.Flags().BoolVar(&o.WithMetadata, "with-metadata", o.WithMetadata, fmt.Sprintf("also copy the custom metadata of KVv2 secrets (env: %sWITH_METADATA)", envPrefix))
+	cmd.Flags().BoolVar(&o.AllVersions, "all-versions", o.AllVersions, fmt.Sprintf("copy all readable versions of KVv2 secrets, oldest first (env: %sALL_VERSIONS)", envPrefix))
+	cmd.Flags().BoolVar(&o.Force, "force", o.Force, fmt.Sprintf("overwrite existing secrets of the target (env: %sFORCE)", envPrefix))
+	cmd.Flags().BoolVarP(&o.DryRun, "dry-run", "d", o.DryRun, fmt.Sprintf("only print the secrets that would be copied (env: %sDRY_RUN)", envPrefix))
+	cmd.Flags().IntVar(&o.CASRetries, "cas-retries", o.CASRetries, fmt.Sprintf("number of times the write of a secret that has been modified concurrently is retried (env: %sCAS_RETRIES)", envPrefix))
+
+	return cmd
+}
+
+func (o *copyOptions) validateFlags(cmd *cobra.Command, args []string) error {
+	switch {
+	case o.EnginePath == "" && o.Path == "":
+		return errors.New("no source KV-paths given. Either --engine-path/-e or --path/-p needs to be specified")
+	case o.TargetEnginePath == "" && o.TargetPath == "":
+		return errors.New("no target KV-paths given. Either --target-engine-path or --target-path/-t needs to be specified")
+	case o.Force && o.DryRun:
+		return fmt.Errorf("%w: %s", errInvalidFlagCombination, "cannot specify both --force and --dry-run")
+	case o.CASRetries < 0:
+		return errors.New("--cas-retries needs to be a positive number")
+	}
+
+	return nil
+}
+
+// location returns the source or target of the copy.
+func (o *copyOptions) location(enginePath, subPath, ns string) *copyLocation {
+	rootPath, subPath := utils.HandleEnginePath(enginePath, subPath)
+
+	return newCopyLocation(vaultClient, ns, rootPath, subPath)
+}
+
+// newCopyLocation returns the location of the secrets under rootPath/subPath of the namespace ns.
+func newCopyLocation(client *vault.Vault, ns, rootPath, subPath string) *copyLocation {
+	return &copyLocation{
+		client:   client.WithNamespace(ns),
+		ns:       ns,
+		rootPath: strings.TrimSuffix(rootPath, utils.Delimiter),
+		subPath:  strings.TrimSuffix(subPath, utils.Delimiter),
+	}
+}
+
+// String returns the full path of the location.
+func (l *copyLocation) String() string {
+	return path.Join(l.ns, l.rootPath, l.subPath)
+}
+
+// contains reports whether the other location is within l.
+func (l *copyLocation) contains(other *copyLocation) bool {
+	if l.ns != other.ns || l.rootPath != other.rootPath {
+		return false
+	}
+
+	return l.subPath == "" || other.subPath == l.subPath || strings.HasPrefix(other.subPath, l.subPath+utils.Delimiter)
+}
+
+// read reads all secrets of the source and maps them to their target paths.
+func (o *copyOptions) read(source, target *copyLocation) ([]*copySecret, error) {
+	secrets := make(map[string]*copySecret)
+
+	if o.AllVersions {
+		vs, err := source.client.ListRecursiveAllVersions(rootContext, source.rootPath, source.subPath, o.SkipErrors)
+		if err != nil {
+			return nil, err
+		}
+
+		for p, secret := range vs {
+			s := &copySecret{source: p, customMetadata: secret.CustomMetadata}
+
+			// versions are ordered newest first, deleted and destroyed versions have no data
+			for i := len(secret.Versions) - 1; i >= 0; i-- {
+				if secret.Versions[i].Data != nil {
+					s.versions = append(s.versions, secret.Versions[i].Data)
+				}
+			}
+
+			if len(s.versions) > 0 {
+				secrets[p] = s
+			}
+		}
+	} else {
+		current, err := source.client.ListSecretTree(rootContext, "", source.rootPath, source.subPath, o.SkipErrors, true)
+		if err != nil {
+			return nil, err
+		}
+
+		metadata := current.SecretsMetadata()
+
+		for p, data := range current.Flatten() {
+			s := &copySecret{source: p, versions: []map[string]interface{}{data}}
+
+			if md, ok := metadata[p]; ok {
+				s.customMetadata = md.CustomMetadata
+			}
+
+			secrets[p] = s
+		}
+	}
+
+	res := make([]*copySecret, 0, len(secrets))
+
+	for p, s := range secrets {
+		rel := strings.TrimPrefix(strings.TrimPrefix(p, source.subPath), utils.Delimiter)
+
+		switch {
+		case rel != "":
+			s.target = path.Join(target.subPath, rel)
+		case target.subPath != "":
+			// the source is a single secret
+			s.target = target.subPath
+		default:
+			s.target = path.Base(p)
+		}
+
+		if !o.WithMetadata {
+			s.customMetadata = nil
+		}
+
+		res = append(res, s)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].source < res[j].source
+	})
+
+	return res, nil
+}
+
+// prepareTarget enables the target engine as KVv2 if it does not exist yet
+// and makes sure no existing secrets are overwritten, unless forced.
+func (o *copyOptions) prepareTarget(target *copyLocation, secrets []*copySecret) error {
+	if _, _, err := target.client.GetEngineTypeVersion(rootContext, target.rootPath); err != nil {
+		if o.DryRun {
+			fmt.Fprintf(writer, "KVv2 engine \"%s\" will be enabled\n", path.Join(target.ns, target.rootPath))
+
+			return nil
+		}
+
+		return target.client.EnableKV2EngineErrorIfNotForced(rootContext, false, target.rootPath)
+	}
+
+	isV1, err := target.client.IsKVv1(rootContext, target.rootPath)
+	if err != nil {
+		return err
+	}
+
+	if isV1 && o.WithMetadata {
+		return fmt.Errorf("%w: --with-metadata cannot be used with the KVv1 target engine \"%s\"", vault.ErrKVv1NotSupported, target.rootPath)
+	}
+
+	if o.Force {
+		return nil
+	}
+
+	// secrets that cannot be read are treated as not existing
+	existing, err := target.client.ListSecretTree(rootContext, "", target.rootPath, target.subPath, true, false)
+	if err != nil {
+		return nil //nolint: nilerr
+	}
+
+	exists := existing.Flatten()
+
+	conflicts := []string{}
+
+	for _, s := range secrets {
+		if _, ok := exists[s.target]; ok {
+			conflicts = append(conflicts, path.Join(target.ns, target.rootPath, s.target))
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return fmt.Errorf("the following secrets already exist in the target, use --force for overwriting them: %s", strings.Join(conflicts, ", "))
+	}
+
+	return nil
+}
+
+// write writes all versions and the custom metadata of a secret to the target.
+// KVv2 secrets are written using check-and-set, if the secret has been modified concurrently
+// its version is read again and the write is retried up to --cas-retries times.
+func (o *copyOptions) write(target *copyLocation, s *copySecret) error {
+	version := 0
+
+	for i, data := range s.versions {
+		for attempt := 0; ; attempt++ {
+			if i == 0 || attempt > 0 {
+				existing, v, err := target.client.ReadSecretsVersion(rootContext, "", target.rootPath, s.target)
+				if err != nil {
+					return err
+				}
+
+				// the secret might have been created since the target has been checked
+				if i == 0 && existing != nil && !o.Force {
+					return errors.New("secret already exists in the target, use --force for overwriting it")
+				}
+
+				version = v
+			}
+
+			err := target.client.WriteSecretsCAS(rootContext, "", target.rootPath, s.target, data, version)
+			if errors.Is(err, vault.ErrCASConflict) && attempt < o.CASRetries {
+				fmt.Fprintf(writer, "secret \"%s\" has been modified concurrently, retrying\n", path.Join(target.ns, target.rootPath, s.target))
+
+				continue
+			}
+
+			if err != nil {
+				return err
+			}
+
+			break
+		}
+
+		version++
+	}
+
+	if len(s.customMetadata) == 0 {
+		return nil
+	}
+
+	return target.client.WriteCustomMetadata(rootContext, target.rootPath, s.target, s.customMetadata)
+}
+
+// writeSecretCAS writes a secret using check-and-set with its current version. If the secret has been modified
+// concurrently, its version is read again and the write is retried up to retries times.
+func writeSecretCAS(c *vault.Vault, ns, rootPath, subPath string, secret map[string]interface{}, retries int) error {
+	for attempt := 0; ; attempt++ {
+		_, version, err := c.ReadSecretsVersion(rootContext, ns, rootPath, subPath)
+		if err != nil {
+			return err
+		}
+
+		err = c.WriteSecretsCAS(rootContext, ns, rootPath, subPath, secret, version)
+		if errors.Is(err, vault.ErrCASConflict) && attempt < retries {
+			fmt.Fprintf(writer, "secret \"%s\" has been modified concurrently, retrying\n", path.Join(ns, rootPath, subPath))
+
+			continue
+		}
+
+		return err
+	}
+}
+
+// remove removes a secret of the location including all of its versions.
+func (l *copyLocation) remove(subPath string) error {
+	isV1, err := l.client.IsKVv1(rootContext, l.rootPath)
+	if err != nil {
+		return err
+	}
+
+	if isV1 {
+		return l.client.DeleteSecret(rootContext, l.rootPath, subPath, nil)
+	}
+
+	return l.client.DeleteSecretMetadata(rootContext, l.rootPath, subPath)
+}
+
+// versionsLabel returns the number of versions written, if all versions are copied.
+func (o *copyOptions) versionsLabel(s *copySecret) string {
+	if !o.AllVersions {
+		return ""
+	}
+
+	return fmt.Sprintf(" (%d versions)", len(s.versions))
+}
+
+func (o *copyOptions) verb() string {
+	if o.move {
+		return "move"
+	}
+
+	return "copy"
+}
+
+func (o *copyOptions) pastTense() string {
+	if o.move {
+		return "moved"
+	}
+
+	return "copied"
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+)
+
+func (s *VaultSuite) TestValidateCopyFlags() {
+	testCases := []struct {
+		name string
+		args []string
+		err  bool
+	}{
+		{
+			name: "source missing",
+			args: []string{"-t=target", "--dry-run"},
+			err:  true,
+		},
+		{
+			name: "target missing",
+			args: []string{"-p=secret", "--dry-run"},
+			err:  true,
+		},
+		{
+			name: "force and dry run",
+			args: []string{"-p=secret", "-t=target", "--dry-run", "--force"},
+			err:  true,
+		},
+		{
+			name: "into itself",
+			args: []string{"-p=secret/a", "-t=secret/a/b", "--dry-run"},
+			err:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		cmd := NewCopyCmd()
+		cmd.SetArgs(tc.args)
+
+		err := cmd.Execute()
+
+		s.Require().Equal(tc.err, err != nil, tc.name)
+	}
+}
+
+func (s *VaultSuite) TestCopyCommand() {
+	s.Run("copy", func() {
+		ctx := context.Background()
+
+		s.Require().NoError(vaultClient.EnableKV2Engine(ctx, "copy"))
+
+		s.Require().NoError(vaultClient.WriteSecrets(ctx, "", "copy", "team-a/admin", map[string]interface{}{"user": "v1"}))
+		s.Require().NoError(vaultClient.WriteSecrets(ctx, "", "copy", "team-a/admin", map[string]interface{}{"user": "v2"}))
+		s.Require().NoError(vaultClient.WriteSecrets(ctx, "", "copy", "team-a/sub/demo", map[string]interface{}{"foo": "bar"}))
+		s.Require().NoError(vaultClient.WriteCustomMetadata(ctx, "copy", "team-a/admin", map[string]interface{}{"owner": "team-a"}))
+
+		// dry run does not write anything
+		b := bytes.NewBufferString("")
+		writer = b
+
+		dryRunCmd := NewCopyCmd()
+		dryRunCmd.SetArgs([]string{"-p=copy/team-a", "-t=team-a-kv", "--dry-run"})
+
+		s.Require().NoError(dryRunCmd.Execute())
+		s.Require().Contains(b.String(), "KVv2 engine \"team-a-kv\" will be enabled")
+		s.Require().Contains(b.String(), "copy secret \"copy/team-a/sub/demo\" to \"team-a-kv/sub/demo\"")
+
+		// copy to a new engine, including all versions and the custom metadata
+		b.Reset()
+
+		copyCmd := NewCopyCmd()
+		copyCmd.SetArgs([]string{"-p=copy/team-a", "-t=team-a-kv", "--all-versions", "--with-metadata"})
+
+		s.Require().NoError(copyCmd.Execute())
+		s.Require().Contains(b.String(), "copied secret \"copy/team-a/admin\" to \"team-a-kv/admin\" (2 versions)")
+
+		md, err := vaultClient.ReadMetadata(ctx, "team-a-kv", "admin")
+		s.Require().NoError(err)
+		s.Require().Equal(2, md.CurrentVersion)
+		s.Require().Equal(map[string]interface{}{"owner": "team-a"}, md.CustomMetadata)
+
+		// existing secrets are not overwritten
+		copyAgainCmd := NewCopyCmd()
+		copyAgainCmd.SetArgs([]string{"-p=copy/team-a", "-t=team-a-kv"})
+
+		s.Require().ErrorContains(copyAgainCmd.Execute(), "already exist")
+
+		// move a single secret
+		b.Reset()
+
+		moveCmd := NewMoveCmd()
+		moveCmd.SetArgs([]string{"-p=copy/team-a/sub/demo", "-t=copy/demo"})
+
+		s.Require().NoError(moveCmd.Execute())
+		s.Require().Contains(b.String(), "moved secret \"copy/team-a/sub/demo\" to \"copy/demo\"")
+
+		secret, err := vaultClient.ReadSecrets(ctx, "", "copy", "demo")
+		s.Require().NoError(err)
+		s.Require().Equal(map[string]interface{}{"foo": "bar"}, secret)
+
+		_, err = vaultClient.ReadMetadata(ctx, "copy", "team-a/sub/demo")
+		s.Require().Error(err)
+	})
+}
+
+func (s *VaultSuite) TestCopyCASRequired() {
+	ctx := context.Background()
+
+	s.Require().NoError(vaultClient.EnableKV2Engine(ctx, "cassource"))
+	s.Require().NoError(vaultClient.EnableKV2Engine(ctx, "castarget"))
+
+	_, err := vaultClient.Client.Logical().Write("castarget/config", map[string]interface{}{"cas_required": true})
+	s.Require().NoError(err)
+
+	s.Require().NoError(vaultClient.WriteSecrets(ctx, "", "cassource", "admin", map[string]interface{}{"user": "v1"}))
+	s.Require().NoError(vaultClient.WriteSecrets(ctx, "", "cassource", "admin", map[string]interface{}{"user": "v2"}))
+	s.Require().NoError(vaultClient.WriteSecretsCAS(ctx, "", "castarget", "admin", map[string]interface{}{"user": "existing"}, 0))
+
+	b := bytes.NewBufferString("")
+	writer = b
+
+	cmd := NewCopyCmd()
+	cmd.SetArgs([]string{"-p=cassource", "-t=castarget", "--all-versions", "--force"})
+	s.Require().NoError(cmd.Execute())
+
+	secret, version, err := vaultClient.ReadSecretsVersion(ctx, "", "castarget", "admin")
+	s.Require().NoError(err)
+	s.Require().Equal("v2", secret["user"])
+	s.Require().Equal(3, version)
+}
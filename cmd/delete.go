@@ -0,0 +1,286 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"sort"
+	"strings"
+
+	prt "github.com/FalcoSuessgott/vkv/pkg/printer/secret"
+	"github.com/FalcoSuessgott/vkv/pkg/utils"
+	"github.com/FalcoSuessgott/vkv/pkg/vault"
+	"github.com/spf13/cobra"
+)
+
+const (
+	deleteModeDelete   = "delete"
+	deleteModeDestroy  = "destroy"
+	deleteModeMetadata = "metadata"
+)
+
+// deleteOptions holds all available commandline options.
+type deleteOptions struct {
+	Path       string `env:"PATH"`
+	EnginePath string `env:"ENGINE_PATH"`
+
+	Mode        string `env:"MODE" envDefault:"delete"`
+	Versions    []int  `env:"VERSIONS"`
+	AllVersions bool   `env:"ALL_VERSIONS"`
+
+	DryRun bool `env:"DRY_RUN"`
+	Yes    bool `env:"YES"`
+
+	SkipErrors  bool `env:"SKIP_ERRORS" envDefault:"false"`
+	Concurrency int
+
+	input io.Reader
+}
+
+// NewDeleteCmd delete subcommand.
+//
+//nolint:lll
+func NewDeleteCmd() *cobra.Command {
+	o := &deleteOptions{}
+
+	if err := utils.ParseEnvs(envVarDeletePrefix, o); err != nil {
+		log.Fatal(err)
+	}
+
+	o.Concurrency = vault.DefaultConcurrency()
+
+	cmd := &cobra.Command{
+		Use:           "delete",
+		Short:         "recursively delete, destroy or purge the secrets of a KV path",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PreRunE:       o.validateFlags,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			vaultClient.SetConcurrency(o.Concurrency)
+
+			rootPath, subPath := utils.HandleEnginePath(o.EnginePath, o.Path)
+
+			isV1, err := vaultClient.IsKVv1(rootContext, rootPath)
+			if err != nil {
+				return err
+			}
+
+			if isV1 && (o.Mode != deleteModeDelete || len(o.Versions) > 0 || o.AllVersions) {
+				return fmt.Errorf("%w: only --mode=delete without version selection is supported for the KVv1 engine \"%s\"", vault.ErrKVv1NotSupported, rootPath)
+			}
+
+			// deleted or destroyed KVv2 secrets cannot be read, they are shown without any data
+			tree, err := vaultClient.ListSecretTree(rootContext, "", rootPath, subPath, o.SkipErrors || !isV1, false)
+			if err != nil {
+				return err
+			}
+
+			var (
+				paths    = secretPaths(tree)
+				metadata vault.SecretsMetadata
+			)
+
+			// the secrets of a KVv2 engine are listed by their metadata, so that deleted or destroyed secrets are included
+			if !isV1 {
+				metadata, err = vaultClient.ListRecursiveMetadata(rootContext, rootPath, subPath, o.SkipErrors)
+				if err != nil {
+					return err
+				}
+
+				paths = make([]string, 0, len(metadata))
+				for p := range metadata {
+					paths = append(paths, p)
+				}
+
+				sort.Strings(paths)
+			}
+
+			if len(paths) == 0 {
+				fmt.Fprintf(writer, "no secrets found in \"%s\"\n", path.Join(rootPath, subPath))
+
+				return nil
+			}
+
+			// the secrets to be deleted are always shown before deleting them
+			fmt.Fprintf(writer, "the following %d secrets will be %s:\n\n", len(paths), o.description())
+
+			printer = prt.NewSecretPrinter(
+				prt.ToFormat(prt.Base),
+				prt.WithVaultClient(vaultClient),
+				prt.WithWriter(writer),
+				prt.ShowVersion(!isV1),
+				prt.ShowMetadata(false),
+				prt.WithSecretsMetadata(metadata),
+				prt.WithEnginePath(utils.NormalizePath(rootPath)),
+				prt.WithContext(rootContext),
+			)
+
+			if err := printer.Out(tree); err != nil {
+				return err
+			}
+
+			fmt.Fprintln(writer, "")
+
+			if o.DryRun {
+				fmt.Fprintln(writer, "delete the secrets by omitting the --dry-run flag")
+
+				return nil
+			}
+
+			if !o.Yes {
+				ok, err := confirm(o.input, fmt.Sprintf("%d secrets in \"%s\" will be %s, continue?", len(paths), path.Join(rootPath, subPath), o.description()))
+				if err != nil {
+					return err
+				}
+
+				if !ok {
+					fmt.Fprintln(writer, "aborted, no secrets have been deleted")
+
+					return nil
+				}
+			}
+
+			deleted := 0
+
+			for _, p := range paths {
+				if err := o.delete(rootPath, p, metadata[p]); err != nil {
+					if o.SkipErrors {
+						continue
+					}
+
+					return fmt.Errorf("error deleting secret \"%s\": %w", path.Join(rootPath, p), err)
+				}
+
+				fmt.Fprintf(writer, "%s secret \"%s\"\n", o.description(), path.Join(rootPath, p))
+
+				deleted++
+			}
+
+			fmt.Fprintf(writer, "successfully %s %d secrets\n", o.description(), deleted)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().SortFlags = false
+
+	// Input
+	cmd.Flags().StringVarP(&o.Path, "path", "p", o.Path, "KV Engine path (env: VKV_DELETE_PATH)")
+	cmd.Flags().StringVarP(&o.EnginePath, "engine-path", "e", o.EnginePath, "engine path in case your KV-engine contains special characters such as \"/\", the path (-p) flag will then be appended if specified (\"<engine-path>/<path>\") (env: VKV_DELETE_ENGINE_PATH)")
+	cmd.Flags().BoolVar(&o.SkipErrors, "skip-errors", o.SkipErrors, "don't exit on errors (permission denied, deleted secrets) (env: VKV_DELETE_SKIP_ERRORS)")
+	cmd.Flags().IntVar(&o.Concurrency, "concurrency", o.Concurrency, "maximum number of concurrent requests sent to Vault while reading secrets (env: VKV_CONCURRENCY)")
+
+	// Mode
+	cmd.Flags().StringVarP(&o.Mode, "mode", "m", o.Mode, "\"delete\" soft deletes versions, \"destroy\" permanently removes the data of versions, \"metadata\" permanently removes the secrets including all versions and metadata (env: VKV_DELETE_MODE)")
+	cmd.Flags().IntSliceVar(&o.Versions, "versions", o.Versions, "KVv2 versions to delete or destroy, defaults to the current version (env: VKV_DELETE_VERSIONS)")
+	cmd.Flags().BoolVar(&o.AllVersions, "all-versions", o.AllVersions, "delete or destroy all KVv2 versions (env: VKV_DELETE_ALL_VERSIONS)")
+
+	// Options
+	cmd.Flags().BoolVarP(&o.DryRun, "dry-run", "d", o.DryRun, "only print the secrets that would be deleted (env: VKV_DELETE_DRY_RUN)")
+	cmd.Flags().BoolVarP(&o.Yes, "yes", "y", o.Yes, "don't ask for confirmation before deleting the secrets (env: VKV_DELETE_YES)")
+
+	o.input = cmd.InOrStdin()
+
+	return cmd
+}
+
+func (o *deleteOptions) validateFlags(cmd *cobra.Command, args []string) error {
+	o.Mode = strings.ToLower(o.Mode)
+
+	switch {
+	case o.EnginePath == "" && o.Path == "":
+		return errors.New("no KV-paths given. Either --engine-path/-e or --path/-p needs to be specified")
+	case o.Mode != deleteModeDelete && o.Mode != deleteModeDestroy && o.Mode != deleteModeMetadata:
+		return fmt.Errorf("invalid mode \"%s\" (valid options: delete, destroy, metadata)", o.Mode)
+	case len(o.Versions) > 0 && o.AllVersions:
+		return fmt.Errorf("%w: %s", errInvalidFlagCombination, "cannot specify both --versions and --all-versions")
+	case o.Mode == deleteModeMetadata && (len(o.Versions) > 0 || o.AllVersions):
+		return fmt.Errorf("%w: %s", errInvalidFlagCombination, "--mode=metadata always removes all versions, --versions and --all-versions cannot be used")
+	case o.DryRun && o.Yes:
+		return fmt.Errorf("%w: %s", errInvalidFlagCombination, "cannot specify both --dry-run and --yes")
+	}
+
+	return nil
+}
+
+// confirm asks the user the given yes/no question, the default answer is no.
+func confirm(r io.Reader, question string) (bool, error) {
+	fmt.Fprintf(writer, "%s [y/N]: ", question)
+
+	answer, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// delete deletes a single secret according to the mode.
+func (o *deleteOptions) delete(rootPath, subPath string, md *vault.SecretMetadata) error {
+	if o.Mode == deleteModeMetadata {
+		return vaultClient.DeleteSecretMetadata(rootContext, rootPath, subPath)
+	}
+
+	versions := o.Versions
+
+	// the metadata is missing for KVv1 secrets or secrets that could not be read
+	if (o.AllVersions || o.Mode == deleteModeDestroy) && len(versions) == 0 && md == nil {
+		var err error
+
+		md, err = vaultClient.ReadMetadata(rootContext, rootPath, subPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case o.AllVersions:
+		for _, sv := range md.Versions {
+			versions = append(versions, sv.Version)
+		}
+	case o.Mode == deleteModeDestroy && len(versions) == 0:
+		versions = []int{md.CurrentVersion}
+	}
+
+	if o.Mode == deleteModeDestroy {
+		return vaultClient.DestroySecret(rootContext, rootPath, subPath, versions)
+	}
+
+	return vaultClient.DeleteSecret(rootContext, rootPath, subPath, versions)
+}
+
+// description returns what happens to the secrets in the mode.
+func (o *deleteOptions) description() string {
+	switch o.Mode {
+	case deleteModeDestroy:
+		return "destroyed"
+	case deleteModeMetadata:
+		return "permanently deleted"
+	default:
+		return "deleted"
+	}
+}
+
+// secretPaths returns the sorted paths of all readable secrets of the tree.
+func secretPaths(tree *vault.SecretTree) []string {
+	paths := []string{}
+
+	for _, s := range tree.SecretNodes() {
+		// a listed secret that could not be read does not exist
+		if !tree.Dir && s.Data == nil {
+			continue
+		}
+
+		paths = append(paths, s.Path)
+	}
+
+	return paths
+}
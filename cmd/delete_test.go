@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+)
+
+func (s *VaultSuite) TestValidateDeleteFlags() {
+	testCases := []struct {
+		name string
+		args []string
+		err  bool
+	}{
+		{
+			name: "path missing",
+			args: []string{"--dry-run"},
+			err:  true,
+		},
+		{
+			name: "invalid mode",
+			args: []string{"-p=secret", "--mode=invalid", "--dry-run"},
+			err:  true,
+		},
+		{
+			name: "versions and all versions mutually exclusive",
+			args: []string{"-p=secret", "--versions=1", "--all-versions", "--dry-run"},
+			err:  true,
+		},
+		{
+			name: "metadata mode with versions",
+			args: []string{"-p=secret", "--mode=metadata", "--versions=1", "--dry-run"},
+			err:  true,
+		},
+		{
+			name: "dry run and yes mutually exclusive",
+			args: []string{"-p=secret", "--dry-run", "--yes"},
+			err:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		cmd := NewDeleteCmd()
+		cmd.SetArgs(tc.args)
+
+		err := cmd.Execute()
+
+		s.Require().Equal(tc.err, err != nil, tc.name)
+	}
+}
+
+func (s *VaultSuite) TestDeleteCommand() {
+	s.Run("delete", func() {
+		ctx := context.Background()
+
+		s.Require().NoError(vaultClient.EnableKV2Engine(ctx, "delete"))
+
+		s.Require().NoError(vaultClient.WriteSecrets(ctx, "", "delete", "admin", map[string]interface{}{"user": "v1"}))
+		s.Require().NoError(vaultClient.WriteSecrets(ctx, "", "delete", "admin", map[string]interface{}{"user": "v2"}))
+		s.Require().NoError(vaultClient.WriteSecrets(ctx, "", "delete", "sub/demo", map[string]interface{}{"foo": "bar"}))
+
+		// dry run only prints the secrets
+		b := bytes.NewBufferString("")
+		writer = b
+
+		dryRunCmd := NewDeleteCmd()
+		dryRunCmd.SetArgs([]string{"-p=delete", "--dry-run"})
+
+		s.Require().NoError(dryRunCmd.Execute())
+		s.Require().Contains(b.String(), "the following 2 secrets will be deleted")
+		s.Require().Contains(b.String(), "sub/")
+
+		_, err := vaultClient.ReadSecrets(ctx, "", "delete", "admin")
+		s.Require().NoError(err)
+
+		// declining the confirmation does not delete anything
+		b.Reset()
+
+		abortCmd := NewDeleteCmd()
+		abortCmd.SetIn(strings.NewReader("n\n"))
+		abortCmd.SetArgs([]string{"-p=delete"})
+
+		s.Require().NoError(abortCmd.Execute())
+		s.Require().Contains(b.String(), "aborted")
+
+		_, err = vaultClient.ReadSecrets(ctx, "", "delete", "admin")
+		s.Require().NoError(err)
+
+		// confirming soft deletes the latest versions
+		b.Reset()
+
+		deleteCmd := NewDeleteCmd()
+		deleteCmd.SetIn(strings.NewReader("y\n"))
+		deleteCmd.SetArgs([]string{"-p=delete/sub"})
+
+		s.Require().NoError(deleteCmd.Execute())
+		s.Require().Contains(b.String(), "deleted secret \"delete/sub/demo\"")
+
+		_, err = vaultClient.ReadSecrets(ctx, "", "delete", "sub/demo")
+		s.Require().Error(err)
+
+		// soft deleted secrets can still be destroyed
+		b.Reset()
+
+		destroyDeletedCmd := NewDeleteCmd()
+		destroyDeletedCmd.SetArgs([]string{"-p=delete/sub", "--mode=destroy", "--yes"})
+
+		s.Require().NoError(destroyDeletedCmd.Execute())
+		s.Require().Contains(b.String(), "destroyed secret \"delete/sub/demo\"")
+		s.Require().Contains(b.String(), "successfully destroyed 1 secrets")
+
+		// destroy all versions
+		b.Reset()
+
+		destroyCmd := NewDeleteCmd()
+		destroyCmd.SetArgs([]string{"-p=delete/admin", "--mode=destroy", "--all-versions", "--yes"})
+
+		s.Require().NoError(destroyCmd.Execute())
+		s.Require().Contains(b.String(), "destroyed secret \"delete/admin\"")
+
+		md, err := vaultClient.ReadMetadata(ctx, "delete", "admin")
+		s.Require().NoError(err)
+
+		for _, sv := range md.Versions {
+			s.Require().True(sv.Destroyed)
+		}
+
+		// purging removes the metadata
+		b.Reset()
+
+		purgeCmd := NewDeleteCmd()
+		purgeCmd.SetArgs([]string{"-p=delete", "--mode=metadata", "--yes", "--skip-errors"})
+
+		s.Require().NoError(purgeCmd.Execute())
+
+		_, err = vaultClient.ReadMetadata(ctx, "delete", "admin")
+		s.Require().Error(err)
+	})
+}
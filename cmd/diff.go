@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+
+	"github.com/FalcoSuessgott/vkv/pkg/diff"
+	prt "github.com/FalcoSuessgott/vkv/pkg/printer/secret"
+	"github.com/FalcoSuessgott/vkv/pkg/utils"
+	"github.com/FalcoSuessgott/vkv/pkg/vault"
+	"github.com/spf13/cobra"
+)
+
+// diffOptions holds all available commandline options.
+type diffOptions struct {
+	Path       string `env:"PATH"`
+	EnginePath string `env:"ENGINE_PATH"`
+	Address    string `env:"ADDRESS"`
+	Namespace  string `env:"NS"`
+
+	TargetPath       string `env:"TARGET_PATH"`
+	TargetEnginePath string `env:"TARGET_ENGINE_PATH"`
+	TargetAddress    string `env:"TARGET_ADDRESS"`
+	TargetToken      string `env:"TARGET_TOKEN"`
+	TargetNamespace  string `env:"TARGET_NS"`
+
+	OnlyKeys       bool `env:"ONLY_KEYS"`
+	OnlyPaths      bool `env:"ONLY_PATHS"`
+	ShowValues     bool `env:"SHOW_VALUES"`
+	MaxValueLength int  `env:"MAX_VALUE_LENGTH" envDefault:"12"`
+
+	SkipErrors  bool `env:"SKIP_ERRORS" envDefault:"false"`
+	Concurrency int
+
+	FormatString string `env:"FORMAT" envDefault:"base"`
+
+	outputFormat prt.OutputFormat
+}
+
+// diffSource describes one side of a comparison.
+type diffSource struct {
+	client   *vault.Vault
+	rootPath string
+	subPath  string
+	label    string
+}
+
+// NewDiffCmd diff subcommand.
+//
+//nolint:lll
+func NewDiffCmd() *cobra.Command {
+	o := &diffOptions{}
+
+	if err := utils.ParseEnvs(envVarDiffPrefix, o); err != nil {
+		log.Fatal(err)
+	}
+
+	o.Concurrency = vault.DefaultConcurrency()
+
+	cmd := &cobra.Command{
+		Use:           "diff",
+		Short:         "compare the secrets of two KV paths, engines, namespaces or Vault servers",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PreRunE:       o.validateFlags,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			vaultClient.SetConcurrency(o.Concurrency)
+
+			source, err := o.source(o.EnginePath, o.Path, o.Address, "", o.Namespace)
+			if err != nil {
+				return err
+			}
+
+			target, err := o.source(o.TargetEnginePath, o.TargetPath, o.TargetAddress, o.TargetToken, o.TargetNamespace)
+			if err != nil {
+				return err
+			}
+
+			sourceSecrets, err := o.read(source)
+			if err != nil {
+				return err
+			}
+
+			targetSecrets, err := o.read(target)
+			if err != nil {
+				return err
+			}
+
+			printer = prt.NewSecretPrinter(
+				prt.OnlyKeys(o.OnlyKeys),
+				prt.OnlyPaths(o.OnlyPaths),
+				prt.CustomValueLength(o.MaxValueLength),
+				prt.ShowValues(o.ShowValues),
+				prt.ToFormat(o.outputFormat),
+				prt.WithVaultClient(vaultClient),
+				prt.WithWriter(writer),
+				prt.WithContext(rootContext),
+			)
+
+			return printer.Out(&diff.Result{
+				Source:  source.label,
+				Target:  target.label,
+				Changes: diff.Compare(sourceSecrets, targetSecrets),
+			})
+		},
+	}
+
+	cmd.Flags().SortFlags = false
+
+	// Source
+	cmd.Flags().StringVarP(&o.Path, "path", "p", o.Path, "KV Engine path of the source (env: VKV_DIFF_PATH)")
+	cmd.Flags().StringVarP(&o.EnginePath, "engine-path", "e", o.EnginePath, "engine path of the source in case your KV-engine contains special characters such as \"/\", the path (-p) flag will then be appended if specified (\"<engine-path>/<path>\") (env: VKV_DIFF_ENGINE_PATH)")
+	cmd.Flags().StringVar(&o.Address, "address", o.Address, "Vault address of the source, defaults to VAULT_ADDR (env: VKV_DIFF_ADDRESS)")
+	cmd.Flags().StringVarP(&o.Namespace, "namespace", "n", o.Namespace, "namespace of the source (env: VKV_DIFF_NS)")
+
+	// Target
+	cmd.Flags().StringVarP(&o.TargetPath, "target-path", "t", o.TargetPath, "KV Engine path of the target (env: VKV_DIFF_TARGET_PATH)")
+	cmd.Flags().StringVar(&o.TargetEnginePath, "target-engine-path", o.TargetEnginePath, "engine path of the target, the target path (-t) flag will then be appended if specified (env: VKV_DIFF_TARGET_ENGINE_PATH)")
+	cmd.Flags().StringVar(&o.TargetAddress, "target-address", o.TargetAddress, "Vault address of the target, defaults to VAULT_ADDR. The token is read from VKV_DIFF_TARGET_TOKEN and defaults to the one of the source (env: VKV_DIFF_TARGET_ADDRESS)")
+	cmd.Flags().StringVar(&o.TargetNamespace, "target-namespace", o.TargetNamespace, "namespace of the target (env: VKV_DIFF_TARGET_NS)")
+
+	cmd.Flags().BoolVar(&o.SkipErrors, "skip-errors", o.SkipErrors, "don't exit on errors (permission denied, deleted secrets) (env: VKV_DIFF_SKIP_ERRORS)")
+	cmd.Flags().IntVar(&o.Concurrency, "concurrency", o.Concurrency, "maximum number of concurrent requests sent to Vault while reading secrets (env: VKV_CONCURRENCY)")
+
+	// Modify
+	cmd.Flags().BoolVar(&o.OnlyKeys, "only-keys", o.OnlyKeys, "show only keys (env: VKV_DIFF_ONLY_KEYS)")
+	cmd.Flags().BoolVar(&o.OnlyPaths, "only-paths", o.OnlyPaths, "show only paths (env: VKV_DIFF_ONLY_PATHS)")
+	cmd.Flags().BoolVar(&o.ShowValues, "show-values", o.ShowValues, "don't mask values (env: VKV_DIFF_SHOW_VALUES)")
+	cmd.Flags().IntVar(&o.MaxValueLength, "max-value-length", o.MaxValueLength, "maximum char length of values. Set to \"-1\" for disabling "+
+		"(env: VKV_DIFF_MAX_VALUE_LENGTH)")
+
+	// Output format
+	cmd.Flags().StringVarP(&o.FormatString, "format", "f", o.FormatString, "available output formats: \"base\", \"json\", \"yaml\", \"markdown\" "+
+		"(env: VKV_DIFF_FORMAT)")
+
+	return cmd
+}
+
+// nolint: cyclop
+func (o *diffOptions) validateFlags(cmd *cobra.Command, args []string) error {
+	switch {
+	case (o.OnlyKeys && o.ShowValues), (o.OnlyPaths && o.ShowValues), (o.OnlyKeys && o.OnlyPaths):
+		return errInvalidFlagCombination
+	case o.EnginePath == "" && o.Path == "":
+		return errors.New("no source KV-paths given. Either --engine-path/-e or --path/-p needs to be specified")
+	case o.TargetEnginePath == "" && o.TargetPath == "":
+		return errors.New("no target KV-paths given. Either --target-engine-path or --target-path/-t needs to be specified")
+	}
+
+	switch strings.ToLower(o.FormatString) {
+	case "yaml", "yml":
+		o.outputFormat = prt.YAML
+	case "json":
+		o.outputFormat = prt.JSON
+	case "markdown":
+		o.outputFormat = prt.Markdown
+	case "base":
+		o.outputFormat = prt.Base
+	default:
+		return fmt.Errorf("%w: diff only supports the \"base\", \"json\", \"yaml\" and \"markdown\" output formats", prt.ErrInvalidFormat)
+	}
+
+	return nil
+}
+
+// source builds one side of the comparison, cloning the vault client if a different address, token or namespace is requested.
+func (o *diffOptions) source(enginePath, subPath, addr, token, ns string) (*diffSource, error) {
+	rootPath, subPath := utils.HandleEnginePath(enginePath, subPath)
+
+	client := vaultClient
+
+	if addr != "" || token != "" || ns != "" {
+		c, err := vaultClient.Clone(addr, ns)
+		if err != nil {
+			return nil, err
+		}
+
+		if token != "" {
+			c.Client.SetToken(token)
+		}
+
+		client = c
+	}
+
+	label := utils.NormalizePath(path.Join(ns, rootPath, subPath))
+	if addr != "" {
+		label = fmt.Sprintf("%s/%s", strings.TrimSuffix(addr, utils.Delimiter), label)
+	}
+
+	return &diffSource{
+		client:   client,
+		rootPath: rootPath,
+		subPath:  subPath,
+		label:    label,
+	}, nil
+}
+
+// read returns the flattened secrets of one side of the comparison.
+func (o *diffOptions) read(s *diffSource) (map[string]map[string]interface{}, error) {
+	tree, err := s.client.ListSecretTree(rootContext, "", s.rootPath, s.subPath, o.SkipErrors, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return tree.FlattenRelative(), nil
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io"
+)
+
+func (s *VaultSuite) TestValidateDiffFlags() {
+	testCases := []struct {
+		name string
+		args []string
+		err  bool
+	}{
+		{
+			name: "source path missing",
+			args: []string{"-t=secret"},
+			err:  true,
+		},
+		{
+			name: "target path missing",
+			args: []string{"-p=secret"},
+			err:  true,
+		},
+		{
+			name: "invalid format",
+			args: []string{"-p=secret", "-t=secret", "-f=policy"},
+			err:  true,
+		},
+		{
+			name: "only keys and show values mutually exclusive",
+			args: []string{"-p=secret", "-t=secret", "--only-keys", "--show-values"},
+			err:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		cmd := NewDiffCmd()
+		cmd.SetArgs(tc.args)
+
+		err := cmd.Execute()
+
+		s.Require().Equal(tc.err, err != nil, tc.name)
+	}
+}
+
+func (s *VaultSuite) TestDiffCommand() {
+	s.Run("diff two engines", func() {
+		ctx := context.Background()
+
+		s.Require().NoError(vaultClient.EnableKV2Engine(ctx, "staging"))
+		s.Require().NoError(vaultClient.EnableKV2Engine(ctx, "prod"))
+
+		s.Require().NoError(vaultClient.WriteSecrets(ctx, "", "staging", "admin", map[string]interface{}{"sub": "password"}))
+		s.Require().NoError(vaultClient.WriteSecrets(ctx, "", "staging", "demo", map[string]interface{}{"foo": "bar"}))
+		s.Require().NoError(vaultClient.WriteSecrets(ctx, "", "staging", "same", map[string]interface{}{"key": "value"}))
+		s.Require().NoError(vaultClient.WriteSecrets(ctx, "", "prod", "admin", map[string]interface{}{"sub": "secret", "user": "root"}))
+		s.Require().NoError(vaultClient.WriteSecrets(ctx, "", "prod", "same", map[string]interface{}{"key": "value"}))
+
+		b := bytes.NewBufferString("")
+		writer = b
+
+		cmd := NewDiffCmd()
+		cmd.SetArgs([]string{"-p=staging", "-t=prod", "--show-values"})
+
+		s.Require().NoError(cmd.Execute())
+
+		out, _ := io.ReadAll(b)
+
+		s.Require().Equal(`staging/ -> prod/
+├── ~ admin
+│   ├── ~ sub=password -> secret
+│   └── + user=root
+└── - demo
+    └── - foo=bar
+`, string(out))
+	})
+}
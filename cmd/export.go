@@ -4,11 +4,12 @@ import (
 	"errors"
 	"fmt"
 	"log"
-	"path"
 	"strings"
+	"time"
 
 	prt "github.com/FalcoSuessgott/vkv/pkg/printer/secret"
 	"github.com/FalcoSuessgott/vkv/pkg/utils"
+	"github.com/FalcoSuessgott/vkv/pkg/vault"
 	"github.com/spf13/cobra"
 )
 
@@ -16,6 +17,10 @@ import (
 type exportOptions struct {
 	Path       string `env:"PATH"`
 	EnginePath string `env:"ENGINE_PATH"`
+	Namespace  string `env:"NS"`
+
+	AllEngines          bool `env:"ALL_ENGINES"`
+	RecursiveNamespaces bool `env:"RECURSIVE_NAMESPACES"`
 
 	OnlyKeys       bool `env:"ONLY_KEYS"`
 	OnlyPaths      bool `env:"ONLY_PATHS"`
@@ -27,7 +32,12 @@ type exportOptions struct {
 	WithHyperLink  bool `env:"WITH_HYPERLINK" envDefault:"true"`
 	MaxValueLength int  `env:"MAX_VALUE_LENGTH" envDefault:"12"`
 
-	SkipErrors bool `env:"SKIP_ERRORS" envDefault:"false"`
+	AsOf string `env:"AS_OF"`
+
+	WithMetadata bool `env:"WITH_METADATA"`
+
+	SkipErrors  bool `env:"SKIP_ERRORS" envDefault:"false"`
+	Concurrency int
 
 	TemplateFile   string `env:"TEMPLATE_FILE"`
 	TemplateString string `env:"TEMPLATE_STRING"`
@@ -35,6 +45,7 @@ type exportOptions struct {
 	FormatString string `env:"FORMAT" envDefault:"base"`
 
 	outputFormat prt.OutputFormat
+	asOf         time.Time
 }
 
 // NewExportCmd export subcommand.
@@ -47,6 +58,8 @@ func NewExportCmd() *cobra.Command {
 		log.Fatal(err)
 	}
 
+	o.Concurrency = vault.DefaultConcurrency()
+
 	cmd := &cobra.Command{
 		Use:           "export",
 		Short:         "recursively list secrets from Vaults KV2 engine in various formats",
@@ -56,7 +69,11 @@ func NewExportCmd() *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			enginePath, subPath := utils.HandleEnginePath(o.EnginePath, o.Path)
 
-			printer = prt.NewSecretPrinter(
+			vaultClient.SetConcurrency(o.Concurrency)
+
+			client := vaultClient.WithNamespace(o.Namespace)
+
+			secretPrinter := prt.NewSecretPrinter(
 				prt.OnlyKeys(o.OnlyKeys),
 				prt.OnlyPaths(o.OnlyPaths),
 				prt.MergePaths(o.MergePaths),
@@ -64,17 +81,29 @@ func NewExportCmd() *cobra.Command {
 				prt.ShowValues(o.ShowValues),
 				prt.WithTemplate(o.TemplateString, o.TemplateFile),
 				prt.ToFormat(o.outputFormat),
-				prt.WithVaultClient(vaultClient),
+				prt.WithVaultClient(client),
 				prt.WithWriter(writer),
 				prt.ShowVersion(o.ShowVersion),
 				prt.ShowMetadata(o.ShowMetadata),
 				prt.WithHyperLinks(o.WithHyperLink),
 				prt.WithEnginePath(utils.NormalizePath(enginePath)),
 				prt.WithContext(rootContext),
+				prt.AsOf(o.asOf),
 			)
 
+			printer = secretPrinter
+
+			if o.AllEngines {
+				secrets, err := o.listAllEngines()
+				if err != nil {
+					return err
+				}
+
+				return printer.Out(secrets)
+			}
+
 			if o.AllVersions {
-				vs, err := vaultClient.ListRecursiveAllVersions(rootContext, enginePath, subPath, o.SkipErrors)
+				vs, err := client.ListRecursiveAllVersions(rootContext, enginePath, subPath, o.SkipErrors)
 				if err != nil {
 					return err
 				}
@@ -82,28 +111,32 @@ func NewExportCmd() *cobra.Command {
 				return printer.Out(vs)
 			}
 
-			secrets, err := vaultClient.ListRecursive(rootContext, enginePath, subPath, o.SkipErrors)
+			tree, err := o.listSecrets(enginePath, subPath)
 			if err != nil {
 				return err
 			}
 
+			metadata := tree.SecretsMetadata()
+
+			prt.Update(secretPrinter, prt.WithSecretsMetadata(metadata))
+
 			// yaml/json use flat, full-path keys (no engine root) for consistency
 			// with --all-versions and to keep them re-importable
 			if o.outputFormat == prt.YAML || o.outputFormat == prt.JSON {
 				flat := make(map[string]interface{})
-				utils.FlattenMap(utils.ToMapStringInterface(secrets), flat, subPath)
+				for p, secret := range tree.Flatten() {
+					flat[p] = secret
+				}
 
-				return printer.Out(flat)
-			}
+				// the metadata is kept in a reserved key, so that vkv import can restore it
+				if settings := metadata.SettingsOf(); o.WithMetadata && len(settings) > 0 {
+					flat[vault.MetadataKey] = settings
+				}
 
-			p := path.Join(enginePath, subPath)
-			if subPath == "" {
-				p = utils.NormalizePath(p)
+				return printer.Out(flat)
 			}
 
-			result := utils.UnflattenMap(p, utils.ToMapStringInterface(secrets), o.EnginePath)
-
-			if err := printer.Out(result); err != nil {
+			if err := printer.Out(tree); err != nil {
 				return err
 			}
 
@@ -116,13 +149,19 @@ func NewExportCmd() *cobra.Command {
 	// Input
 	cmd.Flags().StringVarP(&o.Path, "path", "p", o.Path, "KV Engine path (env: VKV_EXPORT_PATH")
 	cmd.Flags().StringVarP(&o.EnginePath, "engine-path", "e", o.EnginePath, "engine path in case your KV-engine contains special characters such as \"/\", the path (-p) flag will then be appended if specified (\"<engine-path>/<path>\") (env: VKV_EXPORT_ENGINE_PATH)")
+	cmd.Flags().StringVarP(&o.Namespace, "namespace", "n", o.Namespace, "namespace of the KV engine, or the namespace whose engines are exported when using --all-engines (env: VKV_EXPORT_NS)")
+	cmd.Flags().BoolVar(&o.AllEngines, "all-engines", o.AllEngines, "export all visible KV engines of the namespace as a single document (env: VKV_EXPORT_ALL_ENGINES)")
+	cmd.Flags().BoolVar(&o.RecursiveNamespaces, "recursive-namespaces", o.RecursiveNamespaces, "include the KV engines of all child namespaces when using --all-engines (env: VKV_EXPORT_RECURSIVE_NAMESPACES)")
 	cmd.Flags().BoolVar(&o.SkipErrors, "skip-errors", o.SkipErrors, "don't exit on errors (permission denied, deleted secrets) (env: VKV_EXPORT_SKIP_ERRORS)")
+	cmd.Flags().IntVar(&o.Concurrency, "concurrency", o.Concurrency, "maximum number of concurrent requests sent to Vault while reading secrets (env: VKV_CONCURRENCY)")
 
 	// Modify
 	cmd.Flags().BoolVar(&o.OnlyKeys, "only-keys", o.OnlyKeys, "show only keys (env: VKV_EXPORT_ONLY_KEYS)")
 	cmd.Flags().BoolVar(&o.OnlyPaths, "only-paths", o.OnlyPaths, "show only paths (env: VKV_EXPORT_ONLY_PATHS)")
 	cmd.Flags().BoolVar(&o.MergePaths, "merge-paths", o.MergePaths, "merge paths (env: VKV_EXPORT_MERGE_PATHS)")
 	cmd.Flags().BoolVar(&o.AllVersions, "all-versions", o.AllVersions, "export all versions of each KVv2 secret (base, json and yaml formats) (env: VKV_EXPORT_ALL_VERSIONS)")
+	cmd.Flags().StringVar(&o.AsOf, "as-of", o.AsOf, "export each KVv2 secret in the version that was current at the given RFC3339 timestamp, e.g. \"2026-09-01T00:00:00Z\" (env: VKV_EXPORT_AS_OF)")
+	cmd.Flags().BoolVar(&o.WithMetadata, "with-metadata", o.WithMetadata, "include the custom metadata and settings (max_versions, cas_required, delete_version_after) of KVv2 secrets, which vkv import restores (json and yaml formats) (env: VKV_EXPORT_WITH_METADATA)")
 	cmd.Flags().BoolVar(&o.ShowVersion, "show-version", o.ShowVersion, "show the secret version (env: VKV_EXPORT_VERSION)")
 	cmd.Flags().BoolVar(&o.ShowMetadata, "show-metadata", o.ShowMetadata, "show the secrets metadata (env: VKV_EXPORT_METADATA)")
 	cmd.Flags().BoolVar(&o.ShowValues, "show-values", o.ShowValues, "don't mask values (env: VKV_EXPORT_SHOW_VALUES)")
@@ -143,6 +182,46 @@ func NewExportCmd() *cobra.Command {
 	return cmd
 }
 
+// listSecrets reads the secrets recursively. The secrets metadata is read within the same
+// traversal, if the output format displays versions or metadata.
+func (o *exportOptions) listSecrets(enginePath, subPath string) (*vault.SecretTree, error) {
+	if !o.asOf.IsZero() {
+		return vaultClient.WithNamespace(o.Namespace).ListSecretTreeAsOf(rootContext, enginePath, subPath, o.asOf, o.SkipErrors)
+	}
+
+	return vaultClient.ListSecretTree(rootContext, o.Namespace, enginePath, subPath, o.SkipErrors, o.withMetadata())
+}
+
+// listAllEngines reads the secrets of all visible KV engines of the namespace
+// and, if enabled, of all its child namespaces.
+func (o *exportOptions) listAllEngines() (vault.AllEngineSecrets, error) {
+	var (
+		engines vault.Engines
+		err     error
+	)
+
+	if o.RecursiveNamespaces {
+		engines, err = vaultClient.ListAllKVSecretEngines(rootContext, o.Namespace)
+	} else {
+		var e []string
+
+		e, err = vaultClient.ListKVSecretEngines(rootContext, o.Namespace)
+		engines = vault.Engines{o.Namespace: e}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return vaultClient.ListRecursiveAllEngines(rootContext, engines, o.SkipErrors, o.withMetadata())
+}
+
+// withMetadata reports whether the output format displays the secrets versions or metadata,
+// or the metadata is exported.
+func (o *exportOptions) withMetadata() bool {
+	return ((o.outputFormat == prt.Base || o.outputFormat == prt.Markdown) && (o.ShowVersion || o.ShowMetadata)) || o.WithMetadata
+}
+
 // isAllVersionsFormat reports whether the format supports --all-versions.
 func isAllVersionsFormat(format string) bool {
 	switch strings.ToLower(format) {
@@ -153,18 +232,49 @@ func isAllVersionsFormat(format string) bool {
 	}
 }
 
+// isMetadataFormat reports whether the format supports --with-metadata.
+func isMetadataFormat(format string) bool {
+	switch strings.ToLower(format) {
+	case "json", "yaml", "yml":
+		return true
+	default:
+		return false
+	}
+}
+
 // nolint: cyclop, goconst
 func (o *exportOptions) validateFlags(cmd *cobra.Command, args []string) error {
 	switch {
 	case (o.OnlyKeys && o.ShowValues), (o.OnlyPaths && o.ShowValues), (o.OnlyKeys && o.OnlyPaths):
 		return errInvalidFlagCombination
-	case o.EnginePath == "" && o.Path == "":
-		return errors.New("no KV-paths given. Either --engine-path/-e or --path/-p needs to be specified")
+	case o.AllEngines && (o.EnginePath != "" || o.Path != ""):
+		return fmt.Errorf("%w: --all-engines cannot be combined with --engine-path or --path", errInvalidFlagCombination)
+	case o.AllEngines && (o.AllVersions || o.MergePaths):
+		return fmt.Errorf("%w: --all-engines cannot be combined with --all-versions or --merge-paths", errInvalidFlagCombination)
+	case o.AsOf != "" && (o.AllVersions || o.AllEngines):
+		return fmt.Errorf("%w: --as-of cannot be combined with --all-versions or --all-engines", errInvalidFlagCombination)
+	case o.WithMetadata && (o.AllVersions || o.AllEngines || o.AsOf != ""):
+		return fmt.Errorf("%w: --with-metadata cannot be combined with --all-versions, --all-engines or --as-of", errInvalidFlagCombination)
+	case o.WithMetadata && !isMetadataFormat(o.FormatString):
+		return fmt.Errorf("%w: --with-metadata only supports the \"json\" and \"yaml\" output formats", errInvalidFlagCombination)
+	case o.RecursiveNamespaces && !o.AllEngines:
+		return fmt.Errorf("%w: --recursive-namespaces requires --all-engines", errInvalidFlagCombination)
+	case !o.AllEngines && o.EnginePath == "" && o.Path == "":
+		return errors.New("no KV-paths given. Either --engine-path/-e, --path/-p or --all-engines needs to be specified")
 	case o.AllVersions && !isAllVersionsFormat(o.FormatString):
 		return fmt.Errorf("%w: --all-versions only supports the \"base\", \"json\" and \"yaml\" output formats", errInvalidFlagCombination)
 	case o.AllVersions && (o.MergePaths || o.OnlyPaths):
 		return fmt.Errorf("%w: --all-versions cannot be combined with --merge-paths or --only-paths", errInvalidFlagCombination)
 	case true:
+		if o.AsOf != "" {
+			t, err := time.Parse(time.RFC3339, o.AsOf)
+			if err != nil {
+				return fmt.Errorf("invalid --as-of timestamp %q, expected RFC3339 (e.g. \"2026-09-01T00:00:00Z\"): %w", o.AsOf, err)
+			}
+
+			o.asOf = t
+		}
+
 		switch strings.ToLower(o.FormatString) {
 		case "yaml", "yml":
 			o.outputFormat = prt.YAML
@@ -52,6 +52,41 @@ func (s *VaultSuite) TestValidateExportFlags() {
 			args: []string{"-p=1", "--all-versions", "--only-paths"},
 			err:  true,
 		},
+		{
+			name: "all-engines and path mutually exclusive",
+			args: []string{"-p=1", "--all-engines"},
+			err:  true,
+		},
+		{
+			name: "all-engines and all-versions mutually exclusive",
+			args: []string{"--all-engines", "--all-versions"},
+			err:  true,
+		},
+		{
+			name: "recursive-namespaces requires all-engines",
+			args: []string{"-p=1", "--recursive-namespaces"},
+			err:  true,
+		},
+		{
+			name: "as-of requires a RFC3339 timestamp",
+			args: []string{"-p=1", "--as-of=yesterday"},
+			err:  true,
+		},
+		{
+			name: "as-of and all-versions mutually exclusive",
+			args: []string{"-p=1", "--as-of=2026-09-01T00:00:00Z", "--all-versions"},
+			err:  true,
+		},
+		{
+			name: "with-metadata rejects base format",
+			args: []string{"-p=1", "--with-metadata"},
+			err:  true,
+		},
+		{
+			name: "with-metadata and all-versions mutually exclusive",
+			args: []string{"-p=1", "-f=json", "--with-metadata", "--all-versions"},
+			err:  true,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -165,9 +200,9 @@ func (s *VaultSuite) TestExportAllVersions() {
 		s.Require().NoError(vaultClient.EnableKV2Engine(ctx, "versions"))
 
 		// write two versions of the same secret + a nested secret
-		s.Require().NoError(vaultClient.WriteSecrets(ctx, "versions", "admin", map[string]interface{}{"user": "v1"}))
-		s.Require().NoError(vaultClient.WriteSecrets(ctx, "versions", "admin", map[string]interface{}{"user": "v2"}))
-		s.Require().NoError(vaultClient.WriteSecrets(ctx, "versions", "sub/demo", map[string]interface{}{"foo": "bar"}))
+		s.Require().NoError(vaultClient.WriteSecrets(ctx, "", "versions", "admin", map[string]interface{}{"user": "v1"}))
+		s.Require().NoError(vaultClient.WriteSecrets(ctx, "", "versions", "admin", map[string]interface{}{"user": "v2"}))
+		s.Require().NoError(vaultClient.WriteSecrets(ctx, "", "versions", "sub/demo", map[string]interface{}{"foo": "bar"}))
 
 		b := bytes.NewBufferString("")
 		writer = b
@@ -206,12 +241,47 @@ func (s *VaultSuite) TestExportAllVersions() {
 	})
 }
 
+func (s *VaultSuite) TestExportAllEngines() {
+	s.Run("export all engines", func() {
+		ctx := context.Background()
+
+		s.Require().NoError(vaultClient.EnableKV2Engine(ctx, "inventory-a"))
+		s.Require().NoError(vaultClient.EnableKV1Engine(ctx, "inventory-b"))
+		s.Require().NoError(vaultClient.WriteSecrets(ctx, "", "inventory-a", "sub/admin", map[string]interface{}{"user": "admin"}))
+		s.Require().NoError(vaultClient.WriteSecrets(ctx, "", "inventory-b", "demo", map[string]interface{}{"foo": "bar"}))
+
+		b := bytes.NewBufferString("")
+		writer = b
+
+		exportCmd := NewExportCmd()
+		exportCmd.SetArgs([]string{"--all-engines", "--skip-errors", "-f=json"})
+
+		s.Require().NoError(exportCmd.Execute())
+
+		var parsed map[string]map[string]map[string]map[string]interface{}
+		s.Require().NoError(json.Unmarshal(b.Bytes(), &parsed), "all-engines JSON must be valid")
+		s.Require().Equal("admin", parsed["root"]["inventory-a"]["sub/admin"]["user"])
+		s.Require().Equal("bar", parsed["root"]["inventory-b"]["demo"]["foo"])
+
+		// base tree lists every engine below the namespace
+		b.Reset()
+
+		baseCmd := NewExportCmd()
+		baseCmd.SetArgs([]string{"--all-engines", "--skip-errors", "--with-hyperlink=false"})
+
+		s.Require().NoError(baseCmd.Execute())
+		s.Require().Contains(b.String(), "root [namespace]")
+		s.Require().Contains(b.String(), "inventory-a/")
+		s.Require().Contains(b.String(), "inventory-b/")
+	})
+}
+
 func (s *VaultSuite) TestExportAllVersionsKVv1() {
 	s.Run("export all versions on a KVv1 engine errors", func() {
 		ctx := context.Background()
 
 		s.Require().NoError(vaultClient.EnableKV1Engine(ctx, "kvv1"))
-		s.Require().NoError(vaultClient.WriteSecrets(ctx, "kvv1", "admin", map[string]interface{}{"user": "v1"}))
+		s.Require().NoError(vaultClient.WriteSecrets(ctx, "", "kvv1", "admin", map[string]interface{}{"user": "v1"}))
 
 		writer = io.Discard
 
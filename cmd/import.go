@@ -6,29 +6,56 @@ import (
 	"io"
 	"log"
 	"path"
+	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/FalcoSuessgott/vkv/pkg/fs"
 	prt "github.com/FalcoSuessgott/vkv/pkg/printer/secret"
 	"github.com/FalcoSuessgott/vkv/pkg/utils"
+	"github.com/FalcoSuessgott/vkv/pkg/vault"
 	"github.com/spf13/cobra"
 )
 
+const (
+	mergeStrategyReplace      = "replace"
+	mergeStrategyPatch        = "patch"
+	mergeStrategyKeepExisting = "keep-existing"
+)
+
 type importOptions struct {
 	EnginePath string `env:"ENGINE_PATH"`
 	Path       string `env:"PATH"`
 
 	File string `env:"FILE"`
 
+	PlanFile  string `env:"PLAN_FILE"`
+	ApplyPlan string `env:"APPLY_PLAN"`
+
+	Prune        bool `env:"PRUNE"`
+	MaxDeletions int  `env:"MAX_DELETIONS" envDefault:"10"`
+	CASRetries   int  `env:"CAS_RETRIES"`
+
+	MergeStrategy string `env:"MERGE_STRATEGY" envDefault:"replace"`
+
+	ReplayDeletions bool `env:"REPLAY_DELETIONS"`
+
 	Force          bool `env:"FORCE"`
 	DryRun         bool `env:"DRY_RUN"`
 	Silent         bool `env:"SILENT"`
 	ShowValues     bool `env:"SHOW_VALUES"`
 	MaxValueLength int  `env:"MAX_VALUE_LENGTH" envDefault:"12"`
 
-	SkipErrors bool `env:"SKIP_ERRORS" envDefault:"false"`
+	SkipErrors  bool `env:"SKIP_ERRORS" envDefault:"false"`
+	Concurrency int
 
 	input io.Reader
+
+	// settings holds the metadata of the secrets to be imported keyed by their path within the KV engine
+	settings map[string]*vault.SecretSettings
+	// versions holds the versions of the existing secrets a plan has been created from keyed by their path,
+	// nil unless a plan is applied
+	versions map[string]int
 }
 
 // NewImportCmd import subcommand.
@@ -40,6 +67,8 @@ func NewImportCmd() *cobra.Command {
 		log.Fatal(err)
 	}
 
+	o.Concurrency = vault.DefaultConcurrency()
+
 	cmd := &cobra.Command{
 		Use:           "import",
 		Short:         "import secrets from vkv's export json or yaml output",
@@ -47,6 +76,13 @@ func NewImportCmd() *cobra.Command {
 		SilenceErrors: true,
 		PreRunE:       o.validateFlags,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			vaultClient.SetConcurrency(o.Concurrency)
+
+			// apply a previously created plan instead of reading any input
+			if o.ApplyPlan != "" {
+				return o.applyPlan()
+			}
+
 			// get user input via -f or STDIN
 			input, err := o.getInput()
 			if err != nil {
@@ -59,6 +95,21 @@ func NewImportCmd() *cobra.Command {
 				return err
 			}
 
+			// exports created using --all-versions are replayed version by version
+			if vault.IsVersionedSecrets(secrets) {
+				return o.importVersions(secrets)
+			}
+
+			// the metadata is not a secret, it is written after the secrets
+			settings, err := o.extractSettings(secrets)
+			if err != nil {
+				return err
+			}
+
+			if err := checkSecrets(secrets); err != nil {
+				return err
+			}
+
 			// if no path specified, use the path from the secrets to be imported
 			if o.EnginePath == "" && o.Path == "" {
 				fmt.Fprintln(writer, "no path specified, trying to determine root path from the provided input")
@@ -78,24 +129,22 @@ func NewImportCmd() *cobra.Command {
 				}
 			}
 
-			// read existing secrets from the rootPath
 			rootPath, subPath := utils.HandleEnginePath(o.EnginePath, o.Path)
 
-			printer = prt.NewSecretPrinter(
-				prt.CustomValueLength(o.MaxValueLength),
-				prt.ShowValues(o.ShowValues),
-				prt.ToFormat(prt.Base),
-				prt.WithVaultClient(vaultClient),
-				prt.WithWriter(writer),
-				prt.ShowVersion(true),
-				prt.ShowMetadata(true),
-				prt.WithEnginePath(utils.NormalizePath(rootPath)),
-				prt.WithContext(rootContext),
-			)
-
-			// print preview during dry run and exit
+			o.setPrinter(rootPath)
+
+			desired := o.desiredSecrets(subPath, secrets)
+
+			o.settings = make(map[string]*vault.SecretSettings, len(settings))
+			for p, s := range settings {
+				o.settings[path.Join(subPath, p)] = s
+			}
+
+			o.skipSettingsOfKVv1(rootPath)
+
+			// print the plan during dry run and exit
 			if o.DryRun {
-				return o.dryRun(rootPath, o.rerootSecrets(rootPath, subPath, secrets))
+				return o.plan(rootPath, subPath, desired)
 			}
 
 			// enable kv engine, error if already enabled, unless force is used
@@ -103,24 +152,27 @@ func NewImportCmd() *cobra.Command {
 				return err
 			}
 
-			// write secrets
-			if err := o.writeSecrets(rootPath, subPath, secrets); err != nil {
+			if !o.Prune {
+				return o.importSecrets(rootPath, desired, nil)
+			}
+
+			// the secrets to be deleted are always shown before pruning them
+			plan, err := o.newPlan(rootPath, subPath, desired)
+			if err != nil {
 				return err
 			}
 
-			// show result if not silence mode
-			if !o.Silent {
-				result, err := o.printResult(rootPath)
-				if err != nil {
-					return err
-				}
+			if err := o.printPlan(plan); err != nil {
+				return err
+			}
 
-				if err := printer.Out(result); err != nil {
-					return err
-				}
+			if err := o.checkDeletions(len(plan.deletions())); err != nil {
+				return err
 			}
 
-			return nil
+			fmt.Fprintln(writer, "")
+
+			return o.importSecrets(rootPath, plan.desired(), plan.deletions())
 		},
 	}
 
@@ -129,10 +181,18 @@ func NewImportCmd() *cobra.Command {
 	cmd.Flags().StringVarP(&o.EnginePath, "engine-path", "e", o.EnginePath, "engine path in case your KV-engine contains special characters such as \"/\", the path (-p) flag will then be appended if specified (\"<engine-path>/<path>\") (env: VKV_IMPORT_PATH)")
 	cmd.Flags().StringVarP(&o.File, "file", "f", o.File, "path to a file containing vkv export json or yaml output (env: VKV_IMPORT_FILE)")
 	cmd.Flags().BoolVar(&o.SkipErrors, "skip-errors", o.SkipErrors, "don't exit on errors (permission denied, ...) (env: VKV_EXPORT_SKIP_ERRORS)")
+	cmd.Flags().IntVar(&o.Concurrency, "concurrency", o.Concurrency, "maximum number of concurrent requests sent to Vault while reading secrets (env: VKV_CONCURRENCY)")
 
 	// Options
 	cmd.Flags().BoolVar(&o.Force, "force", o.Force, "overwrite existing kv secrets (env: VKV_IMPORT_FORCE)")
-	cmd.Flags().BoolVarP(&o.DryRun, "dry-run", "d", o.DryRun, "print resulting KV secrets (env: VKV_IMPORT_DRY_RUN)")
+	cmd.Flags().BoolVarP(&o.DryRun, "dry-run", "d", o.DryRun, "print the changes to the KV secrets without applying them (env: VKV_IMPORT_DRY_RUN)")
+	cmd.Flags().StringVar(&o.PlanFile, "plan-file", o.PlanFile, "save the changes printed during --dry-run to the given file (env: VKV_IMPORT_PLAN_FILE)")
+	cmd.Flags().StringVar(&o.ApplyPlan, "apply-plan", o.ApplyPlan, "apply the changes of a plan file created using --plan-file, fails if the secrets changed since (env: VKV_IMPORT_APPLY_PLAN)")
+	cmd.Flags().BoolVar(&o.Prune, "prune", o.Prune, "delete all secrets below the destination path that are not present in the input, requires --merge-strategy=replace (env: VKV_IMPORT_PRUNE)")
+	cmd.Flags().IntVar(&o.MaxDeletions, "max-deletions", o.MaxDeletions, "maximum number of secrets --prune may delete. Set to \"-1\" for disabling (env: VKV_IMPORT_MAX_DELETIONS)")
+	cmd.Flags().IntVar(&o.CASRetries, "cas-retries", o.CASRetries, "number of times a secret that has been modified concurrently is read, compared and written again (env: VKV_IMPORT_CAS_RETRIES)")
+	cmd.Flags().StringVar(&o.MergeStrategy, "merge-strategy", o.MergeStrategy, "how existing secrets are updated: \"replace\" overwrites the whole secret, \"patch\" only changes the keys of the input and \"keep-existing\" only adds keys that do not exist yet (env: VKV_IMPORT_MERGE_STRATEGY)")
+	cmd.Flags().BoolVar(&o.ReplayDeletions, "replay-deletions", o.ReplayDeletions, "when importing an --all-versions export, write deleted and destroyed versions without data and delete or destroy them again, so the version numbers are preserved (env: VKV_IMPORT_REPLAY_DELETIONS)")
 	cmd.Flags().BoolVarP(&o.Silent, "silent", "s", o.Silent, "do not output secrets (env: VKV_IMPORT_SILENT)")
 	cmd.Flags().BoolVar(&o.ShowValues, "show-values", o.ShowValues, "don't mask values (env: VKV_IMPORT_SHOW_VALUES)")
 	cmd.Flags().IntVar(&o.MaxValueLength, "max-value-length", o.MaxValueLength, "maximum char length of values. Set to \"-1\" for disabling "+
@@ -149,6 +209,18 @@ func (o *importOptions) validateFlags(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("%w: %s", errInvalidFlagCombination, "cannot specify both --force and --dry-run")
 	case o.Silent && o.DryRun:
 		return fmt.Errorf("%w: %s", errInvalidFlagCombination, "cannot specify both --silent and --dry-run")
+	case o.Prune && o.ApplyPlan != "":
+		return fmt.Errorf("%w: %s", errInvalidFlagCombination, "cannot specify both --prune and --apply-plan, the plan already contains the secrets to be deleted")
+	case o.MergeStrategy != mergeStrategyReplace && o.MergeStrategy != mergeStrategyPatch && o.MergeStrategy != mergeStrategyKeepExisting:
+		return fmt.Errorf("invalid merge strategy \"%s\" (valid options: replace, patch, keep-existing)", o.MergeStrategy)
+	case o.Prune && o.MergeStrategy != mergeStrategyReplace:
+		return fmt.Errorf("%w: %s", errInvalidFlagCombination, "--prune requires --merge-strategy=replace, since other strategies keep the keys not present in the input")
+	case o.CASRetries < 0:
+		return errors.New("--cas-retries needs to be a positive number")
+	case o.PlanFile != "" && !o.DryRun:
+		return fmt.Errorf("%w: %s", errInvalidFlagCombination, "--plan-file requires --dry-run")
+	case o.ApplyPlan != "" && (o.DryRun || o.File != "" || o.Path != "" || o.EnginePath != "" || len(args) > 0):
+		return fmt.Errorf("%w: %s", errInvalidFlagCombination, "--apply-plan cannot be used with --dry-run, an input or a destination path")
 	case len(args) > 0:
 		if o.File != "" && args[0] == "-" {
 			return fmt.Errorf("%w: %s", errInvalidFlagCombination, "cannot specify both --file and read from STDIN")
@@ -202,10 +274,14 @@ func (o *importOptions) parseInput(input []byte) (map[string]interface{}, error)
 	return json, nil
 }
 
-func (o *importOptions) writeSecrets(rootPath, subPath string, secrets map[string]interface{}) error {
-	transformedMap := make(map[string]interface{})
-	utils.FlattenMap(secrets, transformedMap, "")
+// importStats counts the outcome of an import per secret.
+type importStats struct {
+	created, updated, unchanged, deleted, failed int
+}
 
+// desiredSecrets returns the parsed secrets keyed by their path within the KV engine,
+// handling both flat and legacy nested (engine-rooted) input.
+func (o *importOptions) desiredSecrets(subPath string, secrets map[string]interface{}) map[string]map[string]interface{} {
 	// nested (legacy) exports are rooted at the engine path (e.g. "secret/"); flat
 	// exports have no such root. Only strip an engine-like root from the paths.
 	rootPrefix := ""
@@ -213,12 +289,10 @@ func (o *importOptions) writeSecrets(rootPath, subPath string, secrets map[strin
 		rootPrefix = root
 	}
 
-	for p, m := range transformedMap {
-		secret, ok := m.(map[string]interface{})
-		if !ok {
-			log.Fatalf("cannot convert %T to map[string]interface", secret)
-		}
+	flat := vault.SecretTreeFromMap("", secrets).Flatten()
+	desired := make(map[string]map[string]interface{}, len(flat))
 
+	for p, secret := range flat {
 		// replace original path with the new engine path
 		newSubPath := strings.TrimPrefix(p, rootPrefix)
 
@@ -227,87 +301,314 @@ func (o *importOptions) writeSecrets(rootPath, subPath string, secrets map[strin
 			newSubPath = path.Join(subPath, newSubPath)
 		}
 
-		if err := vaultClient.WriteSecrets(rootContext, rootPath, newSubPath, secret); !o.SkipErrors && err != nil {
-			return fmt.Errorf("error writing secret \"%s\": %w", p, err)
-		}
+		desired[newSubPath] = secret
+	}
+
+	return desired
+}
+
+// importResult describes the outcome of importing a single secret.
+type importResult int
 
-		fmt.Fprintf(writer, "writing secret \"%s\" \n", path.Join(rootPath, newSubPath))
+const (
+	importCreated importResult = iota
+	importUpdated
+	importUnchanged
+)
+
+// writeSecrets writes the secrets that do not exist yet or differ from the existing ones.
+func (o *importOptions) writeSecrets(rootPath string, desired map[string]map[string]interface{}, stats *importStats) error {
+	paths := make([]string, 0, len(desired))
+	for p := range desired {
+		paths = append(paths, p)
 	}
 
-	fmt.Fprintln(writer, "successfully imported all secrets")
+	sort.Strings(paths)
+
+	for _, p := range paths {
+		res, err := o.writeSecret(rootPath, p, desired[p])
+		if err != nil {
+			if !o.SkipErrors {
+				return fmt.Errorf("error writing secret \"%s\": %w", p, err)
+			}
+
+			stats.failed++
+
+			fmt.Fprintf(writer, "error writing secret \"%s\": %v\n", path.Join(rootPath, p), err)
+
+			continue
+		}
+
+		switch res {
+		case importUnchanged:
+			stats.unchanged++
+
+			fmt.Fprintf(writer, "skipping unchanged secret \"%s\"\n", path.Join(rootPath, p))
+		case importUpdated:
+			stats.updated++
+
+			fmt.Fprintf(writer, "updating secret \"%s\"\n", path.Join(rootPath, p))
+		case importCreated:
+			stats.created++
+
+			fmt.Fprintf(writer, "writing secret \"%s\"\n", path.Join(rootPath, p))
+		}
+
+		if err := o.writeSettings(rootPath, p); err != nil {
+			if !o.SkipErrors {
+				return fmt.Errorf("error writing metadata of secret \"%s\": %w", p, err)
+			}
+
+			stats.failed++
+
+			fmt.Fprintf(writer, "error writing metadata of secret \"%s\": %v\n", path.Join(rootPath, p), err)
+		}
+	}
 
 	return nil
 }
 
-// rerootSecrets rebuilds a nested map of the parsed secrets rooted under the
-// target rootPath/subPath, handling both flat and legacy nested (engine-rooted) input.
-func (o *importOptions) rerootSecrets(rootPath, subPath string, secrets map[string]interface{}) map[string]interface{} {
-	flat := make(map[string]interface{})
-	utils.FlattenMap(secrets, flat, "")
+// writeSettings writes the imported metadata of a secret, unless it is unchanged.
+func (o *importOptions) writeSettings(rootPath, subPath string) error {
+	changed, err := o.settingsChanged(rootPath, subPath)
+	if err != nil || !changed {
+		return err
+	}
 
-	rootPrefix := ""
-	if root, err := utils.GetRootElement(secrets); err == nil && strings.HasSuffix(root, utils.Delimiter) {
-		rootPrefix = root
+	if err := vaultClient.WriteSecretSettings(rootContext, "", rootPath, subPath, o.settings[subPath]); err != nil {
+		return err
 	}
 
-	result := make(map[string]interface{})
+	fmt.Fprintf(writer, "updating metadata of secret \"%s\"\n", path.Join(rootPath, subPath))
+
+	return nil
+}
+
+// skipSettingsOfKVv1 drops the imported metadata with a warning, if the secrets are imported into a KVv1 engine,
+// since KVv1 secrets have no metadata.
+func (o *importOptions) skipSettingsOfKVv1(rootPath string) {
+	if len(o.settings) == 0 {
+		return
+	}
 
-	for p, m := range flat {
-		sub := strings.TrimPrefix(p, rootPrefix)
-		full := utils.NormalizePath(path.Join(rootPath, subPath, sub))
-		result = utils.DeepMergeMaps(result, utils.UnflattenMap(full, utils.ToMapStringInterface(m), o.EnginePath))
+	// an engine that does not exist yet is enabled as KVv2 engine
+	if isV1, err := vaultClient.IsKVv1(rootContext, rootPath); err != nil || !isV1 {
+		return
 	}
 
-	return result
+	fmt.Fprintf(writer, "warning: \"%s\" is a KVv1 engine, skipping the metadata of %d secrets\n", utils.NormalizePath(rootPath), len(o.settings))
+
+	o.settings = nil
 }
 
-func (o *importOptions) dryRun(rootPath string, secrets map[string]interface{}) error {
-	fmt.Printf("fetching any existing KV secrets from \"%s\" (if any)\n", utils.NormalizePath(rootPath))
+// settingsChanged reports whether the imported metadata of a secret differs from its current metadata.
+// Secrets without imported metadata keep their metadata.
+func (o *importOptions) settingsChanged(rootPath, subPath string) (bool, error) {
+	settings, ok := o.settings[subPath]
+	if !ok {
+		return false, nil
+	}
 
-	tmp, err := vaultClient.ListRecursive(rootContext, rootPath, "", true)
+	md, err := vaultClient.ReadMetadata(rootContext, rootPath, subPath)
 	if err != nil {
-		return fmt.Errorf("error listing secrets from \"%s/\": %w", rootPath, err)
+		// the secret has not been written yet
+		return true, nil //nolint: nilerr
 	}
 
-	if len(utils.ToMapStringInterface(tmp)) == 0 {
-		fmt.Println("no secrets found - nothing to compare with")
+	return !md.Settings().Equal(settings), nil
+}
+
+// extractSettings removes the reserved metadata key from the parsed input and returns its settings.
+func (o *importOptions) extractSettings(secrets map[string]interface{}) (map[string]*vault.SecretSettings, error) {
+	m, ok := secrets[vault.MetadataKey]
+	if !ok {
+		return nil, nil
 	}
 
-	existingSecrets := utils.UnflattenMap(utils.NormalizePath(rootPath), utils.ToMapStringInterface(tmp), o.EnginePath)
+	delete(secrets, vault.MetadataKey)
+
+	return vault.ParseSecretSettings(m)
+}
+
+// checkSecrets returns an error, if a key of the parsed input or of one of its directories is neither a secret nor a directory,
+// since the input is keyed by the paths of the secrets. Without this check, the key-value pairs of a single secret would be silently skipped.
+func checkSecrets(secrets map[string]interface{}) error {
+	for _, k := range utils.SortMapKeys(secrets) {
+		m, ok := secrets[k].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("invalid input: the value of \"%s\" is not a secret, the input has to be keyed by the paths of the secrets", k)
+		}
 
-	// check whether new and existing secrets are equal
-	if fmt.Sprint(secrets) == fmt.Sprint(existingSecrets) {
-		fmt.Fprintln(writer, "")
-		fmt.Fprintln(writer, "input matches secrets - no changes needed:")
-		fmt.Fprintln(writer, "")
+		if !strings.HasSuffix(k, utils.Delimiter) {
+			continue
+		}
 
-		if err := printer.Out(existingSecrets); err != nil {
+		if err := checkSecrets(m); err != nil {
 			return err
 		}
+	}
+
+	return nil
+}
+
+// writeSecret writes a secret unless it is unchanged. KVv2 secrets are written using check-and-set
+// with the version that has been compared, if the secret has been modified concurrently, the secret
+// is read and compared again up to --cas-retries times. Secrets of an applied plan are written using
+// the version the plan has been created from and are never retried.
+// nolint: cyclop
+func (o *importOptions) writeSecret(rootPath, subPath string, secret map[string]interface{}) (importResult, error) {
+	planned, fromPlan := o.versions[subPath]
+
+	for attempt := 0; ; attempt++ {
+		existing, version, err := vaultClient.ReadSecretsVersion(rootContext, "", rootPath, subPath)
+		if err != nil {
+			return 0, err
+		}
 
-		return nil
+		if fromPlan {
+			if version != planned {
+				return 0, fmt.Errorf("%w: \"%s\" (planned version %d, current version %d), create a new plan using --dry-run",
+					errStalePlan, path.Join(rootPath, subPath), planned, version)
+			}
+		}
+
+		merged := mergeSecret(o.MergeStrategy, existing, secret)
+
+		if existing != nil && secretsEqual(existing, merged) {
+			return importUnchanged, nil
+		}
+
+		changed := changedKeys(existing, merged)
+
+		// only the changed keys are sent, so keys written concurrently by others are preserved. A JSON merge patch
+		// merges object values instead of replacing them, such secrets are written as a whole using check-and-set.
+		if existing != nil && o.MergeStrategy != mergeStrategyReplace && !hasObjectValues(changed) {
+			err = vaultClient.PatchSecrets(rootContext, "", rootPath, subPath, changed, version)
+		} else {
+			err = vaultClient.WriteSecretsCAS(rootContext, "", rootPath, subPath, merged, version)
+		}
+		if errors.Is(err, vault.ErrCASConflict) && fromPlan {
+			return 0, fmt.Errorf("%w: %w", errStalePlan, err)
+		}
+
+		if errors.Is(err, vault.ErrCASConflict) && attempt < o.CASRetries {
+			fmt.Fprintf(writer, "secret \"%s\" has been modified concurrently, retrying\n", path.Join(rootPath, subPath))
+
+			continue
+		}
+
+		if err != nil {
+			return 0, err
+		}
+
+		if existing != nil {
+			return importUpdated, nil
+		}
+
+		return importCreated, nil
 	}
+}
 
-	fmt.Fprintf(writer, "deep merging provided secrets with existing secrets read from \"%s\"\n", utils.NormalizePath(rootPath))
-	fmt.Fprintln(writer, "")
-	fmt.Fprintln(writer, "preview:")
-	fmt.Fprintln(writer, "")
+// deleteSecrets deletes the secrets not present in the input including all of their versions.
+func (o *importOptions) deleteSecrets(rootPath string, paths []string, stats *importStats) error {
+	location := newCopyLocation(vaultClient, "", rootPath, "")
 
-	if err := printer.Out(utils.DeepMergeMaps(secrets, existingSecrets)); err != nil {
-		return err
+	for _, p := range paths {
+		if err := location.remove(p); err != nil {
+			if !o.SkipErrors {
+				return fmt.Errorf("error deleting secret \"%s\": %w", p, err)
+			}
+
+			stats.failed++
+
+			fmt.Fprintf(writer, "error deleting secret \"%s\": %v\n", path.Join(rootPath, p), err)
+
+			continue
+		}
+
+		stats.deleted++
+
+		fmt.Fprintf(writer, "deleting secret \"%s\"\n", path.Join(rootPath, p))
 	}
 
-	fmt.Fprintln(writer, "")
-	fmt.Fprintln(writer, "apply changes by using the --force flag")
+	return nil
+}
+
+// checkDeletions errors if more secrets would be deleted than allowed.
+func (o *importOptions) checkDeletions(n int) error {
+	return checkMaxDeletions(n, o.MaxDeletions)
+}
+
+// checkMaxDeletions errors if n exceeds the maximum number of deletions, a negative maximum disables the check.
+func checkMaxDeletions(n, maxDeletions int) error {
+	if maxDeletions >= 0 && n > maxDeletions {
+		return fmt.Errorf("%w: %d secrets would be deleted, but only %d deletions are allowed (--max-deletions)", errTooManyDeletions, n, maxDeletions)
+	}
 
 	return nil
 }
 
-func (o *importOptions) printResult(rootPath string) (map[string]interface{}, error) {
-	fmt.Fprintln(writer, "")
-	fmt.Fprintln(writer, "result:")
-	fmt.Fprintln(writer, "")
+// mergeSecret returns the secret resulting from importing a secret into an existing secret
+// using the given merge strategy.
+func mergeSecret(strategy string, existing, secret map[string]interface{}) map[string]interface{} {
+	if existing == nil || strategy == mergeStrategyReplace {
+		return secret
+	}
+
+	merged := make(map[string]interface{}, len(existing)+len(secret))
+
+	first, second := existing, secret
+	if strategy == mergeStrategyKeepExisting {
+		first, second = secret, existing
+	}
+
+	for k, v := range first {
+		merged[k] = v
+	}
+
+	for k, v := range second {
+		merged[k] = v
+	}
+
+	return merged
+}
+
+// changedKeys returns the key-value pairs of a merged secret that differ from the existing secret.
+func changedKeys(existing, merged map[string]interface{}) map[string]interface{} {
+	res := make(map[string]interface{})
+
+	for k, v := range merged {
+		if old, ok := existing[k]; !ok || !reflect.DeepEqual(old, v) {
+			res[k] = v
+		}
+	}
 
+	return res
+}
+
+// hasObjectValues reports whether any value of a secret is an object.
+func hasObjectValues(secret map[string]interface{}) bool {
+	for _, v := range secret {
+		if _, ok := v.(map[string]interface{}); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// secretsEqual reports whether two secrets contain the same key-value pairs. Numbers are compared by
+// their json.Number, which is how both the input and Vault's responses are decoded.
+func secretsEqual(a, b map[string]interface{}) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return len(a) == 0 && len(b) == 0
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+// setPrinter configures the printer used for the plan and the result of the import.
+func (o *importOptions) setPrinter(rootPath string) {
 	printer = prt.NewSecretPrinter(
 		prt.CustomValueLength(o.MaxValueLength),
 		prt.ShowValues(o.ShowValues),
@@ -316,15 +617,66 @@ func (o *importOptions) printResult(rootPath string) (map[string]interface{}, er
 		prt.WithWriter(writer),
 		prt.ShowVersion(true),
 		prt.ShowMetadata(true),
-		prt.ShowVersion(true),
 		prt.WithEnginePath(utils.NormalizePath(rootPath)),
 		prt.WithContext(rootContext),
 	)
+}
+
+// importSecrets writes and deletes the secrets and prints the resulting KV engine unless silent.
+func (o *importOptions) importSecrets(rootPath string, desired map[string]map[string]interface{}, deletions []string) error {
+	stats := &importStats{}
+
+	if err := o.writeSecrets(rootPath, desired, stats); err != nil {
+		return err
+	}
+
+	if err := o.deleteSecrets(rootPath, deletions, stats); err != nil {
+		return err
+	}
+
+	if o.Prune || len(deletions) > 0 {
+		fmt.Fprintf(writer, "successfully imported secrets: %d created, %d updated, %d unchanged, %d deleted, %d failed\n",
+			stats.created, stats.updated, stats.unchanged, stats.deleted, stats.failed)
+	} else {
+		fmt.Fprintf(writer, "successfully imported secrets: %d created, %d updated, %d unchanged, %d failed\n",
+			stats.created, stats.updated, stats.unchanged, stats.failed)
+	}
+
+	if o.Silent {
+		return nil
+	}
+
+	result, err := o.printResult(rootPath)
+	if err != nil {
+		return err
+	}
+
+	return printer.Out(result)
+}
+
+func (o *importOptions) printResult(rootPath string) (*vault.SecretTree, error) {
+	fmt.Fprintln(writer, "")
+	fmt.Fprintln(writer, "result:")
+	fmt.Fprintln(writer, "")
 
-	secrets, err := vaultClient.ListRecursive(rootContext, rootPath, "", false)
+	tree, err := vaultClient.ListSecretTree(rootContext, "", rootPath, "", false, true)
 	if err != nil {
 		return nil, err
 	}
 
-	return utils.UnflattenMap(utils.NormalizePath(rootPath), utils.ToMapStringInterface(secrets), o.EnginePath), nil
+	printer = prt.NewSecretPrinter(
+		prt.CustomValueLength(o.MaxValueLength),
+		prt.ShowValues(o.ShowValues),
+		prt.ToFormat(prt.Base),
+		prt.WithVaultClient(vaultClient),
+		prt.WithWriter(writer),
+		prt.ShowVersion(true),
+		prt.ShowMetadata(true),
+		prt.ShowVersion(true),
+		prt.WithEnginePath(utils.NormalizePath(rootPath)),
+		prt.WithContext(rootContext),
+		prt.WithSecretsMetadata(tree.SecretsMetadata()),
+	)
+
+	return tree, nil
 }
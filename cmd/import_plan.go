@@ -0,0 +1,427 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/FalcoSuessgott/vkv/pkg/diff"
+	"github.com/FalcoSuessgott/vkv/pkg/fs"
+	"github.com/FalcoSuessgott/vkv/pkg/utils"
+	"github.com/FalcoSuessgott/vkv/pkg/vault"
+)
+
+var (
+	errStalePlan        = errors.New("secrets changed since the plan has been created")
+	errTooManyDeletions = errors.New("too many deletions")
+)
+
+// importPlan describes the secrets to be imported into a KV engine and the state of the
+// existing secrets the plan has been created from.
+type importPlan struct {
+	RootPath      string             `json:"root_path"`
+	EnginePath    string             `json:"engine_path,omitempty"`
+	MergeStrategy string             `json:"merge_strategy,omitempty"`
+	Secrets       []importPlanSecret `json:"secrets"`
+
+	// changes contain the values of the existing secrets and are only used for printing the plan
+	changes []diff.PathChange
+	// metadataChanges holds the paths of the secrets whose metadata will be updated
+	metadataChanges []string
+}
+
+// importPlanSecret is a secret to be imported or deleted and the checksum of the existing secret,
+// empty if the secret did not exist when the plan has been created. Version is the current version of
+// the existing KVv2 secret, which is used for check-and-set when applying the plan.
+type importPlanSecret struct {
+	Path     string                 `json:"path"`
+	Checksum string                 `json:"checksum,omitempty"`
+	Version  int                    `json:"version,omitempty"`
+	Data     map[string]interface{} `json:"data,omitempty"`
+	Metadata *vault.SecretSettings  `json:"metadata,omitempty"`
+	Delete   bool                   `json:"delete,omitempty"`
+}
+
+// newPlan reads the existing secrets and compares them with the secrets to be imported using the merge strategy.
+// If --prune is set, all existing secrets below subPath not present in desired will be deleted.
+// nolint: cyclop
+func (o *importOptions) newPlan(rootPath, subPath string, desired map[string]map[string]interface{}) (*importPlan, error) {
+	plan := &importPlan{
+		RootPath:      rootPath,
+		EnginePath:    o.EnginePath,
+		MergeStrategy: o.MergeStrategy,
+		Secrets:       make([]importPlanSecret, 0, len(desired)),
+	}
+
+	current := make(map[string]map[string]interface{})
+
+	paths := make([]string, 0, len(desired))
+	for p := range desired {
+		paths = append(paths, p)
+	}
+
+	sort.Strings(paths)
+
+	for _, p := range paths {
+		s := importPlanSecret{Path: p, Data: desired[p], Metadata: o.settings[p]}
+
+		changed, err := o.settingsChanged(rootPath, p)
+		if err != nil {
+			return nil, err
+		}
+
+		if changed {
+			plan.metadataChanges = append(plan.metadataChanges, p)
+		}
+
+		// secrets that cannot be read are treated as not existing, the version of a secret whose current
+		// version has been deleted is kept nevertheless, since it is required for writing the secret
+		existing, version, err := vaultClient.ReadSecretsVersion(rootContext, "", rootPath, p)
+		if err == nil {
+			s.Version = version
+		}
+
+		if err == nil && existing != nil {
+			checksum, err := secretChecksum(existing, readSettings(vaultClient, rootPath, p))
+			if err != nil {
+				return nil, err
+			}
+
+			s.Checksum = checksum
+			current[p] = existing
+		}
+
+		plan.Secrets = append(plan.Secrets, s)
+	}
+
+	if o.Prune {
+		existing, err := o.existingPaths(rootPath, subPath)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range existing {
+			if desired[p] != nil {
+				continue
+			}
+
+			// secrets whose current version has been deleted cannot be read, but are deleted nevertheless
+			s := importPlanSecret{Path: p, Delete: true}
+			current[p] = map[string]interface{}{}
+
+			if secret, err := vaultClient.ReadSecrets(rootContext, "", rootPath, p); err == nil {
+				if s.Checksum, err = secretChecksum(secret, readSettings(vaultClient, rootPath, p)); err != nil {
+					return nil, err
+				}
+
+				current[p] = secret
+			}
+
+			plan.Secrets = append(plan.Secrets, s)
+		}
+	}
+
+	merged := plan.desired()
+	for p, secret := range merged {
+		merged[p] = mergeSecret(o.MergeStrategy, current[p], secret)
+	}
+
+	plan.changes = diff.Compare(current, merged)
+
+	return plan, nil
+}
+
+// existingPaths returns the sorted paths of all secrets below subPath. The secrets of a KVv2 engine are listed by their
+// metadata, so that secrets that cannot be read are included.
+func (o *importOptions) existingPaths(rootPath, subPath string) ([]string, error) {
+	// an engine that is not enabled yet has no secrets
+	if _, _, err := vaultClient.GetEngineTypeVersion(rootContext, rootPath); err != nil {
+		return nil, nil //nolint: nilerr
+	}
+
+	isV1, err := vaultClient.IsKVv1(rootContext, rootPath)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := []string{}
+
+	if isV1 {
+		// a path that does not exist is listed as a secret without any data
+		tree, err := vaultClient.ListSecretTree(rootContext, "", rootPath, subPath, true, false)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, s := range tree.SecretNodes() {
+			if s.Data != nil {
+				paths = append(paths, s.Path)
+			}
+		}
+	} else {
+		md, err := vaultClient.ListRecursiveMetadata(rootContext, rootPath, subPath, false)
+		if err != nil {
+			return nil, fmt.Errorf("error listing the secrets of \"%s\": %w", path.Join(rootPath, subPath), err)
+		}
+
+		for p := range md {
+			paths = append(paths, p)
+		}
+	}
+
+	sort.Strings(paths)
+
+	return paths, nil
+}
+
+// readImportPlan reads a plan file created using --plan-file.
+func readImportPlan(file string) (*importPlan, error) {
+	out, err := fs.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	// numbers are kept as json.Number, so that the secrets compare equal to the ones read from Vault
+	plan := &importPlan{}
+	if err := utils.DecodeJSON(out, plan); err != nil {
+		return nil, fmt.Errorf("cannot parse plan file \"%s\": %w", file, err)
+	}
+
+	if plan.RootPath == "" {
+		return nil, fmt.Errorf("invalid plan file \"%s\": no KV engine path found", file)
+	}
+
+	return plan, nil
+}
+
+// desired returns the secrets to be imported keyed by their path.
+func (p *importPlan) desired() map[string]map[string]interface{} {
+	res := make(map[string]map[string]interface{}, len(p.Secrets))
+
+	for _, s := range p.Secrets {
+		if !s.Delete {
+			res[s.Path] = s.Data
+		}
+	}
+
+	return res
+}
+
+// deletions returns the paths of the secrets to be deleted.
+func (p *importPlan) deletions() []string {
+	res := []string{}
+
+	for _, s := range p.Secrets {
+		if s.Delete {
+			res = append(res, s.Path)
+		}
+	}
+
+	return res
+}
+
+// verify returns an error listing all secrets that have been changed since the plan has been created.
+func (p *importPlan) verify() error {
+	stale := []string{}
+
+	for _, s := range p.Secrets {
+		checksum, err := readChecksum(vaultClient, p.RootPath, s.Path)
+		if err != nil {
+			return err
+		}
+
+		if checksum != s.Checksum {
+			stale = append(stale, path.Join(p.RootPath, s.Path))
+		}
+	}
+
+	if len(stale) > 0 {
+		return fmt.Errorf("%w: \"%s\", create a new plan using --dry-run", errStalePlan, strings.Join(stale, "\", \""))
+	}
+
+	return nil
+}
+
+// readChecksum returns the checksum of an existing secret and its settings, empty if the secret cannot be read.
+func readChecksum(c *vault.Vault, rootPath, subPath string) (string, error) {
+	existing, err := c.ReadSecrets(rootContext, "", rootPath, subPath)
+	if err != nil {
+		return "", nil //nolint: nilerr
+	}
+
+	return secretChecksum(existing, readSettings(c, rootPath, subPath))
+}
+
+// readSettings returns the settings of a KVv2 secret, nil if the secret has none or its metadata cannot be read.
+func readSettings(c *vault.Vault, rootPath, subPath string) *vault.SecretSettings {
+	if isV1, err := c.IsKVv1(rootContext, rootPath); err != nil || isV1 {
+		return nil
+	}
+
+	md, err := c.ReadMetadata(rootContext, rootPath, subPath)
+	if err != nil {
+		return nil
+	}
+
+	return md.Settings()
+}
+
+// secretChecksum returns a checksum of the key-value pairs of a secret and its settings.
+// Settings with their default values do not change the checksum.
+func secretChecksum(secret map[string]interface{}, settings *vault.SecretSettings) (string, error) {
+	var v interface{} = secret
+
+	if !settings.IsEmpty() {
+		v = map[string]interface{}{"data": v, "metadata": settings}
+	}
+
+	out, err := utils.ToJSON(v)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(out)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// plan prints the changes an import would apply and optionally saves them to a plan file.
+func (o *importOptions) plan(rootPath, subPath string, desired map[string]map[string]interface{}) error {
+	fmt.Fprintf(writer, "fetching any existing KV secrets from \"%s\" (if any)\n", utils.NormalizePath(rootPath))
+
+	plan, err := o.newPlan(rootPath, subPath, desired)
+	if err != nil {
+		return err
+	}
+
+	if err := o.printPlan(plan); err != nil {
+		return err
+	}
+
+	if err := o.checkDeletions(len(plan.deletions())); err != nil {
+		return err
+	}
+
+	if len(plan.changes) == 0 && len(plan.metadataChanges) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(writer, "")
+
+	if o.PlanFile == "" {
+		fmt.Fprintln(writer, "apply changes by using the --force flag")
+
+		return nil
+	}
+
+	out, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// the plan file contains the secrets to be imported, thus only the owner may read it
+	if err := os.WriteFile(o.PlanFile, out, 0o600); err != nil {
+		return fmt.Errorf("error writing plan file \"%s\": %w", o.PlanFile, err)
+	}
+
+	fmt.Fprintf(writer, "saved plan to \"%s\", apply it by using --apply-plan=%s\n", o.PlanFile, o.PlanFile)
+
+	return nil
+}
+
+// applyPlan imports the secrets of a plan file, unless the secrets changed since the plan has been created.
+func (o *importOptions) applyPlan() error {
+	plan, err := readImportPlan(o.ApplyPlan)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(writer, "reading plan from %s\n", o.ApplyPlan)
+
+	o.EnginePath = plan.EnginePath
+
+	o.settings = make(map[string]*vault.SecretSettings)
+	o.versions = make(map[string]int)
+
+	for _, s := range plan.Secrets {
+		if s.Metadata != nil {
+			o.settings[s.Path] = s.Metadata
+		}
+
+		if !s.Delete {
+			o.versions[s.Path] = s.Version
+		}
+	}
+
+	// the secrets are merged the same way they have been when creating the plan
+	if plan.MergeStrategy != "" {
+		o.MergeStrategy = plan.MergeStrategy
+	}
+	o.setPrinter(plan.RootPath)
+
+	if err := plan.verify(); err != nil {
+		return err
+	}
+
+	if err := o.checkDeletions(len(plan.deletions())); err != nil {
+		return err
+	}
+
+	// applying a reviewed plan implies --force
+	if err := vaultClient.EnableKV2EngineErrorIfNotForced(rootContext, true, plan.RootPath); err != nil {
+		return err
+	}
+
+	return o.importSecrets(plan.RootPath, plan.desired(), plan.deletions())
+}
+
+// printPlan prints the per key changes of a plan and a summary.
+func (o *importOptions) printPlan(plan *importPlan) error {
+	fmt.Fprintln(writer, "")
+
+	if err := printer.Out(&diff.Result{
+		Source:  utils.NormalizePath(plan.RootPath),
+		Target:  o.planTarget(),
+		Changes: plan.changes,
+	}); err != nil {
+		return err
+	}
+
+	added, changed, removed := 0, 0, 0
+
+	for _, c := range plan.changes {
+		switch c.Type {
+		case diff.Added:
+			added++
+		case diff.Removed:
+			removed++
+		case diff.Changed:
+			changed++
+		}
+	}
+
+	fmt.Fprintln(writer, "")
+	fmt.Fprintf(writer, "plan: %d secrets to create, %d to update, %d to delete, %d unchanged\n",
+		added, changed, removed, len(plan.Secrets)-added-changed-removed)
+
+	if len(plan.metadataChanges) > 0 {
+		fmt.Fprintf(writer, "metadata of %d secrets will be updated: \"%s\"\n", len(plan.metadataChanges), strings.Join(plan.metadataChanges, "\", \""))
+	}
+
+	return nil
+}
+
+// planTarget returns the label of the imported secrets in the plan.
+func (o *importOptions) planTarget() string {
+	if o.MergeStrategy == mergeStrategyReplace {
+		return "import"
+	}
+
+	return fmt.Sprintf("import (merge strategy: %s)", o.MergeStrategy)
+}
@@ -5,8 +5,11 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path"
+	"strings"
 
 	"github.com/FalcoSuessgott/vkv/pkg/utils"
+	"github.com/FalcoSuessgott/vkv/pkg/vault"
 )
 
 func (s *VaultSuite) TestValidateImportFlags() {
@@ -31,6 +34,31 @@ func (s *VaultSuite) TestValidateImportFlags() {
 			err:  true,
 			args: []string{"-f=sss", "-"},
 		},
+		{
+			name: "plan file without dry-run should fail",
+			err:  true,
+			args: []string{"-p=o", "--plan-file=plan.json"},
+		},
+		{
+			name: "apply plan and path should fail",
+			err:  true,
+			args: []string{"-p=o", "--apply-plan=plan.json"},
+		},
+		{
+			name: "invalid merge strategy should fail",
+			err:  true,
+			args: []string{"-p=o", "--merge-strategy=invalid"},
+		},
+		{
+			name: "prune and patch should fail",
+			err:  true,
+			args: []string{"-p=o", "--prune", "--merge-strategy=patch"},
+		},
+		{
+			name: "prune and apply plan should fail",
+			err:  true,
+			args: []string{"--prune", "--apply-plan=plan.json"},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -180,6 +208,40 @@ func (s *VaultSuite) TestParseInput() {
 	}
 }
 
+func (s *VaultSuite) TestCheckSecrets() {
+	testCases := []struct {
+		name    string
+		secrets map[string]interface{}
+		err     bool
+	}{
+		{
+			name: "secrets and directories",
+			secrets: map[string]interface{}{
+				"secret": map[string]interface{}{"config": map[string]interface{}{"port": 5432}},
+				"sub/": map[string]interface{}{
+					"demo": map[string]interface{}{"user": "password"},
+				},
+			},
+		},
+		{
+			name:    "key-value pairs of a single secret",
+			secrets: map[string]interface{}{"user": "password"},
+			err:     true,
+		},
+		{
+			name: "key-value pairs within a directory",
+			secrets: map[string]interface{}{
+				"sub/": map[string]interface{}{"user": "password"},
+			},
+			err: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Require().Equal(tc.err, checkSecrets(tc.secrets) != nil, tc.name)
+	}
+}
+
 func (s *VaultSuite) TestImportCommand() {
 	testCases := []struct {
 		name            string
@@ -324,3 +386,599 @@ secret2/secret:
 		})
 	}
 }
+
+func (s *VaultSuite) TestImportSkipsUnchangedSecrets() {
+	secrets := `idempotent/:
+  unchanged:
+    user: password
+    port: 8080
+  changed:
+    key: value
+`
+
+	f, err := os.CreateTemp(s.Suite.T().TempDir(), "secrets")
+	s.Require().NoError(err, "temp file")
+	s.Require().NoError(os.WriteFile(f.Name(), []byte(secrets), 0o600), "write secrets")
+
+	writer = io.Discard
+
+	importCmd := NewImportCmd()
+	importCmd.SetArgs([]string{fmt.Sprintf("-f=%s", f.Name()), "-s"})
+	s.Require().NoError(importCmd.Execute(), "initial import")
+
+	// change a single secret and import again
+	s.Require().NoError(os.WriteFile(f.Name(), []byte(strings.Replace(secrets, "key: value", "key: new", 1)), 0o600), "update secrets")
+
+	b := bytes.NewBufferString("")
+	writer = b
+
+	importCmd = NewImportCmd()
+	importCmd.SetArgs([]string{"-p=idempotent", fmt.Sprintf("-f=%s", f.Name()), "--force", "-s"})
+	s.Require().NoError(importCmd.Execute(), "second import")
+
+	out, _ := io.ReadAll(b)
+
+	s.Require().Contains(string(out), "skipping unchanged secret \"idempotent/unchanged\"")
+	s.Require().Contains(string(out), "updating secret \"idempotent/changed\"")
+	s.Require().Contains(string(out), "successfully imported secrets: 0 created, 1 updated, 1 unchanged, 0 failed")
+
+	// unchanged secrets must not get a new version
+	md, err := vaultClient.ReadMetadata(rootContext, "idempotent", "unchanged")
+	s.Require().NoError(err)
+	s.Require().Equal(1, md.CurrentVersion)
+
+	md, err = vaultClient.ReadMetadata(rootContext, "idempotent", "changed")
+	s.Require().NoError(err)
+	s.Require().Equal(2, md.CurrentVersion)
+}
+
+func (s *VaultSuite) TestImportPlan() {
+	secrets := `plan/:
+  existing:
+    user: password
+  new:
+    key: value
+`
+
+	dir := s.Suite.T().TempDir()
+	input := path.Join(dir, "secrets.yaml")
+	plan := path.Join(dir, "plan.json")
+
+	writer = io.Discard
+
+	s.Require().NoError(vaultClient.EnableKV2Engine(rootContext, "plan"))
+	s.Require().NoError(vaultClient.WriteSecrets(rootContext, "", "plan", "existing", map[string]interface{}{"user": "admin"}))
+	s.Require().NoError(os.WriteFile(input, []byte(secrets), 0o600), "write secrets")
+
+	// 1. create the plan
+	b := bytes.NewBufferString("")
+	writer = b
+
+	importCmd := NewImportCmd()
+	importCmd.SetArgs([]string{"-p=plan", fmt.Sprintf("-f=%s", input), "-d", fmt.Sprintf("--plan-file=%s", plan), "--show-values"})
+	s.Require().NoError(importCmd.Execute(), "plan")
+
+	out, _ := io.ReadAll(b)
+
+	s.Require().Contains(string(out), "user=admin -> password")
+	s.Require().Contains(string(out), "key=value")
+	s.Require().Contains(string(out), "plan: 1 secrets to create, 1 to update, 0 to delete, 0 unchanged")
+	s.Require().FileExists(plan)
+
+	// nothing has been written yet
+	existing, err := vaultClient.ReadSecrets(rootContext, "", "plan", "existing")
+	s.Require().NoError(err)
+	s.Require().Equal("admin", existing["user"])
+
+	// 2. apply the plan
+	writer = io.Discard
+
+	importCmd = NewImportCmd()
+	importCmd.SetArgs([]string{fmt.Sprintf("--apply-plan=%s", plan), "-s"})
+	s.Require().NoError(importCmd.Execute(), "apply plan")
+
+	existing, err = vaultClient.ReadSecrets(rootContext, "", "plan", "existing")
+	s.Require().NoError(err)
+	s.Require().Equal("password", existing["user"])
+
+	// 3. the plan refuses to be applied once the secrets changed
+	s.Require().NoError(vaultClient.WriteSecrets(rootContext, "", "plan", "new", map[string]interface{}{"key": "changed"}))
+
+	importCmd = NewImportCmd()
+	importCmd.SetArgs([]string{fmt.Sprintf("--apply-plan=%s", plan), "-s"})
+	s.Require().ErrorIs(importCmd.Execute(), errStalePlan, "stale plan")
+
+	changed, err := vaultClient.ReadSecrets(rootContext, "", "plan", "new")
+	s.Require().NoError(err)
+	s.Require().Equal("changed", changed["key"])
+
+	// 4. changing only the metadata of a secret makes the plan stale as well
+	importCmd = NewImportCmd()
+	importCmd.SetArgs([]string{"-p=plan", fmt.Sprintf("-f=%s", input), "-d", fmt.Sprintf("--plan-file=%s", plan)})
+	s.Require().NoError(importCmd.Execute(), "plan")
+
+	s.Require().NoError(vaultClient.WriteCustomMetadata(rootContext, "plan", "existing", map[string]interface{}{"owner": "team-a"}))
+
+	importCmd = NewImportCmd()
+	importCmd.SetArgs([]string{fmt.Sprintf("--apply-plan=%s", plan), "-s"})
+	s.Require().ErrorIs(importCmd.Execute(), errStalePlan, "stale metadata")
+
+	// 5. a secret written in between is detected by its version, even if its data has been restored
+	importCmd = NewImportCmd()
+	importCmd.SetArgs([]string{"-p=plan", fmt.Sprintf("-f=%s", input), "-d", fmt.Sprintf("--plan-file=%s", plan)})
+	s.Require().NoError(importCmd.Execute(), "plan")
+
+	s.Require().NoError(vaultClient.WriteSecrets(rootContext, "", "plan", "new", map[string]interface{}{"key": "restored"}))
+	s.Require().NoError(vaultClient.WriteSecrets(rootContext, "", "plan", "new", map[string]interface{}{"key": "changed"}))
+
+	importCmd = NewImportCmd()
+	importCmd.SetArgs([]string{fmt.Sprintf("--apply-plan=%s", plan), "-s"})
+	s.Require().ErrorIs(importCmd.Execute(), errStalePlan, "stale version")
+
+	changed, err = vaultClient.ReadSecrets(rootContext, "", "plan", "new")
+	s.Require().NoError(err)
+	s.Require().Equal("changed", changed["key"])
+}
+
+func (s *VaultSuite) TestImportPrune() {
+	secrets := `prune/:
+  keep:
+    user: password
+`
+
+	input := path.Join(s.Suite.T().TempDir(), "secrets.yaml")
+	s.Require().NoError(os.WriteFile(input, []byte(secrets), 0o600), "write secrets")
+
+	s.Require().NoError(vaultClient.EnableKV2Engine(rootContext, "prune"))
+
+	for _, p := range []string{"keep", "remove", "sub/remove", "deleted"} {
+		s.Require().NoError(vaultClient.WriteSecrets(rootContext, "", "prune", p, map[string]interface{}{"user": "admin"}))
+	}
+
+	// soft deleted secrets cannot be read, but are pruned as well
+	s.Require().NoError(vaultClient.DeleteSecret(rootContext, "prune", "deleted", nil))
+
+	writer = io.Discard
+
+	// 1. exceeding the maximum number of deletions fails
+	importCmd := NewImportCmd()
+	importCmd.SetArgs([]string{"-p=prune", fmt.Sprintf("-f=%s", input), "--force", "--prune", "--max-deletions=1", "-s"})
+	s.Require().ErrorIs(importCmd.Execute(), errTooManyDeletions, "max deletions")
+
+	_, err := vaultClient.ReadSecrets(rootContext, "", "prune", "remove")
+	s.Require().NoError(err, "secret has not been deleted")
+
+	// 2. prune all secrets not present in the input
+	b := bytes.NewBufferString("")
+	writer = b
+
+	importCmd = NewImportCmd()
+	importCmd.SetArgs([]string{"-p=prune", fmt.Sprintf("-f=%s", input), "--force", "--prune", "-s"})
+	s.Require().NoError(importCmd.Execute(), "prune")
+
+	out, _ := io.ReadAll(b)
+
+	s.Require().Contains(string(out), "plan: 0 secrets to create, 1 to update, 3 to delete, 0 unchanged")
+	s.Require().Contains(string(out), "deleting secret \"prune/remove\"")
+	s.Require().Contains(string(out), "successfully imported secrets: 0 created, 1 updated, 0 unchanged, 3 deleted, 0 failed")
+
+	secret, err := vaultClient.ReadSecrets(rootContext, "", "prune", "keep")
+	s.Require().NoError(err)
+	s.Require().Equal("password", secret["user"])
+
+	for _, p := range []string{"remove", "sub/remove", "deleted"} {
+		_, err := vaultClient.ReadMetadata(rootContext, "prune", p)
+		s.Require().Error(err, p)
+	}
+}
+
+func (s *VaultSuite) TestImportCASRequired() {
+	secrets := `casrequired/:
+  admin:
+    user: password
+`
+
+	input := path.Join(s.Suite.T().TempDir(), "secrets.yaml")
+	s.Require().NoError(os.WriteFile(input, []byte(secrets), 0o600), "write secrets")
+
+	// engines requiring check-and-set reject writes without a cas version
+	s.Require().NoError(vaultClient.EnableKV2Engine(rootContext, "casrequired"))
+
+	_, err := vaultClient.Client.Logical().Write("casrequired/config", map[string]interface{}{"cas_required": true})
+	s.Require().NoError(err)
+	s.Require().NoError(vaultClient.WriteSecretsCAS(rootContext, "", "casrequired", "admin", map[string]interface{}{"user": "admin"}, 0))
+
+	writer = io.Discard
+
+	importCmd := NewImportCmd()
+	importCmd.SetArgs([]string{"-p=casrequired", fmt.Sprintf("-f=%s", input), "--force", "-s"})
+	s.Require().NoError(importCmd.Execute(), "import")
+
+	secret, version, err := vaultClient.ReadSecretsVersion(rootContext, "", "casrequired", "admin")
+	s.Require().NoError(err)
+	s.Require().Equal("password", secret["user"])
+	s.Require().Equal(2, version)
+}
+
+func (s *VaultSuite) TestImportMergeStrategy() {
+	testCases := []struct {
+		name     string
+		strategy string
+		expected map[string]interface{}
+	}{
+		{
+			name:     "replace",
+			strategy: "replace",
+			expected: map[string]interface{}{"user": "new", "added": "key", "cfg": map[string]interface{}{"a": "1"}},
+		},
+		{
+			name:     "patch",
+			strategy: "patch",
+			// object values are replaced rather than merged
+			expected: map[string]interface{}{"user": "new", "added": "key", "owner": "team-b", "cfg": map[string]interface{}{"a": "1"}},
+		},
+		{
+			name:     "keep-existing",
+			strategy: "keep-existing",
+			expected: map[string]interface{}{"user": "old", "added": "key", "owner": "team-b", "cfg": map[string]interface{}{"a": "1", "b": "2"}},
+		},
+	}
+
+	input := path.Join(s.Suite.T().TempDir(), "secrets.yaml")
+	s.Require().NoError(os.WriteFile(input, []byte(`shared:
+  user: new
+  added: key
+  cfg:
+    a: "1"
+`), 0o600), "write secrets")
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			rootPath := "merge-" + tc.strategy
+
+			s.Require().NoError(vaultClient.EnableKV2Engine(rootContext, rootPath))
+			s.Require().NoError(vaultClient.WriteSecrets(rootContext, "", rootPath, "shared", map[string]interface{}{
+				"user":  "old",
+				"owner": "team-b",
+				"cfg":   map[string]interface{}{"a": "1", "b": "2"},
+			}))
+
+			writer = io.Discard
+
+			importCmd := NewImportCmd()
+			importCmd.SetArgs([]string{"-p=" + rootPath, fmt.Sprintf("-f=%s", input), "--force", "-s", "--merge-strategy=" + tc.strategy})
+			s.Require().NoError(importCmd.Execute(), tc.name)
+
+			secret, err := vaultClient.ReadSecrets(rootContext, "", rootPath, "shared")
+			s.Require().NoError(err)
+			s.Require().Equal(tc.expected, secret, tc.name)
+
+			// importing again does not change anything
+			b := bytes.NewBufferString("")
+			writer = b
+
+			importCmd = NewImportCmd()
+			importCmd.SetArgs([]string{"-p=" + rootPath, fmt.Sprintf("-f=%s", input), "-d", "--merge-strategy=" + tc.strategy})
+			s.Require().NoError(importCmd.Execute(), tc.name)
+
+			out, _ := io.ReadAll(b)
+			s.Require().Contains(string(out), "plan: 0 secrets to create, 0 to update, 0 to delete, 1 unchanged", tc.name)
+		})
+	}
+}
+
+func (s *VaultSuite) TestImportMetadata() {
+	settings := &vault.SecretSettings{
+		CustomMetadata: map[string]interface{}{"owner": "team-a"},
+		MaxVersions:    3,
+	}
+
+	s.Require().NoError(vaultClient.EnableKV2Engine(rootContext, "metadata-source"))
+	s.Require().NoError(vaultClient.WriteSecrets(rootContext, "", "metadata-source", "admin", map[string]interface{}{"user": "password"}))
+	s.Require().NoError(vaultClient.WriteSecretSettings(rootContext, "", "metadata-source", "admin", settings))
+
+	// 1. export including the metadata
+	b := bytes.NewBufferString("")
+	writer = b
+
+	exportCmd := NewExportCmd()
+	exportCmd.SetArgs([]string{"-p=metadata-source", "-f=yaml", "--with-metadata"})
+	s.Require().NoError(exportCmd.Execute(), "export")
+
+	out, _ := io.ReadAll(b)
+	s.Require().Contains(string(out), vault.MetadataKey)
+
+	input := path.Join(s.Suite.T().TempDir(), "secrets.yaml")
+	s.Require().NoError(os.WriteFile(input, out, 0o600), "write secrets")
+
+	// 2. import into another engine
+	writer = io.Discard
+
+	importCmd := NewImportCmd()
+	importCmd.SetArgs([]string{"-p=metadata-target", fmt.Sprintf("-f=%s", input), "-s"})
+	s.Require().NoError(importCmd.Execute(), "import")
+
+	secret, err := vaultClient.ReadSecrets(rootContext, "", "metadata-target", "admin")
+	s.Require().NoError(err)
+	s.Require().Equal(map[string]interface{}{"user": "password"}, secret)
+
+	md, err := vaultClient.ReadMetadata(rootContext, "metadata-target", "admin")
+	s.Require().NoError(err)
+	s.Require().Equal(settings, md.Settings())
+
+	// 3. importing again does not change the metadata
+	b = bytes.NewBufferString("")
+	writer = b
+
+	importCmd = NewImportCmd()
+	importCmd.SetArgs([]string{"-p=metadata-target", fmt.Sprintf("-f=%s", input), "--force", "-s"})
+	s.Require().NoError(importCmd.Execute(), "import again")
+
+	out, _ = io.ReadAll(b)
+	s.Require().NotContains(string(out), "updating metadata")
+}
+
+func (s *VaultSuite) TestImportMetadataKVv1() {
+	s.Require().NoError(vaultClient.EnableKV1Engine(rootContext, "metadata-kvv1"))
+
+	input := path.Join(s.Suite.T().TempDir(), "secrets.json")
+	s.Require().NoError(os.WriteFile(input, []byte(`{
+  "__metadata__": {
+    "admin": {
+      "custom_metadata": {
+        "owner": "team-a"
+      }
+    }
+  },
+  "admin": {
+    "user": "password"
+  }
+}`), 0o600), "write secrets")
+
+	b := bytes.NewBufferString("")
+	writer = b
+
+	// the metadata is skipped, since KVv1 secrets have no metadata
+	importCmd := NewImportCmd()
+	importCmd.SetArgs([]string{"-p=metadata-kvv1", fmt.Sprintf("-f=%s", input), "--force", "-s"})
+	s.Require().NoError(importCmd.Execute(), "import")
+
+	out, _ := io.ReadAll(b)
+	s.Require().Contains(string(out), `warning: "metadata-kvv1/" is a KVv1 engine, skipping the metadata of 1 secrets`)
+
+	secret, err := vaultClient.ReadSecrets(rootContext, "", "metadata-kvv1", "admin")
+	s.Require().NoError(err)
+	s.Require().Equal(map[string]interface{}{"user": "password"}, secret)
+}
+
+func (s *VaultSuite) TestImportAllVersions() {
+	s.Require().NoError(vaultClient.EnableKV2Engine(rootContext, "versions-source"))
+
+	for _, v := range []string{"v1", "v2", "v3"} {
+		s.Require().NoError(vaultClient.WriteSecrets(rootContext, "", "versions-source", "admin", map[string]interface{}{"user": v}))
+	}
+
+	s.Require().NoError(vaultClient.DeleteSecret(rootContext, "versions-source", "admin", []int{2}))
+	s.Require().NoError(vaultClient.WriteCustomMetadata(rootContext, "versions-source", "admin", map[string]interface{}{"owner": "team-a"}))
+
+	// 1. export all versions
+	b := bytes.NewBufferString("")
+	writer = b
+
+	exportCmd := NewExportCmd()
+	exportCmd.SetArgs([]string{"-p=versions-source", "-f=json", "--all-versions"})
+	s.Require().NoError(exportCmd.Execute(), "export")
+
+	out, _ := io.ReadAll(b)
+
+	input := path.Join(s.Suite.T().TempDir(), "secrets.json")
+	s.Require().NoError(os.WriteFile(input, out, 0o600), "write secrets")
+
+	// 2. replay the versions into another engine
+	b = bytes.NewBufferString("")
+	writer = b
+
+	importCmd := NewImportCmd()
+	importCmd.SetArgs([]string{"-p=versions-target", fmt.Sprintf("-f=%s", input), "--replay-deletions", "-s"})
+	s.Require().NoError(importCmd.Execute(), "import")
+
+	out, _ = io.ReadAll(b)
+	s.Require().Contains(string(out), "successfully replayed 3 versions of 1 secrets: 0 skipped, 0 failed")
+
+	secret, err := vaultClient.ReadAllVersions(rootContext, "versions-target", "admin")
+	s.Require().NoError(err)
+	s.Require().Equal(map[string]interface{}{"owner": "team-a"}, secret.CustomMetadata)
+	s.Require().Len(secret.Versions, 3)
+	s.Require().Equal(map[string]interface{}{"user": "v3"}, secret.Versions[0].Data)
+	s.Require().Nil(secret.Versions[1].Data)
+	s.Require().NotNil(secret.Versions[1].DeletionTime)
+	s.Require().Equal(map[string]interface{}{"user": "v1"}, secret.Versions[2].Data)
+
+	// 3. existing secrets are skipped
+	b = bytes.NewBufferString("")
+	writer = b
+
+	importCmd = NewImportCmd()
+	importCmd.SetArgs([]string{"-p=versions-target", fmt.Sprintf("-f=%s", input), "--force", "-s"})
+	s.Require().NoError(importCmd.Execute(), "import again")
+
+	out, _ = io.ReadAll(b)
+	s.Require().Contains(string(out), "skipping existing secret \"versions-target/admin\"")
+	s.Require().Contains(string(out), "successfully replayed 0 versions of 0 secrets: 1 skipped, 0 failed")
+}
+
+func (s *VaultSuite) TestImportAllVersionsTrimmed() {
+	s.Require().NoError(vaultClient.EnableKV2Engine(rootContext, "trimmed-source"))
+	s.Require().NoError(vaultClient.WriteSecretSettings(rootContext, "", "trimmed-source", "admin", &vault.SecretSettings{MaxVersions: 2}))
+
+	// version 1 is removed due to max_versions
+	for _, v := range []string{"v1", "v2", "v3"} {
+		s.Require().NoError(vaultClient.WriteSecrets(rootContext, "", "trimmed-source", "admin", map[string]interface{}{"user": v}))
+	}
+
+	b := bytes.NewBufferString("")
+	writer = b
+
+	exportCmd := NewExportCmd()
+	exportCmd.SetArgs([]string{"-p=trimmed-source", "-f=json", "--all-versions"})
+	s.Require().NoError(exportCmd.Execute(), "export")
+
+	out, _ := io.ReadAll(b)
+
+	input := path.Join(s.Suite.T().TempDir(), "secrets.json")
+	s.Require().NoError(os.WriteFile(input, out, 0o600), "write secrets")
+
+	// 1. the version numbers and the settings are kept
+	writer = io.Discard
+
+	importCmd := NewImportCmd()
+	importCmd.SetArgs([]string{"-p=trimmed-target", fmt.Sprintf("-f=%s", input), "--replay-deletions", "-s"})
+	s.Require().NoError(importCmd.Execute(), "import")
+
+	secret, err := vaultClient.ReadAllVersions(rootContext, "trimmed-target", "admin")
+	s.Require().NoError(err)
+	s.Require().Equal(2, secret.MaxVersions)
+	s.Require().Len(secret.Versions, 2)
+	s.Require().Equal(3, secret.Versions[0].Version)
+	s.Require().Equal(map[string]interface{}{"user": "v3"}, secret.Versions[0].Data)
+	s.Require().Equal(2, secret.Versions[1].Version)
+	s.Require().Equal(map[string]interface{}{"user": "v2"}, secret.Versions[1].Data)
+
+	// 2. versions the destination would not keep are rejected
+	s.Require().NoError(vaultClient.EnableKV2Engine(rootContext, "trimmed-small"))
+	_, err = vaultClient.Client.Logical().Write("trimmed-small/config", map[string]interface{}{"max_versions": 1})
+	s.Require().NoError(err)
+
+	input = path.Join(s.Suite.T().TempDir(), "versions.json")
+	s.Require().NoError(os.WriteFile(input, []byte(`{
+  "admin": {
+    "versions": [
+      {"version": 2, "data": {"user": "v2"}},
+      {"version": 1, "data": {"user": "v1"}}
+    ]
+  }
+}`), 0o600), "write secrets")
+
+	importCmd = NewImportCmd()
+	importCmd.SetArgs([]string{"-p=trimmed-small", fmt.Sprintf("-f=%s", input), "--force", "-s"})
+	s.Require().ErrorContains(importCmd.Execute(), "2 versions are replayed, but the destination only keeps 1 versions")
+
+	// 3. a partially replayed secret is removed again
+	s.Require().NoError(os.WriteFile(input, []byte(`{
+  "admin": {
+    "versions": [
+      {"version": 1, "data": {"user": "v1"}},
+      {"version": 2, "data": {"user": "v2"}}
+    ]
+  }
+}`), 0o600), "write secrets")
+
+	importCmd = NewImportCmd()
+	importCmd.SetArgs([]string{"-p=trimmed-partial", fmt.Sprintf("-f=%s", input), "--replay-deletions", "-s"})
+	s.Require().ErrorContains(importCmd.Execute(), "version 1 of the export cannot be written as version 3, removed the partially replayed secret")
+
+	_, version, err := vaultClient.ReadSecretsVersion(rootContext, "", "trimmed-partial", "admin")
+	s.Require().NoError(err)
+	s.Require().Equal(0, version)
+	// 4. KVv1 engines are rejected before anything is written
+	s.Require().NoError(vaultClient.EnableKV1Engine(rootContext, "trimmed-kvv1"))
+
+	importCmd = NewImportCmd()
+	importCmd.SetArgs([]string{"-p=trimmed-kvv1", fmt.Sprintf("-f=%s", input), "--force", "--skip-errors", "-s"})
+	s.Require().ErrorIs(importCmd.Execute(), vault.ErrKVv1NotSupported)
+
+	tree, err := vaultClient.ListSecretTree(rootContext, "", "trimmed-kvv1", "", true, false)
+	s.Require().NoError(err)
+	s.Require().Empty(tree.Flatten())
+}
+
+func (s *VaultSuite) TestImportNestedValues() {
+	secret := map[string]interface{}{
+		"user":    "admin",
+		"port":    8080,
+		"ratio":   0.5,
+		"id":      9007199254740993,
+		"enabled": true,
+		"port_s":  "8080",
+		"db": map[string]interface{}{
+			"host":    "localhost",
+			"options": map[string]interface{}{"tls": false, "retries": 3},
+			"hosts":   []interface{}{"a", "b"},
+		},
+	}
+
+	s.Require().NoError(vaultClient.EnableKV2Engine(rootContext, "nested-source"))
+	s.Require().NoError(vaultClient.WriteSecrets(rootContext, "", "nested-source", "sub/app", secret))
+
+	expected, err := vaultClient.ReadSecrets(rootContext, "", "nested-source", "sub/app")
+	s.Require().NoError(err)
+
+	for _, format := range []string{"json", "yaml"} {
+		// 1. export
+		b := bytes.NewBufferString("")
+		writer = b
+
+		exportCmd := NewExportCmd()
+		exportCmd.SetArgs([]string{"-p=nested-source", fmt.Sprintf("-f=%s", format)})
+		s.Require().NoError(exportCmd.Execute(), format)
+
+		out, _ := io.ReadAll(b)
+		s.Require().NotContains(string(out), "sub/app/db", format)
+
+		input := path.Join(s.Suite.T().TempDir(), "secrets."+format)
+		s.Require().NoError(os.WriteFile(input, out, 0o600), "write secrets")
+
+		// 2. import into another engine
+		writer = io.Discard
+
+		target := "nested-" + format
+
+		importCmd := NewImportCmd()
+		importCmd.SetArgs([]string{fmt.Sprintf("-p=%s", target), fmt.Sprintf("-f=%s", input), "-s"})
+		s.Require().NoError(importCmd.Execute(), format)
+
+		tree, err := vaultClient.ListSecretTree(rootContext, "", target, "", false, false)
+		s.Require().NoError(err, format)
+		s.Require().Equal(map[string]map[string]interface{}{"sub/app": expected}, tree.Flatten(), format)
+	}
+}
+
+func (s *VaultSuite) TestImportSecretAndDirectoryWithSameName() {
+	s.Require().NoError(vaultClient.EnableKV2Engine(rootContext, "same-name-source"))
+	s.Require().NoError(vaultClient.WriteSecrets(rootContext, "", "same-name-source", "app", map[string]interface{}{"user": "password"}))
+	s.Require().NoError(vaultClient.WriteSecrets(rootContext, "", "same-name-source", "app/db", map[string]interface{}{"user": "admin"}))
+
+	expected := map[string]map[string]interface{}{
+		"app":    {"user": "password"},
+		"app/db": {"user": "admin"},
+	}
+
+	// 1. export
+	b := bytes.NewBufferString("")
+	writer = b
+
+	exportCmd := NewExportCmd()
+	exportCmd.SetArgs([]string{"-p=same-name-source", "-f=yaml"})
+	s.Require().NoError(exportCmd.Execute(), "export")
+
+	out, _ := io.ReadAll(b)
+
+	input := path.Join(s.Suite.T().TempDir(), "secrets.yaml")
+	s.Require().NoError(os.WriteFile(input, out, 0o600), "write secrets")
+
+	// 2. import into another engine
+	b = bytes.NewBufferString("")
+	writer = b
+
+	importCmd := NewImportCmd()
+	importCmd.SetArgs([]string{"-p=same-name-target", fmt.Sprintf("-f=%s", input)})
+	s.Require().NoError(importCmd.Execute(), "import")
+
+	out, _ = io.ReadAll(b)
+	s.Require().Contains(string(out), "successfully imported secrets: 2 created, 0 updated, 0 unchanged, 0 failed")
+	s.Require().Contains(string(out), "└── app/")
+
+	tree, err := vaultClient.ListSecretTree(rootContext, "", "same-name-target", "", false, false)
+	s.Require().NoError(err)
+	s.Require().Equal(expected, tree.Flatten())
+}
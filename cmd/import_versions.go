@@ -0,0 +1,255 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/FalcoSuessgott/vkv/pkg/utils"
+	"github.com/FalcoSuessgott/vkv/pkg/vault"
+)
+
+// defaultMaxVersions is the number of versions of a secret a KVv2 engine keeps, unless configured otherwise.
+const defaultMaxVersions = 10
+
+// importVersions replays all versions of the secrets of an export created using --all-versions.
+func (o *importOptions) importVersions(secrets map[string]interface{}) error {
+	switch {
+	case o.EnginePath == "" && o.Path == "":
+		return errors.New("exports created using --all-versions contain no KV engine path, specify a destination path using -p/-e")
+	case o.Prune || o.PlanFile != "" || o.MergeStrategy != mergeStrategyReplace:
+		return fmt.Errorf("%w: %s", errInvalidFlagCombination, "--prune, --plan-file and --merge-strategy are not supported for exports created using --all-versions")
+	}
+
+	versioned, err := vault.ParseVersionedSecrets(secrets)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(writer, "found all versions export, replaying the versions of every secret")
+
+	rootPath, subPath := utils.HandleEnginePath(o.EnginePath, o.Path)
+
+	// KVv1 engines keep no versions, an engine that is not enabled yet is enabled as KVv2
+	if _, _, err := vaultClient.GetEngineTypeVersion(rootContext, rootPath); err == nil {
+		isV1, err := vaultClient.IsKVv1(rootContext, rootPath)
+		if err != nil {
+			return err
+		}
+
+		if isV1 {
+			return fmt.Errorf("%w: exports created using --all-versions cannot be imported into the KVv1 engine \"%s\"", vault.ErrKVv1NotSupported, rootPath)
+		}
+	}
+
+	o.setPrinter(rootPath)
+
+	desired := make(map[string]*vault.VersionedSecret, len(versioned))
+	for p, s := range versioned {
+		desired[path.Join(subPath, p)] = s
+	}
+
+	paths := make([]string, 0, len(desired))
+	for p := range desired {
+		paths = append(paths, p)
+	}
+
+	sort.Strings(paths)
+
+	if o.DryRun {
+		for _, p := range paths {
+			fmt.Fprintf(writer, "would replay %d versions of secret \"%s\"\n", len(o.replayedVersions(desired[p])), path.Join(rootPath, p))
+		}
+
+		fmt.Fprintln(writer, "")
+		fmt.Fprintln(writer, "apply changes by using the --force flag")
+
+		return nil
+	}
+
+	if err := vaultClient.EnableKV2EngineErrorIfNotForced(rootContext, o.Force, rootPath); !o.SkipErrors && err != nil {
+		return err
+	}
+
+	stats := &importStats{}
+	versions := 0
+
+	for _, p := range paths {
+		n, err := o.replaySecret(rootPath, p, desired[p])
+		if err != nil {
+			if !o.SkipErrors {
+				return fmt.Errorf("error replaying secret \"%s\": %w", p, err)
+			}
+
+			stats.failed++
+
+			fmt.Fprintf(writer, "error replaying secret \"%s\": %v\n", path.Join(rootPath, p), err)
+
+			continue
+		}
+
+		if n == 0 {
+			stats.unchanged++
+
+			continue
+		}
+
+		stats.created++
+		versions += n
+	}
+
+	fmt.Fprintf(writer, "successfully replayed %d versions of %d secrets: %d skipped, %d failed\n",
+		versions, stats.created, stats.unchanged, stats.failed)
+
+	if o.Silent {
+		return nil
+	}
+
+	result, err := o.printResult(rootPath)
+	if err != nil {
+		return err
+	}
+
+	return printer.Out(result)
+}
+
+// replayedVersions returns the versions of a secret to be written, oldest first.
+// Deleted and destroyed versions have no data, they are only written if --replay-deletions is set.
+func (o *importOptions) replayedVersions(secret *vault.VersionedSecret) []*vault.SecretVersion {
+	res := []*vault.SecretVersion{}
+
+	for i := len(secret.Versions) - 1; i >= 0; i-- {
+		if sv := secret.Versions[i]; sv.Data != nil || o.ReplayDeletions {
+			res = append(res, sv)
+		}
+	}
+
+	return res
+}
+
+// replaySecret writes the settings and the versions of a secret oldest first and returns the number of written versions.
+// Secrets that already exist in the destination are skipped, since their versions would be mixed.
+// A secret that could not be replayed completely is removed again, so that it is replayed by the next import.
+func (o *importOptions) replaySecret(rootPath, subPath string, secret *vault.VersionedSecret) (int, error) {
+	_, version, err := vaultClient.ReadSecretsVersion(rootContext, "", rootPath, subPath)
+	if err != nil {
+		return 0, err
+	}
+
+	if version > 0 {
+		fmt.Fprintf(writer, "skipping existing secret \"%s\"\n", path.Join(rootPath, subPath))
+
+		return 0, nil
+	}
+
+	versions := o.replayedVersions(secret)
+
+	if err := o.checkMaxVersions(rootPath, secret, versions); err != nil {
+		return 0, err
+	}
+
+	if err := o.writeVersions(rootPath, subPath, secret.Settings(), versions); err != nil {
+		if rmErr := vaultClient.DeleteSecretMetadata(rootContext, rootPath, subPath); rmErr != nil {
+			return 0, fmt.Errorf("%w, the partially replayed secret could not be removed: %v", err, rmErr)
+		}
+
+		return 0, fmt.Errorf("%w, removed the partially replayed secret", err)
+	}
+
+	fmt.Fprintf(writer, "replaying %d versions of secret \"%s\"\n", len(versions), path.Join(rootPath, subPath))
+
+	return len(versions), nil
+}
+
+// writeVersions writes the settings of a secret first, so that they apply to the replayed versions, and then its versions.
+// Deleted and destroyed versions are written without data and deleted or destroyed again. Using --replay-deletions
+// the version numbers of the export are kept, thus versions missing in the export, e.g. since the source engine
+// removed them due to max_versions, are written without data and destroyed.
+func (o *importOptions) writeVersions(rootPath, subPath string, settings *vault.SecretSettings, versions []*vault.SecretVersion) error {
+	if settings != nil {
+		if err := vaultClient.WriteSecretSettings(rootContext, "", rootPath, subPath, settings); err != nil {
+			return err
+		}
+	}
+
+	version := 0
+
+	for _, sv := range versions {
+		for o.ReplayDeletions && version+1 < sv.Version {
+			if err := writeVersion(rootPath, subPath, &vault.SecretVersion{Destroyed: true}, version); err != nil {
+				return err
+			}
+
+			version++
+		}
+
+		if o.ReplayDeletions && sv.Version != version+1 {
+			return fmt.Errorf("version %d of the export cannot be written as version %d", sv.Version, version+1)
+		}
+
+		if err := writeVersion(rootPath, subPath, sv, version); err != nil {
+			return err
+		}
+
+		version++
+	}
+
+	return nil
+}
+
+// writeVersion writes a version of a secret following the given version and deletes or destroys it, if it has no data.
+func writeVersion(rootPath, subPath string, sv *vault.SecretVersion, version int) error {
+	data := sv.Data
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+
+	if err := vaultClient.WriteSecretsCAS(rootContext, "", rootPath, subPath, data, version); err != nil {
+		return err
+	}
+
+	switch {
+	case sv.Data != nil:
+		return nil
+	case sv.Destroyed:
+		return vaultClient.DestroySecret(rootContext, rootPath, subPath, []int{version + 1})
+	default:
+		return vaultClient.DeleteSecret(rootContext, rootPath, subPath, []int{version + 1})
+	}
+}
+
+// checkMaxVersions returns an error, if the destination keeps fewer versions of a secret than are replayed,
+// since Vault would remove the oldest replayed versions.
+func (o *importOptions) checkMaxVersions(rootPath string, secret *vault.VersionedSecret, versions []*vault.SecretVersion) error {
+	if len(versions) == 0 {
+		return nil
+	}
+
+	// the versions from the oldest to the latest replayed version are kept
+	replayed := len(versions)
+	if o.ReplayDeletions {
+		replayed = versions[len(versions)-1].Version - versions[0].Version + 1
+	}
+
+	maxVersions := secret.MaxVersions
+	if maxVersions == 0 {
+		engineMaxVersions, err := vaultClient.ReadEngineMaxVersions(rootContext, rootPath)
+		if err != nil {
+			return err
+		}
+
+		maxVersions = engineMaxVersions
+	}
+
+	// Vault keeps 10 versions, unless configured otherwise
+	if maxVersions == 0 {
+		maxVersions = defaultMaxVersions
+	}
+
+	if replayed > maxVersions {
+		return fmt.Errorf("%d versions are replayed, but the destination only keeps %d versions, increase max_versions of the secret or engine", replayed, maxVersions)
+	}
+
+	return nil
+}
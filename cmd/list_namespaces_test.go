@@ -3,11 +3,11 @@ package cmd
 import (
 	"bytes"
 	"io"
+	"sort"
 	"strings"
 	"testing"
 
 	prt "github.com/FalcoSuessgott/vkv/pkg/printer/namespace"
-	"github.com/FalcoSuessgott/vkv/pkg/utils"
 	"github.com/FalcoSuessgott/vkv/pkg/vault"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -44,7 +44,14 @@ func (s *VaultSuite) TestListNamespacesCommand() {
 	for _, tc := range testCases {
 		s.Run(tc.name, func() {
 			// create ns
-			for _, ns := range utils.SortMapKeys(utils.ToMapStringInterface(tc.ns)) {
+			namespaces := make([]string, 0, len(tc.ns))
+			for ns := range tc.ns {
+				namespaces = append(namespaces, ns)
+			}
+
+			sort.Strings(namespaces)
+
+			for _, ns := range namespaces {
 				nsParts := strings.Split(ns, "/")
 				nsParent := strings.Join(nsParts[:len(nsParts)-1], "/")
 				nsName := nsParts[len(nsParts)-1]
@@ -0,0 +1,244 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	prt "github.com/FalcoSuessgott/vkv/pkg/printer/secret"
+	"github.com/FalcoSuessgott/vkv/pkg/utils"
+	"github.com/FalcoSuessgott/vkv/pkg/vault"
+	"github.com/spf13/cobra"
+)
+
+// pruneOptions holds all available commandline options.
+type pruneOptions struct {
+	Path       string `env:"PATH"`
+	EnginePath string `env:"ENGINE_PATH"`
+
+	KeepLast  int    `env:"KEEP_LAST"`
+	OlderThan string `env:"OLDER_THAN"`
+	Mode      string `env:"MODE" envDefault:"destroy"`
+
+	DryRun bool `env:"DRY_RUN"`
+	Yes    bool `env:"YES"`
+
+	SkipErrors  bool `env:"SKIP_ERRORS" envDefault:"false"`
+	Concurrency int
+
+	olderThan time.Duration
+	input     io.Reader
+}
+
+// NewPruneCmd prune subcommand.
+//
+//nolint:lll
+func NewPruneCmd() *cobra.Command {
+	o := &pruneOptions{}
+
+	if err := utils.ParseEnvs(envVarPrunePrefix, o); err != nil {
+		log.Fatal(err)
+	}
+
+	o.Concurrency = vault.DefaultConcurrency()
+
+	cmd := &cobra.Command{
+		Use:           "prune",
+		Short:         "recursively destroy or delete KVv2 secret versions outside a retention policy",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PreRunE:       o.validateFlags,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			vaultClient.SetConcurrency(o.Concurrency)
+
+			rootPath, subPath := utils.HandleEnginePath(o.EnginePath, o.Path)
+
+			prunable, err := vaultClient.ListRecursivePrunable(rootContext, rootPath, subPath, vault.RetentionPolicy{
+				KeepLast:  o.KeepLast,
+				OlderThan: o.olderThan,
+			}, o.SkipErrors)
+			if err != nil {
+				return err
+			}
+
+			if o.Mode == deleteModeDelete {
+				prunable = withoutDeletedVersions(prunable)
+			}
+
+			if len(prunable) == 0 {
+				fmt.Fprintf(writer, "no versions to prune found in \"%s\"\n", path.Join(rootPath, subPath))
+
+				return nil
+			}
+
+			paths := make([]string, 0, len(prunable))
+			versions := 0
+
+			for p, vs := range prunable {
+				paths = append(paths, p)
+				versions += len(vs.Versions)
+			}
+
+			sort.Strings(paths)
+
+			// the versions to be pruned are always shown before pruning them
+			fmt.Fprintf(writer, "the following %d versions of %d secrets will be %s:\n\n", versions, len(paths), o.description())
+
+			printer = prt.NewSecretPrinter(
+				prt.ToFormat(prt.Base),
+				prt.WithVaultClient(vaultClient),
+				prt.WithWriter(writer),
+				prt.WithEnginePath(utils.NormalizePath(rootPath)),
+				prt.WithContext(rootContext),
+			)
+
+			if err := printer.Out(prunable); err != nil {
+				return err
+			}
+
+			fmt.Fprintln(writer, "")
+
+			if o.DryRun {
+				fmt.Fprintln(writer, "prune the versions by omitting the --dry-run flag")
+
+				return nil
+			}
+
+			if !o.Yes {
+				ok, err := confirm(o.input, fmt.Sprintf("%s %d versions of %d secrets in \"%s\"?", o.Mode, versions, len(paths), path.Join(rootPath, subPath)))
+				if err != nil {
+					return err
+				}
+
+				if !ok {
+					fmt.Fprintln(writer, "aborted, no versions have been pruned")
+
+					return nil
+				}
+			}
+
+			pruned := 0
+
+			for _, p := range paths {
+				numbers := []int{}
+				for _, sv := range prunable[p].Versions {
+					numbers = append(numbers, sv.Version)
+				}
+
+				if err := o.prune(rootPath, p, numbers); err != nil {
+					if o.SkipErrors {
+						continue
+					}
+
+					return fmt.Errorf("error pruning secret \"%s\": %w", path.Join(rootPath, p), err)
+				}
+
+				pruned += len(numbers)
+
+				fmt.Fprintf(writer, "%s %d versions of secret \"%s\"\n", o.description(), len(numbers), path.Join(rootPath, p))
+			}
+
+			fmt.Fprintf(writer, "successfully %s %d versions\n", o.description(), pruned)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().SortFlags = false
+
+	// Input
+	cmd.Flags().StringVarP(&o.Path, "path", "p", o.Path, "KV Engine path (env: VKV_PRUNE_PATH)")
+	cmd.Flags().StringVarP(&o.EnginePath, "engine-path", "e", o.EnginePath, "engine path in case your KV-engine contains special characters such as \"/\", the path (-p) flag will then be appended if specified (\"<engine-path>/<path>\") (env: VKV_PRUNE_ENGINE_PATH)")
+	cmd.Flags().BoolVar(&o.SkipErrors, "skip-errors", o.SkipErrors, "don't exit on errors (permission denied, deleted secrets) (env: VKV_PRUNE_SKIP_ERRORS)")
+	cmd.Flags().IntVar(&o.Concurrency, "concurrency", o.Concurrency, "maximum number of concurrent requests sent to Vault while reading secrets (env: VKV_CONCURRENCY)")
+
+	// Retention
+	cmd.Flags().IntVar(&o.KeepLast, "keep-last", o.KeepLast, "retain the given number of latest versions of every secret, destroyed versions are not counted (env: VKV_PRUNE_KEEP_LAST)")
+	cmd.Flags().StringVar(&o.OlderThan, "older-than", o.OlderThan, "only prune versions older than the given duration, e.g. \"90d\" or \"12h\" (env: VKV_PRUNE_OLDER_THAN)")
+	cmd.Flags().StringVarP(&o.Mode, "mode", "m", o.Mode, "\"destroy\" permanently removes the data of the versions, \"delete\" soft deletes them (env: VKV_PRUNE_MODE)")
+
+	// Options
+	cmd.Flags().BoolVarP(&o.DryRun, "dry-run", "d", o.DryRun, "only print the versions that would be pruned (env: VKV_PRUNE_DRY_RUN)")
+	cmd.Flags().BoolVarP(&o.Yes, "yes", "y", o.Yes, "don't ask for confirmation before pruning the versions (env: VKV_PRUNE_YES)")
+
+	o.input = cmd.InOrStdin()
+
+	return cmd
+}
+
+func (o *pruneOptions) validateFlags(cmd *cobra.Command, args []string) error {
+	o.Mode = strings.ToLower(o.Mode)
+
+	switch {
+	case o.EnginePath == "" && o.Path == "":
+		return errors.New("no KV-paths given. Either --engine-path/-e or --path/-p needs to be specified")
+	case o.KeepLast == 0 && o.OlderThan == "":
+		return errors.New("either --keep-last or --older-than needs to be specified")
+	case o.KeepLast < 0:
+		return errors.New("--keep-last needs to be a positive number")
+	case o.Mode != deleteModeDestroy && o.Mode != deleteModeDelete:
+		return fmt.Errorf("invalid mode \"%s\" (valid options: destroy, delete)", o.Mode)
+	case o.DryRun && o.Yes:
+		return fmt.Errorf("%w: %s", errInvalidFlagCombination, "cannot specify both --dry-run and --yes")
+	case o.OlderThan != "":
+		d, err := utils.ParseDuration(o.OlderThan)
+		if err != nil {
+			return fmt.Errorf("invalid --older-than duration %q, expected e.g. \"90d\" or \"12h\": %w", o.OlderThan, err)
+		}
+
+		if d <= 0 {
+			return fmt.Errorf("--older-than needs to be a positive duration, got %q", o.OlderThan)
+		}
+
+		o.olderThan = d
+	}
+
+	return nil
+}
+
+// prune destroys or deletes the given versions of a secret according to the mode.
+func (o *pruneOptions) prune(rootPath, subPath string, versions []int) error {
+	if o.Mode == deleteModeDelete {
+		return vaultClient.DeleteSecret(rootContext, rootPath, subPath, versions)
+	}
+
+	return vaultClient.DestroySecret(rootContext, rootPath, subPath, versions)
+}
+
+// description returns what happens to the versions in the mode.
+func (o *pruneOptions) description() string {
+	if o.Mode == deleteModeDelete {
+		return "deleted"
+	}
+
+	return "destroyed"
+}
+
+// withoutDeletedVersions removes the versions that have already been deleted.
+func withoutDeletedVersions(secrets vault.VersionedSecrets) vault.VersionedSecrets {
+	res := make(vault.VersionedSecrets)
+
+	for p, vs := range secrets {
+		versions := []*vault.SecretVersion{}
+
+		for _, sv := range vs.Versions {
+			if !sv.DeletedAt(time.Now()) {
+				versions = append(versions, sv)
+			}
+		}
+
+		if len(versions) > 0 {
+			res[p] = &vault.VersionedSecret{
+				CustomMetadata: vs.CustomMetadata,
+				Versions:       versions,
+			}
+		}
+	}
+
+	return res
+}
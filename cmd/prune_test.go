@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+)
+
+func (s *VaultSuite) TestValidatePruneFlags() {
+	testCases := []struct {
+		name string
+		args []string
+		err  bool
+	}{
+		{
+			name: "path missing",
+			args: []string{"--keep-last=1", "--dry-run"},
+			err:  true,
+		},
+		{
+			name: "retention missing",
+			args: []string{"-p=secret", "--dry-run"},
+			err:  true,
+		},
+		{
+			name: "invalid duration",
+			args: []string{"-p=secret", "--older-than=90", "--dry-run"},
+			err:  true,
+		},
+		{
+			name: "invalid mode",
+			args: []string{"-p=secret", "--keep-last=1", "--mode=metadata", "--dry-run"},
+			err:  true,
+		},
+		{
+			name: "negative keep last",
+			args: []string{"-p=secret", "--keep-last=-1", "--dry-run"},
+			err:  true,
+		},
+		{
+			name: "zero older than",
+			args: []string{"-p=secret", "--older-than=0s", "--dry-run"},
+			err:  true,
+		},
+		{
+			name: "negative older than",
+			args: []string{"-p=secret", "--older-than=-5d", "--dry-run"},
+			err:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		cmd := NewPruneCmd()
+		cmd.SetArgs(tc.args)
+
+		err := cmd.Execute()
+
+		s.Require().Equal(tc.err, err != nil, tc.name)
+	}
+}
+
+func (s *VaultSuite) TestPruneCommand() {
+	s.Run("prune", func() {
+		ctx := context.Background()
+
+		s.Require().NoError(vaultClient.EnableKV2Engine(ctx, "prune"))
+
+		for _, v := range []string{"v1", "v2", "v3"} {
+			s.Require().NoError(vaultClient.WriteSecrets(ctx, "", "prune", "admin", map[string]interface{}{"user": v}))
+		}
+
+		s.Require().NoError(vaultClient.WriteSecrets(ctx, "", "prune", "sub/demo", map[string]interface{}{"foo": "bar"}))
+
+		// dry run only prints the plan
+		b := bytes.NewBufferString("")
+		writer = b
+
+		dryRunCmd := NewPruneCmd()
+		dryRunCmd.SetArgs([]string{"-p=prune", "--keep-last=1", "--dry-run"})
+
+		s.Require().NoError(dryRunCmd.Execute())
+		s.Require().Contains(b.String(), "the following 2 versions of 1 secrets will be destroyed")
+
+		// nothing is older than a day
+		b.Reset()
+
+		olderThanCmd := NewPruneCmd()
+		olderThanCmd.SetArgs([]string{"-p=prune", "--keep-last=1", "--older-than=1d", "--yes"})
+
+		s.Require().NoError(olderThanCmd.Execute())
+		s.Require().Contains(b.String(), "no versions to prune found")
+
+		// prune after confirming
+		b.Reset()
+
+		pruneCmd := NewPruneCmd()
+		pruneCmd.SetIn(strings.NewReader("y\n"))
+		pruneCmd.SetArgs([]string{"-p=prune", "--keep-last=2"})
+
+		s.Require().NoError(pruneCmd.Execute())
+		s.Require().Contains(b.String(), "destroyed 1 versions of secret \"prune/admin\"")
+		s.Require().Contains(b.String(), "successfully destroyed 1 versions")
+
+		md, err := vaultClient.ReadMetadata(ctx, "prune", "admin")
+		s.Require().NoError(err)
+
+		for _, sv := range md.Versions {
+			s.Require().Equal(sv.Version == 1, sv.Destroyed)
+		}
+	})
+}
@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"time"
+
+	"github.com/FalcoSuessgott/vkv/pkg/diff"
+	prt "github.com/FalcoSuessgott/vkv/pkg/printer/secret"
+	"github.com/FalcoSuessgott/vkv/pkg/utils"
+	"github.com/FalcoSuessgott/vkv/pkg/vault"
+	"github.com/spf13/cobra"
+)
+
+// rollbackOptions holds all available commandline options.
+type rollbackOptions struct {
+	Path       string `env:"PATH"`
+	EnginePath string `env:"ENGINE_PATH"`
+
+	To       string `env:"TO"`
+	Versions int    `env:"VERSIONS"`
+
+	DryRun         bool `env:"DRY_RUN"`
+	ShowValues     bool `env:"SHOW_VALUES"`
+	MaxValueLength int  `env:"MAX_VALUE_LENGTH" envDefault:"12"`
+
+	CASRetries int `env:"CAS_RETRIES"`
+
+	SkipErrors  bool `env:"SKIP_ERRORS" envDefault:"false"`
+	Concurrency int
+
+	to time.Time
+}
+
+// NewRollbackCmd rollback subcommand.
+//
+//nolint:lll
+func NewRollbackCmd() *cobra.Command {
+	o := &rollbackOptions{}
+
+	if err := utils.ParseEnvs(envVarRollbackPrefix, o); err != nil {
+		log.Fatal(err)
+	}
+
+	o.Concurrency = vault.DefaultConcurrency()
+
+	cmd := &cobra.Command{
+		Use:           "rollback",
+		Short:         "roll back all KVv2 secrets of a path to an earlier point in time or version",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PreRunE:       o.validateFlags,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			vaultClient.SetConcurrency(o.Concurrency)
+
+			rootPath, subPath := utils.HandleEnginePath(o.EnginePath, o.Path)
+
+			current, err := vaultClient.ListSecretTree(rootContext, "", rootPath, subPath, o.SkipErrors, false)
+			if err != nil {
+				return err
+			}
+
+			previous, err := o.listPrevious(rootPath, subPath)
+			if err != nil {
+				return err
+			}
+
+			metadata := previous.SecretsMetadata()
+			target := previous.FlattenRelative()
+			source := current.FlattenRelative()
+
+			// only the secrets that are rolled back are compared
+			for p := range source {
+				if _, ok := target[p]; !ok {
+					delete(source, p)
+				}
+			}
+
+			label := path.Join(rootPath, subPath)
+			changes := diff.Compare(source, target)
+
+			if o.DryRun {
+				printer = prt.NewSecretPrinter(
+					prt.CustomValueLength(o.MaxValueLength),
+					prt.ShowValues(o.ShowValues),
+					prt.ToFormat(prt.Base),
+					prt.WithWriter(writer),
+					prt.WithContext(rootContext),
+				)
+
+				if err := printer.Out(&diff.Result{
+					Source:  label,
+					Target:  o.targetLabel(label),
+					Changes: changes,
+				}); err != nil {
+					return err
+				}
+
+				if len(changes) > 0 {
+					fmt.Fprintln(writer, "")
+					fmt.Fprintln(writer, "apply changes by omitting the --dry-run flag")
+				}
+
+				return nil
+			}
+
+			rolledBack := 0
+
+			for _, c := range changes {
+				p := path.Join(subPath, c.Path)
+
+				if err := writeSecretCAS(vaultClient, "", rootPath, p, target[c.Path], o.CASRetries); err != nil {
+					if o.SkipErrors {
+						continue
+					}
+
+					return fmt.Errorf("error rolling back secret \"%s\": %w", path.Join(rootPath, p), err)
+				}
+
+				fmt.Fprintf(writer, "rolled back secret \"%s\" to version %d\n", path.Join(rootPath, p), metadata[p].CurrentVersion)
+
+				rolledBack++
+			}
+
+			fmt.Fprintf(writer, "successfully rolled back %d secrets to %s\n", rolledBack, o.targetLabel(label))
+
+			return nil
+		},
+	}
+
+	cmd.Flags().SortFlags = false
+
+	// Input
+	cmd.Flags().StringVarP(&o.Path, "path", "p", o.Path, "KV Engine path (env: VKV_ROLLBACK_PATH)")
+	cmd.Flags().StringVarP(&o.EnginePath, "engine-path", "e", o.EnginePath, "engine path in case your KV-engine contains special characters such as \"/\", the path (-p) flag will then be appended if specified (\"<engine-path>/<path>\") (env: VKV_ROLLBACK_ENGINE_PATH)")
+	cmd.Flags().BoolVar(&o.SkipErrors, "skip-errors", o.SkipErrors, "don't exit on errors (permission denied, deleted secrets) (env: VKV_ROLLBACK_SKIP_ERRORS)")
+	cmd.Flags().IntVar(&o.Concurrency, "concurrency", o.Concurrency, "maximum number of concurrent requests sent to Vault while reading secrets (env: VKV_CONCURRENCY)")
+
+	// Version
+	cmd.Flags().StringVar(&o.To, "to", o.To, "roll back to the versions that were current at the given RFC3339 timestamp, e.g. \"2026-09-01T00:00:00Z\" (env: VKV_ROLLBACK_TO)")
+	cmd.Flags().IntVar(&o.Versions, "versions", o.Versions, "roll back each secret by the given number of versions (env: VKV_ROLLBACK_VERSIONS)")
+
+	// Options
+	cmd.Flags().BoolVarP(&o.DryRun, "dry-run", "d", o.DryRun, "only print the secrets that would be rolled back (env: VKV_ROLLBACK_DRY_RUN)")
+	cmd.Flags().BoolVar(&o.ShowValues, "show-values", o.ShowValues, "don't mask values (env: VKV_ROLLBACK_SHOW_VALUES)")
+	cmd.Flags().IntVar(&o.MaxValueLength, "max-value-length", o.MaxValueLength, "maximum char length of values. Set to \"-1\" for disabling "+
+		"(env: VKV_ROLLBACK_MAX_VALUE_LENGTH)")
+	cmd.Flags().IntVar(&o.CASRetries, "cas-retries", o.CASRetries, "number of times the write of a secret that has been modified concurrently is retried (env: VKV_ROLLBACK_CAS_RETRIES)")
+
+	return cmd
+}
+
+func (o *rollbackOptions) validateFlags(cmd *cobra.Command, args []string) error {
+	switch {
+	case o.EnginePath == "" && o.Path == "":
+		return errors.New("no KV-paths given. Either --engine-path/-e or --path/-p needs to be specified")
+	case o.To != "" && o.Versions != 0:
+		return fmt.Errorf("%w: %s", errInvalidFlagCombination, "cannot specify both --to and --versions")
+	case o.To == "" && o.Versions == 0:
+		return errors.New("either --to or --versions needs to be specified")
+	case o.Versions < 0:
+		return errors.New("--versions needs to be a positive number")
+	case o.CASRetries < 0:
+		return errors.New("--cas-retries needs to be a positive number")
+	case o.To != "":
+		t, err := time.Parse(time.RFC3339, o.To)
+		if err != nil {
+			return fmt.Errorf("invalid --to timestamp %q, expected RFC3339 (e.g. \"2026-09-01T00:00:00Z\"): %w", o.To, err)
+		}
+
+		o.to = t
+	}
+
+	return nil
+}
+
+// listPrevious reads the secrets in the versions they are rolled back to.
+func (o *rollbackOptions) listPrevious(rootPath, subPath string) (*vault.SecretTree, error) {
+	if o.Versions > 0 {
+		return vaultClient.ListSecretTreeVersionsBack(rootContext, rootPath, subPath, o.Versions, o.SkipErrors)
+	}
+
+	return vaultClient.ListSecretTreeAsOf(rootContext, rootPath, subPath, o.to, o.SkipErrors)
+}
+
+// targetLabel returns the label of the point in time the secrets are rolled back to.
+func (o *rollbackOptions) targetLabel(label string) string {
+	if o.Versions > 0 {
+		return fmt.Sprintf("%s (%d versions back)", label, o.Versions)
+	}
+
+	return fmt.Sprintf("%s (as of %s)", label, o.to.Format(time.RFC3339))
+}
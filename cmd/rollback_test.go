@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"github.com/FalcoSuessgott/vkv/pkg/vault"
+)
+
+func (s *VaultSuite) TestValidateRollbackFlags() {
+	testCases := []struct {
+		name string
+		args []string
+		err  bool
+	}{
+		{
+			name: "path missing",
+			args: []string{"--versions=1"},
+			err:  true,
+		},
+		{
+			name: "version missing",
+			args: []string{"-p=secret"},
+			err:  true,
+		},
+		{
+			name: "to and versions mutually exclusive",
+			args: []string{"-p=secret", "--versions=1", "--to=2026-09-01T00:00:00Z"},
+			err:  true,
+		},
+		{
+			name: "invalid timestamp",
+			args: []string{"-p=secret", "--to=yesterday"},
+			err:  true,
+		},
+		{
+			name: "negative versions",
+			args: []string{"-p=secret", "--versions=-1"},
+			err:  true,
+		},
+		{
+			name: "negative cas retries",
+			args: []string{"-p=secret", "--versions=1", "--cas-retries=-1"},
+			err:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		cmd := NewRollbackCmd()
+		cmd.SetArgs(tc.args)
+
+		err := cmd.Execute()
+
+		s.Require().Equal(tc.err, err != nil, tc.name)
+	}
+}
+
+func (s *VaultSuite) TestRollbackCommand() {
+	s.Run("rollback versions", func() {
+		ctx := context.Background()
+
+		s.Require().NoError(vaultClient.EnableKV2Engine(ctx, "rollback"))
+
+		s.Require().NoError(vaultClient.WriteSecrets(ctx, "", "rollback", "admin", map[string]interface{}{"user": "v1"}))
+		s.Require().NoError(vaultClient.WriteSecrets(ctx, "", "rollback", "admin", map[string]interface{}{"user": "v2"}))
+		s.Require().NoError(vaultClient.WriteSecrets(ctx, "", "rollback", "sub/demo", map[string]interface{}{"foo": "bar"}))
+
+		// secrets requiring check-and-set can be rolled back
+		s.Require().NoError(vaultClient.WriteSecretSettings(ctx, "", "rollback", "admin", &vault.SecretSettings{CASRequired: true}))
+
+		// dry run does not write anything
+		b := bytes.NewBufferString("")
+		writer = b
+
+		dryRunCmd := NewRollbackCmd()
+		dryRunCmd.SetArgs([]string{"-p=rollback", "--versions=1", "--dry-run", "--show-values"})
+
+		s.Require().NoError(dryRunCmd.Execute())
+		s.Require().Contains(b.String(), "user=v2 -> v1")
+
+		secret, err := vaultClient.ReadSecrets(ctx, "", "rollback", "admin")
+		s.Require().NoError(err)
+		s.Require().Equal(map[string]interface{}{"user": "v2"}, secret)
+
+		// rollback writes the previous version as a new version
+		b.Reset()
+
+		rollbackCmd := NewRollbackCmd()
+		rollbackCmd.SetArgs([]string{"-p=rollback", "--versions=1"})
+
+		s.Require().NoError(rollbackCmd.Execute())
+		s.Require().Contains(b.String(), "rolled back secret \"rollback/admin\" to version 1")
+
+		secret, err = vaultClient.ReadSecrets(ctx, "", "rollback", "admin")
+		s.Require().NoError(err)
+		s.Require().Equal(map[string]interface{}{"user": "v1"}, secret)
+
+		// sub/demo has no previous version and is left untouched
+		secret, err = vaultClient.ReadSecrets(ctx, "", "rollback", "sub/demo")
+		s.Require().NoError(err)
+		s.Require().Equal(map[string]interface{}{"foo": "bar"}, secret)
+
+		// rolling back to now does not change anything
+		b.Reset()
+
+		nowCmd := NewRollbackCmd()
+		nowCmd.SetArgs([]string{"-p=rollback", "--to=" + time.Now().Add(time.Minute).Format(time.RFC3339)})
+
+		s.Require().NoError(nowCmd.Execute())
+		s.Require().Contains(b.String(), "successfully rolled back 0 secrets")
+	})
+}
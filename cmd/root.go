@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"io"
+	"log"
 	"os"
 	"strings"
 
@@ -21,6 +22,14 @@ const (
 	envVarListNamespacePrefix   = "VKV_LIST_NAMESPACES_"
 	envVarSnapshotRestorePrefix = "VKV_SNAPSHOT_RESTORE_"
 	envVarSnapshotSavePrefix    = "VKV_SNAPSHOT_SAVE_"
+	envVarDiffPrefix            = "VKV_DIFF_"
+	envVarRollbackPrefix        = "VKV_ROLLBACK_"
+	envVarDeletePrefix          = "VKV_DELETE_"
+	envVarUndeletePrefix        = "VKV_UNDELETE_"
+	envVarPrunePrefix           = "VKV_PRUNE_"
+	envVarCopyPrefix            = "VKV_CP_"
+	envVarMovePrefix            = "VKV_MV_"
+	envVarSyncPrefix            = "VKV_SYNC_"
 )
 
 var (
@@ -35,8 +44,13 @@ var (
 
 // NewRootCmd vkv root command.
 //
-//nolint:cyclop
+//nolint:cyclop,lll
 func NewRootCmd() *cobra.Command {
+	auth, err := vault.AuthOptionsFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	cmd := &cobra.Command{
 		Use:           "vkv",
 		Short:         "The swiss army knife when working with Vault KV engines",
@@ -57,7 +71,7 @@ func NewRootCmd() *cobra.Command {
 
 			// otherwise create a new vault client
 			if _, ok := os.LookupEnv(envVarVKVMode); ok || cmd.HasParent() {
-				vc, err := vault.NewDefaultClient(rootContext)
+				vc, err := vault.NewDefaultClientWithAuth(rootContext, auth)
 				if err != nil {
 					return err
 				}
@@ -89,18 +103,55 @@ func NewRootCmd() *cobra.Command {
 				return NewSnapshotRestoreCmd().Execute()
 			case "SNAPSHOT_SAVE":
 				return NewSnapshotSaveCmd().Execute()
+			case "DIFF":
+				return NewDiffCmd().Execute()
+			case "ROLLBACK":
+				return NewRollbackCmd().Execute()
+			case "DELETE":
+				return NewDeleteCmd().Execute()
+			case "UNDELETE":
+				return NewUndeleteCmd().Execute()
+			case "PRUNE":
+				return NewPruneCmd().Execute()
+			case "CP":
+				return NewCopyCmd().Execute()
+			case "MV":
+				return NewMoveCmd().Execute()
+			case "SYNC":
+				return NewSyncCmd().Execute()
 			default:
 				return errors.New("invalid value for VKV_MODE")
 			}
 		},
 	}
 
+	// auth method flags, secret values (secret id, jwt, password) are only read from env vars or files,
+	// so they don't show up in the process list
+	cmd.PersistentFlags().StringVar(&auth.Method, "auth-method", auth.Method, "auth method to log in with (approle, kubernetes, jwt, oidc, userpass) (env: VKV_AUTH_METHOD)")
+	cmd.PersistentFlags().StringVar(&auth.Mount, "auth-mount", auth.Mount, "mount path of the auth method, defaults to the auth method (env: VKV_AUTH_MOUNT)")
+	cmd.PersistentFlags().StringVar(&auth.Role, "auth-role", auth.Role, "role to log in with (kubernetes, jwt, oidc) (env: VKV_AUTH_ROLE)")
+	cmd.PersistentFlags().StringVar(&auth.RoleID, "auth-role-id", auth.RoleID, "role id to log in with (approle) (env: VKV_AUTH_ROLE_ID)")
+	cmd.PersistentFlags().StringVar(&auth.RoleIDFile, "auth-role-id-file", auth.RoleIDFile, "file containing the role id (approle) (env: VKV_AUTH_ROLE_ID_FILE)")
+	cmd.PersistentFlags().StringVar(&auth.SecretIDFile, "auth-secret-id-file", auth.SecretIDFile, "file containing the secret id (approle) (env: VKV_AUTH_SECRET_ID_FILE)")
+	cmd.PersistentFlags().StringVar(&auth.KubernetesTokenFile, "auth-kubernetes-token-file", auth.KubernetesTokenFile, "file containing the service account token (kubernetes) (env: VKV_AUTH_KUBERNETES_TOKEN_FILE)")
+	cmd.PersistentFlags().StringVar(&auth.JWTFile, "auth-jwt-file", auth.JWTFile, "file containing the jwt (jwt, oidc) (env: VKV_AUTH_JWT_FILE)")
+	cmd.PersistentFlags().StringVar(&auth.Username, "auth-username", auth.Username, "username to log in with (userpass) (env: VKV_AUTH_USERNAME)")
+	cmd.PersistentFlags().StringVar(&auth.PasswordFile, "auth-password-file", auth.PasswordFile, "file containing the password (userpass) (env: VKV_AUTH_PASSWORD_FILE)")
+
 	// sub commands
 	cmd.AddCommand(
 		NewExportCmd(),
+		NewDiffCmd(),
 		NewListCmd(),
 		NewSnapshotCmd(),
 		NewImportCmd(),
+		NewRollbackCmd(),
+		NewDeleteCmd(),
+		NewUndeleteCmd(),
+		NewPruneCmd(),
+		NewCopyCmd(),
+		NewMoveCmd(),
+		NewSyncCmd(),
 		NewServerCmd(),
 		NewDocCmd(),
 		NewMCPCmd(),
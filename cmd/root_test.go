@@ -112,7 +112,7 @@ func (s *VaultSuite) TestMode() {
 			// write secrets
 			for k, secrets := range tc.secrets {
 				if m, ok := secrets.(map[string]interface{}); ok {
-					s.Require().NoError(vaultClient.WriteSecrets(rootContext, "e2e", k, m))
+					s.Require().NoError(vaultClient.WriteSecrets(rootContext, "", "e2e", k, m))
 				}
 			}
 
@@ -10,12 +10,12 @@ import (
 	"net"
 	"net/http"
 	"os"
-	"path"
 	"strings"
 	"time"
 
 	prt "github.com/FalcoSuessgott/vkv/pkg/printer/secret"
 	"github.com/FalcoSuessgott/vkv/pkg/utils"
+	"github.com/FalcoSuessgott/vkv/pkg/vault"
 	"github.com/spf13/cobra"
 )
 
@@ -25,6 +25,8 @@ type serverOptions struct {
 	EnginePath string `env:"ENGINE_PATH"`
 	SkipErrors bool   `env:"SKIP_ERRORS" envDefault:"false"`
 
+	Concurrency int
+
 	writer *bytes.Buffer
 }
 
@@ -43,6 +45,8 @@ func NewServerCmd() *cobra.Command {
 		log.Fatal(err)
 	}
 
+	o.Concurrency = vault.DefaultConcurrency()
+
 	cmd := &cobra.Command{
 		Use:           "server",
 		Short:         "expose a http server that returns the read secrets from Vault, useful during CI",
@@ -50,6 +54,8 @@ func NewServerCmd() *cobra.Command {
 		SilenceErrors: true,
 		PreRunE:       o.validateFlags,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			vaultClient.SetConcurrency(o.Concurrency)
+
 			fmt.Fprintf(writer, "mirroring secrets from path: \"%s\" to \"%s/export\"\n", o.Path, o.Port)
 
 			return o.serve()
@@ -63,6 +69,7 @@ func NewServerCmd() *cobra.Command {
 	cmd.Flags().StringVarP(&o.Path, "path", "p", o.Path, "KVv2 Engine path (env: VKV_SERVER_PATH)")
 	cmd.Flags().StringVarP(&o.EnginePath, "engine-path", "e", o.EnginePath, "engine path in case your KV-engine contains special characters such as \"/\", the path value will then be appended if specified (\"<engine-path>/<path>\") (env: VKV_SERVER_ENGINE_PATH)")
 	cmd.Flags().BoolVar(&o.SkipErrors, "skip-errors", o.SkipErrors, "dont exit on errors (permission denied, deleted secrets) (env: VKV_SERVER_SKIP_ERRORS)")
+	cmd.Flags().IntVar(&o.Concurrency, "concurrency", o.Concurrency, "maximum number of concurrent requests sent to Vault while reading secrets (env: VKV_CONCURRENCY)")
 
 	return cmd
 }
@@ -78,30 +85,12 @@ func (o *serverOptions) validateFlags(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func (o *serverOptions) buildMap() (map[string]interface{}, error) {
+// listSecrets reads the secrets of the path to be served.
+func (o *serverOptions) listSecrets() (*vault.SecretTree, error) {
 	rootPath, subPath := utils.HandleEnginePath(o.EnginePath, o.Path)
 
 	// read recursive all secrets
-	s, err := vaultClient.ListRecursive(rootContext, rootPath, subPath, o.SkipErrors)
-	if err != nil {
-		return nil, err
-	}
-
-	path := path.Join(rootPath, subPath)
-	if o.EnginePath != "" {
-		path = subPath
-	}
-
-	// prepare the output map
-	pathMap := utils.UnflattenMap(path, utils.ToMapStringInterface(s))
-
-	if o.EnginePath != "" {
-		return map[string]interface{}{
-			o.EnginePath: pathMap,
-		}, nil
-	}
-
-	return pathMap, nil
+	return vaultClient.ListSecretTree(rootContext, "", rootPath, subPath, o.SkipErrors, false)
 }
 
 func (o *serverOptions) serve() error {
@@ -156,7 +145,7 @@ func (o *serverOptions) export() http.HandlerFunc {
 			prt.ShowValues(true),
 			prt.WithVaultClient(vaultClient),
 			prt.WithWriter(o.writer),
-			prt.WithEnginePath(enginePath),
+			prt.WithEnginePath(utils.NormalizePath(enginePath)),
 			prt.ToFormat(prt.Export),
 			//nolint: contextcheck
 			prt.WithContext(rootContext),
@@ -191,12 +180,12 @@ func (o *serverOptions) export() http.HandlerFunc {
 		w.WriteHeader(http.StatusOK)
 		//nolint: errcheck, contextcheck
 		w.Write(func() []byte {
-			m, err := o.buildMap()
+			tree, err := o.listSecrets()
 			if err != nil {
 				log.Fatal(err)
 			}
 
-			if err := printer.Out(m); err != nil {
+			if err := printer.Out(tree); err != nil {
 				log.Fatal(err)
 			}
 
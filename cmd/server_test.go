@@ -23,7 +23,7 @@ func (s *VaultSuite) TestServerCommand() {
 		// write secrets
 		for k, secrets := range secrets {
 			if m, ok := secrets.(map[string]interface{}); ok {
-				s.Require().NoError(vaultClient.WriteSecrets(rootContext, "export", k, m))
+				s.Require().NoError(vaultClient.WriteSecrets(rootContext, "", "export", k, m))
 			}
 		}
 
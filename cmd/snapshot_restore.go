@@ -1,11 +1,13 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/FalcoSuessgott/vkv/pkg/fs"
@@ -15,7 +17,8 @@ import (
 )
 
 type snapshotRestoreOptions struct {
-	Source string `env:"SOURCE" envDefault:"./vkv-snapshot-export"`
+	Source     string `env:"SOURCE" envDefault:"./vkv-snapshot-export"`
+	CASRetries int    `env:"CAS_RETRIES"`
 }
 
 func NewSnapshotRestoreCmd() *cobra.Command {
@@ -30,6 +33,7 @@ func NewSnapshotRestoreCmd() *cobra.Command {
 		Short:         "restore the KV engines defined in the specified snapshot",
 		SilenceUsage:  true,
 		SilenceErrors: true,
+		PreRunE:       o.validateFlags,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// make sure source is absolute
 			absolutePath, err := filepath.Abs(o.Source)
@@ -42,10 +46,19 @@ func NewSnapshotRestoreCmd() *cobra.Command {
 	}
 
 	cmd.Flags().StringVarP(&o.Source, "source", "s", o.Source, "source of a vkv snapshot export (env :VKV_SNAPSHOT_RESTORE_SOURCE)")
+	cmd.Flags().IntVar(&o.CASRetries, "cas-retries", o.CASRetries, "number of times the write of a secret that has been modified concurrently is retried (env: VKV_SNAPSHOT_RESTORE_CAS_RETRIES)")
 
 	return cmd
 }
 
+func (o *snapshotRestoreOptions) validateFlags(cmd *cobra.Command, args []string) error {
+	if o.CASRetries < 0 {
+		return errors.New("--cas-retries needs to be a positive number")
+	}
+
+	return nil
+}
+
 // nolint: cyclop
 func (o *snapshotRestoreOptions) restoreSecrets(source string) error {
 	return filepath.Walk(source, func(p string, info os.FileInfo, err error) error {
@@ -69,13 +82,7 @@ func (o *snapshotRestoreOptions) restoreSecrets(source string) error {
 			nsName := nsParts[len(nsParts)-1]
 			nsParent := strings.Join(nsParts[:len(nsParts)-1], "/")
 
-			if ns != "" {
-				if nsParent == "" {
-					nsParent = "root"
-				}
-
-				fmt.Fprintf(writer, "[%s] restore namespace: \"%s\"\n", nsParent, nsName)
-			}
+			fmt.Fprintf(writer, "[%s] restore namespace: \"%s\"\n", nsLabel(nsParent), nsName)
 
 			if err := vaultClient.CreateNamespaceErrorIfNotForced(rootContext, nsParent, nsName, true); err != nil {
 				return err
@@ -85,16 +92,10 @@ func (o *snapshotRestoreOptions) restoreSecrets(source string) error {
 			engine := utils.RemoveExtension(filepath.Base(absPath))
 			ns := strings.Trim(strings.Trim(strings.ReplaceAll(absPath, source, ""), info.Name()), utils.Delimiter)
 
-			if ns == "" {
-				ns = "root"
-			}
+			fmt.Fprintf(writer, "[%s] restore engine: %s\n", nsLabel(ns), engine)
 
-			fmt.Fprintf(writer, "[%s] restore engine: %s\n", ns, engine)
-
-			// create engine
-			vaultClient.Client.SetNamespace(ns)
-
-			if err := vaultClient.EnableKV2EngineErrorIfNotForced(rootContext, true, engine); err != nil {
+			// create engine within the namespace, without changing the namespace of the shared client
+			if err := vaultClient.WithNamespace(ns).EnableKV2EngineErrorIfNotForced(rootContext, true, engine); err != nil {
 				return err
 			}
 
@@ -121,21 +122,67 @@ func (o *snapshotRestoreOptions) restoreSecrets(source string) error {
 }
 
 func (o *snapshotRestoreOptions) writeSecrets(secrets map[string]interface{}, v *vault.Vault, ns, rootPath string) error {
-	transformedMap := make(map[string]interface{})
-	utils.FlattenMap(secrets, transformedMap, "")
+	// the metadata is not a secret, it is written before the secrets, so that their settings apply to the written versions
+	var settings map[string]*vault.SecretSettings
 
-	for p, m := range transformedMap {
-		secrets, ok := m.(map[string]interface{})
-		if !ok {
-			log.Fatalf("cannot convert %T to map[string]interface", secrets)
+	if m, ok := secrets[vault.MetadataKey]; ok {
+		delete(secrets, vault.MetadataKey)
+
+		s, err := vault.ParseSecretSettings(m)
+		if err != nil {
+			return fmt.Errorf("[%s] error reading metadata of engine \"%s\": %w", nsLabel(ns), rootPath, err)
 		}
 
-		if err := v.WriteSecrets(rootContext, rootPath, p, secrets); err != nil {
-			return fmt.Errorf("[%s] error writing secret \"%s\": %w", ns, p, err)
+		settings = s
+	}
+
+	isV1, err := v.WithNamespace(ns).IsKVv1(rootContext, rootPath)
+	if err != nil {
+		return err
+	}
+
+	// an existing KVv1 engine has no metadata
+	if isV1 && len(settings) > 0 {
+		fmt.Fprintf(writer, "[%s] warning: \"%s\" is a KVv1 engine, skipping the metadata of %d secrets\n", nsLabel(ns), rootPath, len(settings))
+
+		settings = nil
+	}
+
+	paths := make([]string, 0, len(settings))
+	for p := range settings {
+		paths = append(paths, p)
+	}
+
+	sort.Strings(paths)
+
+	for _, p := range paths {
+		if err := v.WriteSecretSettings(rootContext, ns, rootPath, p, settings[p]); err != nil {
+			return fmt.Errorf("[%s] error writing metadata of secret \"%s\": %w", nsLabel(ns), p, err)
+		}
+
+		fmt.Fprintf(writer, "[%s] writing metadata of secret \"%s\"\n", nsLabel(ns), path.Join(rootPath, p))
+	}
+
+	for _, s := range vault.SecretTreeFromMap("", secrets).SecretNodes() {
+		if len(s.Data) == 0 {
+			continue
+		}
+
+		if err := writeSecretCAS(v, ns, rootPath, s.Path, s.Data, o.CASRetries); err != nil {
+			return fmt.Errorf("[%s] error writing secret \"%s\": %w", nsLabel(ns), s.Path, err)
 		}
 
-		fmt.Fprintf(writer, "[%s] writing secret \"%s\" \n", ns, path.Join(rootPath, p))
+		fmt.Fprintf(writer, "[%s] writing secret \"%s\" \n", nsLabel(ns), path.Join(rootPath, s.Path))
 	}
 
 	return nil
 }
+
+// nsLabel returns the name of the namespace used in the output, "root" for the root namespace.
+func nsLabel(ns string) string {
+	if ns == "" {
+		return "root"
+	}
+
+	return ns
+}
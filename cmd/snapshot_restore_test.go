@@ -34,6 +34,12 @@ func (s *VaultSuite) TestSnapShotRestoreCommand() {
 
 			s.Require().NoError(cmd.Execute())
 
+			// restoring a snapshot again works for secrets requiring check-and-set
+			cmd = NewSnapshotRestoreCmd()
+			cmd.SetArgs(tc.args)
+
+			s.Require().NoError(cmd.Execute())
+
 			engines, err := vaultClient.ListAllKVSecretEngines(rootContext, "")
 			s.Require().NoError(err)
 
@@ -47,7 +53,7 @@ func (s *VaultSuite) TestSnapShotRestoreCommand() {
 						continue
 					}
 
-					secret, err := vaultClient.ListRecursive(rootContext, path.Join(expNS, engine), "", false)
+					secret, err := vaultClient.ListRecursive(rootContext, "", path.Join(expNS, engine), "", false)
 					s.Require().NoError(err)
 
 					out, err := fs.ReadFile(path.Join("testdata/vkv-snapshot-export", expNS, strings.TrimSuffix(engine, "/")+".yaml"))
@@ -55,7 +61,24 @@ func (s *VaultSuite) TestSnapShotRestoreCommand() {
 
 					res, _ := utils.FromJSON(out)
 
-					s.Require().Equal(res, utils.ToMapStringInterface(secret), tc.name)
+					// the metadata is restored separately
+					settings, ok := res[vault.MetadataKey]
+					delete(res, vault.MetadataKey)
+
+					m, err := utils.LegacyToMap(secret)
+					s.Require().NoError(err)
+					s.Require().Equal(res, m, tc.name)
+
+					if ok {
+						expected, err := vault.ParseSecretSettings(settings)
+						s.Require().NoError(err)
+
+						for p, exp := range expected {
+							md, err := vaultClient.ReadMetadata(rootContext, path.Join(expNS, engine), p)
+							s.Require().NoError(err)
+							s.Require().Equal(exp, md.Settings(), tc.name)
+						}
+					}
 				}
 			}
 		})
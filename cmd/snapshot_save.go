@@ -7,6 +7,7 @@ import (
 	"log"
 	"os"
 	"path"
+	"sort"
 	"strings"
 
 	"github.com/FalcoSuessgott/vkv/pkg/fs"
@@ -20,6 +21,7 @@ type snapshotSaveOptions struct {
 	Namespace   string `env:"NS"`
 	Destination string `env:"DESTINATION" envDefault:"./vkv-snapshot-export"`
 	SkipErrors  bool   `env:"SKIP_ERRORS" envDefault:"false"`
+	Concurrency int
 }
 
 func NewSnapshotSaveCmd() *cobra.Command {
@@ -29,12 +31,16 @@ func NewSnapshotSaveCmd() *cobra.Command {
 		log.Fatal(err)
 	}
 
+	o.Concurrency = vault.DefaultConcurrency()
+
 	cmd := &cobra.Command{
 		Use:           "save",
 		Short:         "create a snapshot of all visible KV engines recursively for all namespaces",
 		SilenceUsage:  true,
 		SilenceErrors: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			vaultClient.SetConcurrency(o.Concurrency)
+
 			engines, err := vaultClient.ListAllKVSecretEngines(rootContext, o.Namespace)
 			if err != nil {
 				return err
@@ -47,13 +53,21 @@ func NewSnapshotSaveCmd() *cobra.Command {
 	cmd.Flags().StringVarP(&o.Namespace, "namespace", "n", o.Namespace, "namespaces from which to save recursively all visible KV engines (env: VKV_SNAPSHOT_SAVE_NS)")
 	cmd.Flags().StringVarP(&o.Destination, "destination", "d", o.Destination, "vkv snapshot destination path (env: VKV_SNAPSHOT_SAVE_DESTINATION)")
 	cmd.Flags().BoolVar(&o.SkipErrors, "skip-errors", o.SkipErrors, "dont exit on errors (permission denied, deleted secrets) (env: VKV_SNAPSHOT_SAVE_SKIP_ERRORS)")
+	cmd.Flags().IntVar(&o.Concurrency, "concurrency", o.Concurrency, "maximum number of concurrent requests sent to Vault while reading secrets (env: VKV_CONCURRENCY)")
 
 	return cmd
 }
 
 // nolint: cyclop
 func (o *snapshotSaveOptions) backupKVEngines(v *vault.Vault, engines map[string][]string) error {
-	for _, ns := range utils.SortMapKeys(utils.ToMapStringInterface(engines)) {
+	namespaces := make([]string, 0, len(engines))
+	for ns := range engines {
+		namespaces = append(namespaces, ns)
+	}
+
+	sort.Strings(namespaces)
+
+	for _, ns := range namespaces {
 		nsDir := path.Join(o.Destination, ns)
 
 		if err := fs.CreateDirectory(nsDir); err != nil {
@@ -63,20 +77,26 @@ func (o *snapshotSaveOptions) backupKVEngines(v *vault.Vault, engines map[string
 		fmt.Fprintf(writer, "created %s\n", nsDir)
 
 		for _, e := range engines[ns] {
-			enginePath := path.Join(ns, e)
-
-			out, err := v.ListRecursive(rootContext, enginePath, "", o.SkipErrors)
+			tree, err := v.ListSecretTree(rootContext, ns, strings.TrimSuffix(e, utils.Delimiter), "", o.SkipErrors, true)
 			if err != nil {
 				return err
 			}
 
+			// snapshots keep the nested shape, in which directories end with a "/", so that older snapshots and vkv versions stay compatible
+			secrets := tree.Map()
+
+			// the metadata of KVv2 secrets is kept in a reserved key, so that it can be restored
+			if settings := tree.SecretsMetadata().SettingsOf(); len(settings) > 0 {
+				secrets[vault.MetadataKey] = settings
+			}
+
 			b := bytes.NewBufferString("")
 
 			printer = prt.NewSecretPrinter(
 				prt.CustomValueLength(-1),
 				prt.ShowValues(true),
 				prt.ToFormat(prt.JSON),
-				prt.WithVaultClient(v),
+				prt.WithVaultClient(v.WithNamespace(ns)),
 				prt.WithWriter(b),
 				prt.ShowVersion(false),
 				prt.ShowMetadata(false),
@@ -84,7 +104,7 @@ func (o *snapshotSaveOptions) backupKVEngines(v *vault.Vault, engines map[string
 				prt.WithContext(rootContext),
 			)
 
-			if err := printer.Out(utils.ToMapStringInterface(out)); err != nil {
+			if err := printer.Out(secrets); err != nil {
 				return err
 			}
 
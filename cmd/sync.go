@@ -0,0 +1,346 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/FalcoSuessgott/vkv/pkg/diff"
+	prt "github.com/FalcoSuessgott/vkv/pkg/printer/secret"
+	"github.com/FalcoSuessgott/vkv/pkg/utils"
+	"github.com/FalcoSuessgott/vkv/pkg/vault"
+	"github.com/spf13/cobra"
+)
+
+// syncOptions holds all available commandline options.
+type syncOptions struct {
+	Path       string `env:"PATH"`
+	EnginePath string `env:"ENGINE_PATH"`
+	Namespace  string `env:"NS"`
+	SourceAddr string `env:"SOURCE_ADDR"`
+
+	DestPath       string `env:"DEST_PATH"`
+	DestEnginePath string `env:"DEST_ENGINE_PATH"`
+	DestNamespace  string `env:"DEST_NS"`
+	DestAddr       string `env:"DEST_ADDR"`
+	DestToken      string `env:"DEST_TOKEN"`
+
+	AllEngines          bool     `env:"ALL_ENGINES"`
+	RecursiveNamespaces bool     `env:"RECURSIVE_NAMESPACES"`
+	MapNamespaces       []string `env:"MAP_NAMESPACES"`
+	MapEngines          []string `env:"MAP_ENGINES"`
+
+	Prune          bool `env:"PRUNE"`
+	MaxDeletions   int  `env:"MAX_DELETIONS" envDefault:"10"`
+	DryRun         bool `env:"DRY_RUN"`
+	ShowValues     bool `env:"SHOW_VALUES"`
+	MaxValueLength int  `env:"MAX_VALUE_LENGTH" envDefault:"12"`
+
+	PlanFile  string `env:"PLAN_FILE"`
+	ApplyPlan string `env:"APPLY_PLAN"`
+
+	CASRetries int `env:"CAS_RETRIES"`
+
+	SkipErrors  bool `env:"SKIP_ERRORS" envDefault:"false"`
+	Concurrency int
+
+	namespaceMapping map[string]string
+	engineMapping    map[string]string
+}
+
+// syncPair is a source and its destination.
+type syncPair struct {
+	source *copyLocation
+	dest   *copyLocation
+}
+
+// NewSyncCmd sync subcommand.
+//
+//nolint:lll
+func NewSyncCmd() *cobra.Command {
+	o := &syncOptions{}
+
+	if err := utils.ParseEnvs(envVarSyncPrefix, o); err != nil {
+		log.Fatal(err)
+	}
+
+	o.Concurrency = vault.DefaultConcurrency()
+
+	cmd := &cobra.Command{
+		Use:           "sync",
+		Short:         "mirror the secrets of KV paths or engines to another Vault server, namespace or engine",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PreRunE:       o.validateFlags,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			vaultClient.SetConcurrency(o.Concurrency)
+
+			// apply a previously created plan instead of reading the source
+			if o.ApplyPlan != "" {
+				return o.applyPlan()
+			}
+
+			source, dest, err := o.clients()
+			if err != nil {
+				return err
+			}
+
+			pairs, err := o.pairs(source, dest)
+			if err != nil {
+				return err
+			}
+
+			for _, p := range pairs {
+				if o.SourceAddr == o.DestAddr && p.source.String() == p.dest.String() {
+					return fmt.Errorf("source and destination of \"%s\" are the same, specify a different destination address, path or namespace", p.source)
+				}
+			}
+
+			printer = prt.NewSecretPrinter(
+				prt.CustomValueLength(o.MaxValueLength),
+				prt.ShowValues(o.ShowValues),
+				prt.ToFormat(prt.Base),
+				prt.WithWriter(writer),
+				prt.WithContext(rootContext),
+			)
+
+			// all pairs are read before anything is written, so that --max-deletions covers the whole sync
+			plan := &syncPlan{DestAddr: o.DestAddr}
+
+			for _, p := range pairs {
+				d, err := o.newPlan(p)
+				if err != nil {
+					return err
+				}
+
+				if o.DryRun {
+					if d.EnableEngine {
+						fmt.Fprintf(writer, "KVv2 engine \"%s\" will be enabled\n", path.Join(p.dest.ns, p.dest.rootPath))
+					}
+
+					if err := printer.Out(&diff.Result{
+						Source:  o.label(o.DestAddr, p.dest),
+						Target:  fmt.Sprintf("%s (synced from %s)", o.label(o.DestAddr, p.dest), d.Source),
+						Changes: d.changes,
+					}); err != nil {
+						return err
+					}
+				}
+
+				plan.Destinations = append(plan.Destinations, d)
+			}
+
+			if o.DryRun {
+				return o.plan(plan)
+			}
+
+			_, deleted := plan.counts()
+
+			if err := checkMaxDeletions(deleted, o.MaxDeletions); err != nil {
+				return err
+			}
+
+			return o.apply(dest, plan)
+		},
+	}
+
+	cmd.Flags().SortFlags = false
+
+	// Source
+	cmd.Flags().StringVarP(&o.Path, "path", "p", o.Path, "KV Engine path of the source (env: VKV_SYNC_PATH)")
+	cmd.Flags().StringVarP(&o.EnginePath, "engine-path", "e", o.EnginePath, "engine path of the source in case your KV-engine contains special characters such as \"/\", the path (-p) flag will then be appended if specified (\"<engine-path>/<path>\") (env: VKV_SYNC_ENGINE_PATH)")
+	cmd.Flags().StringVarP(&o.Namespace, "namespace", "n", o.Namespace, "namespace of the source (env: VKV_SYNC_NS)")
+	cmd.Flags().StringVar(&o.SourceAddr, "source-addr", o.SourceAddr, "Vault address of the source, defaults to VAULT_ADDR (env: VKV_SYNC_SOURCE_ADDR)")
+
+	// Destination
+	cmd.Flags().StringVar(&o.DestPath, "dest-path", o.DestPath, "KV Engine path of the destination, defaults to the source path (env: VKV_SYNC_DEST_PATH)")
+	cmd.Flags().StringVar(&o.DestEnginePath, "dest-engine-path", o.DestEnginePath, "engine path of the destination, the destination path (--dest-path) flag will then be appended if specified (env: VKV_SYNC_DEST_ENGINE_PATH)")
+	cmd.Flags().StringVar(&o.DestNamespace, "dest-namespace", o.DestNamespace, "namespace of the destination, defaults to the source namespace (env: VKV_SYNC_DEST_NS)")
+	cmd.Flags().StringVar(&o.DestAddr, "dest-addr", o.DestAddr, "Vault address of the destination, defaults to VAULT_ADDR. The token is read from VKV_SYNC_DEST_TOKEN and defaults to the one of the source (env: VKV_SYNC_DEST_ADDR)")
+
+	// All engines
+	cmd.Flags().BoolVar(&o.AllEngines, "all-engines", o.AllEngines, "sync all KV engines of the source namespace (env: VKV_SYNC_ALL_ENGINES)")
+	cmd.Flags().BoolVar(&o.RecursiveNamespaces, "recursive-namespaces", o.RecursiveNamespaces, "also sync the KV engines of all child namespaces, requires --all-engines (env: VKV_SYNC_RECURSIVE_NAMESPACES)")
+	cmd.Flags().StringSliceVar(&o.MapNamespaces, "map-namespace", o.MapNamespaces, "map a source namespace to a destination namespace (\"<source>=<destination>\"), requires --all-engines (env: VKV_SYNC_MAP_NAMESPACES)")
+	cmd.Flags().StringSliceVar(&o.MapEngines, "map-engine", o.MapEngines, "map a source engine to a destination engine (\"<source>=<destination>\"), requires --all-engines (env: VKV_SYNC_MAP_ENGINES)")
+
+	cmd.Flags().BoolVar(&o.SkipErrors, "skip-errors", o.SkipErrors, "don't exit on errors (permission denied, deleted secrets) (env: VKV_SYNC_SKIP_ERRORS)")
+	cmd.Flags().IntVar(&o.Concurrency, "concurrency", o.Concurrency, "maximum number of concurrent requests sent to Vault while reading secrets (env: VKV_CONCURRENCY)")
+
+	// Options
+	cmd.Flags().BoolVar(&o.Prune, "prune", o.Prune, "delete the secrets of the destination that do not exist in the source, secrets of the source that cannot be read are never pruned (env: VKV_SYNC_PRUNE)")
+	cmd.Flags().IntVar(&o.MaxDeletions, "max-deletions", o.MaxDeletions, "maximum number of secrets --prune may delete. Set to \"-1\" for disabling (env: VKV_SYNC_MAX_DELETIONS)")
+	cmd.Flags().BoolVarP(&o.DryRun, "dry-run", "d", o.DryRun, "only print the plan of the changes to the destination (env: VKV_SYNC_DRY_RUN)")
+	cmd.Flags().StringVar(&o.PlanFile, "plan-file", o.PlanFile, "save the changes printed during --dry-run to the given file (env: VKV_SYNC_PLAN_FILE)")
+	cmd.Flags().StringVar(&o.ApplyPlan, "apply-plan", o.ApplyPlan, "apply the changes of a plan file created using --plan-file, fails if the destination secrets changed since (env: VKV_SYNC_APPLY_PLAN)")
+	cmd.Flags().BoolVar(&o.ShowValues, "show-values", o.ShowValues, "don't mask values (env: VKV_SYNC_SHOW_VALUES)")
+	cmd.Flags().IntVar(&o.MaxValueLength, "max-value-length", o.MaxValueLength, "maximum char length of values. Set to \"-1\" for disabling "+
+		"(env: VKV_SYNC_MAX_VALUE_LENGTH)")
+	cmd.Flags().IntVar(&o.CASRetries, "cas-retries", o.CASRetries, "number of times the write of a secret that has been modified concurrently is retried (env: VKV_SYNC_CAS_RETRIES)")
+
+	return cmd
+}
+
+// nolint: cyclop
+func (o *syncOptions) validateFlags(cmd *cobra.Command, args []string) error {
+	switch {
+	case o.CASRetries < 0:
+		return errors.New("--cas-retries needs to be a positive number")
+	case o.PlanFile != "" && !o.DryRun:
+		return fmt.Errorf("%w: %s", errInvalidFlagCombination, "--plan-file requires --dry-run")
+	case o.ApplyPlan != "" && (o.DryRun || o.Prune):
+		return fmt.Errorf("%w: %s", errInvalidFlagCombination, "--apply-plan cannot be used with --dry-run or --prune, the plan already contains the secrets to be deleted")
+	case o.ApplyPlan != "" && (o.AllEngines || o.EnginePath != "" || o.Path != "" || o.SourceAddr != "" || o.DestEnginePath != "" || o.DestPath != "" || o.DestNamespace != "" || o.DestAddr != ""):
+		return fmt.Errorf("%w: %s", errInvalidFlagCombination, "--apply-plan cannot be used with a source or destination, the plan already contains them")
+	case o.ApplyPlan != "":
+		return nil
+	case o.AllEngines && (o.EnginePath != "" || o.Path != ""):
+		return fmt.Errorf("%w: %s", errInvalidFlagCombination, "cannot specify both --all-engines and a path")
+	case o.AllEngines && (o.DestEnginePath != "" || o.DestPath != "" || o.DestNamespace != ""):
+		return fmt.Errorf("%w: %s", errInvalidFlagCombination, "--all-engines uses --map-namespace and --map-engine instead of a destination path or namespace")
+	case !o.AllEngines && o.EnginePath == "" && o.Path == "":
+		return errors.New("no source KV-paths given. Either --engine-path/-e, --path/-p or --all-engines needs to be specified")
+	case !o.AllEngines && (o.RecursiveNamespaces || len(o.MapNamespaces) > 0 || len(o.MapEngines) > 0):
+		return fmt.Errorf("%w: %s", errInvalidFlagCombination, "--recursive-namespaces, --map-namespace and --map-engine require --all-engines")
+	}
+
+	var err error
+
+	if o.namespaceMapping, err = parseMapping(o.MapNamespaces); err != nil {
+		return fmt.Errorf("invalid --map-namespace: %w", err)
+	}
+
+	if o.engineMapping, err = parseMapping(o.MapEngines); err != nil {
+		return fmt.Errorf("invalid --map-engine: %w", err)
+	}
+
+	return nil
+}
+
+// clients returns the clients of the source and destination Vault servers.
+func (o *syncOptions) clients() (*vault.Vault, *vault.Vault, error) {
+	source := vaultClient
+
+	if o.SourceAddr != "" {
+		c, err := vaultClient.Clone(o.SourceAddr, "")
+		if err != nil {
+			return nil, nil, err
+		}
+
+		source = c
+	}
+
+	dest := vaultClient
+
+	if o.DestAddr != "" || o.DestToken != "" {
+		c, err := vaultClient.Clone(o.DestAddr, "")
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if o.DestToken != "" {
+			c.Client.SetToken(o.DestToken)
+		}
+
+		dest = c
+	}
+
+	return source, dest, nil
+}
+
+// pairs returns the sources and destinations to be synced.
+func (o *syncOptions) pairs(source, dest *vault.Vault) ([]*syncPair, error) {
+	if !o.AllEngines {
+		rootPath, subPath := utils.HandleEnginePath(o.EnginePath, o.Path)
+		destRootPath, destSubPath := rootPath, subPath
+
+		if o.DestEnginePath != "" || o.DestPath != "" {
+			destRootPath, destSubPath = utils.HandleEnginePath(o.DestEnginePath, o.DestPath)
+		}
+
+		destNamespace := o.Namespace
+		if o.DestNamespace != "" {
+			destNamespace = o.DestNamespace
+		}
+
+		return []*syncPair{{
+			source: newCopyLocation(source, o.Namespace, rootPath, subPath),
+			dest:   newCopyLocation(dest, destNamespace, destRootPath, destSubPath),
+		}}, nil
+	}
+
+	var (
+		engines vault.Engines
+		err     error
+	)
+
+	if o.RecursiveNamespaces {
+		engines, err = source.ListAllKVSecretEngines(rootContext, o.Namespace)
+	} else {
+		var e []string
+
+		e, err = source.ListKVSecretEngines(rootContext, o.Namespace)
+		engines = vault.Engines{o.Namespace: e}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	pairs := []*syncPair{}
+
+	for ns, enginePaths := range engines {
+		for _, e := range enginePaths {
+			e = strings.TrimSuffix(e, utils.Delimiter)
+
+			pairs = append(pairs, &syncPair{
+				source: newCopyLocation(source, ns, e, ""),
+				dest:   newCopyLocation(dest, mapped(o.namespaceMapping, ns), mapped(o.engineMapping, e), ""),
+			})
+		}
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i].source.String() < pairs[j].source.String()
+	})
+
+	return pairs, nil
+}
+
+// label returns the label of a location, prefixed with the Vault address if specified.
+func (o *syncOptions) label(addr string, l *copyLocation) string {
+	label := utils.NormalizePath(l.String())
+	if addr != "" {
+		label = fmt.Sprintf("%s/%s", strings.TrimSuffix(addr, utils.Delimiter), label)
+	}
+
+	return label
+}
+
+// parseMapping parses "<source>=<destination>" mappings.
+func parseMapping(mappings []string) (map[string]string, error) {
+	res := make(map[string]string, len(mappings))
+
+	for _, m := range mappings {
+		src, dst, ok := strings.Cut(m, "=")
+		if !ok || src == "" || dst == "" {
+			return nil, fmt.Errorf("%q, expected \"<source>=<destination>\"", m)
+		}
+
+		res[strings.Trim(src, utils.Delimiter)] = strings.Trim(dst, utils.Delimiter)
+	}
+
+	return res, nil
+}
+
+// mapped returns the mapped value of s, s itself if it is not mapped.
+func mapped(mapping map[string]string, s string) string {
+	if m, ok := mapping[s]; ok {
+		return m
+	}
+
+	return s
+}
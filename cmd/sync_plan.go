@@ -0,0 +1,337 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/FalcoSuessgott/vkv/pkg/diff"
+	"github.com/FalcoSuessgott/vkv/pkg/fs"
+	"github.com/FalcoSuessgott/vkv/pkg/utils"
+	"github.com/FalcoSuessgott/vkv/pkg/vault"
+)
+
+// syncPlan describes the changes a sync applies to the destinations and the state of the
+// destination secrets the plan has been created from.
+type syncPlan struct {
+	DestAddr     string                 `json:"dest_addr,omitempty"`
+	Destinations []*syncPlanDestination `json:"destinations"`
+}
+
+// syncPlanDestination holds the secrets to be written to or deleted from a destination, their paths are
+// relative to the destination path.
+type syncPlanDestination struct {
+	Source       string             `json:"source"`
+	Namespace    string             `json:"namespace,omitempty"`
+	RootPath     string             `json:"root_path"`
+	SubPath      string             `json:"sub_path,omitempty"`
+	EnableEngine bool               `json:"enable_engine,omitempty"`
+	Secrets      []importPlanSecret `json:"secrets"`
+
+	// changes contain the values of the existing secrets and are only used for printing the plan
+	changes []diff.PathChange
+}
+
+// newPlan reads the source and the destination of a pair and compares them.
+// Source secrets that cannot be read are unknown, their destination secrets are neither written nor pruned.
+// nolint: cyclop
+func (o *syncOptions) newPlan(p *syncPair) (*syncPlanDestination, error) {
+	source, err := p.source.client.ListSecretTree(rootContext, "", p.source.rootPath, p.source.subPath, o.SkipErrors, false)
+	if err != nil {
+		return nil, fmt.Errorf("error reading \"%s\": %w", p.source, err)
+	}
+
+	// the paths of the secrets are relative to the source and the destination path, empty secrets are synced as well
+	desired := readSecrets(source)
+
+	// secrets that could not be read are skipped, an unreadable directory is listed as such a secret
+	unknown := []string{}
+
+	for _, s := range source.SecretNodes() {
+		if s.Err != nil {
+			unknown = append(unknown, relativePath(source, s))
+		}
+	}
+
+	current := map[string]map[string]interface{}{}
+	metadata := vault.SecretsMetadata{}
+
+	_, _, err = p.dest.client.GetEngineTypeVersion(rootContext, p.dest.rootPath)
+	engineExists := err == nil
+
+	if engineExists {
+		// unreadable secrets of the destination are treated as not existing and thus never pruned
+		existing, err := p.dest.client.ListSecretTree(rootContext, "", p.dest.rootPath, p.dest.subPath, true, true)
+		if err != nil {
+			return nil, fmt.Errorf("error reading destination \"%s\": %w", p.dest, err)
+		}
+
+		current = readSecrets(existing)
+		metadata = existing.SecretsMetadata()
+	}
+
+	for k := range current {
+		_, ok := desired[k]
+
+		// secrets only existing in the destination are kept, unless pruned
+		if !ok && (!o.Prune || isUnknown(unknown, k)) {
+			delete(current, k)
+		}
+	}
+
+	plan := &syncPlanDestination{
+		Source:       o.label(o.SourceAddr, p.source),
+		Namespace:    p.dest.ns,
+		RootPath:     p.dest.rootPath,
+		SubPath:      p.dest.subPath,
+		EnableEngine: !engineExists,
+		changes:      diff.Compare(current, desired),
+	}
+
+	for _, c := range plan.changes {
+		s := importPlanSecret{Path: c.Path, Data: desired[c.Path], Delete: c.Type == diff.Removed}
+
+		// the checksum covers the settings of the secret, like the one computed when applying the plan
+		if existing, ok := current[c.Path]; ok {
+			var settings *vault.SecretSettings
+			if md, ok := metadata[path.Join(p.dest.subPath, c.Path)]; ok {
+				settings = md.Settings()
+			}
+
+			if s.Checksum, err = secretChecksum(existing, settings); err != nil {
+				return nil, err
+			}
+		}
+
+		plan.Secrets = append(plan.Secrets, s)
+	}
+
+	if len(unknown) > 0 {
+		labels := make([]string, 0, len(unknown))
+		for _, u := range unknown {
+			labels = append(labels, path.Join(p.source.String(), u))
+		}
+
+		fmt.Fprintf(writer, "%d secrets could not be read, their destination is left unchanged: \"%s\"\n", len(unknown), strings.Join(labels, "\", \""))
+	}
+
+	return plan, nil
+}
+
+// readSecrets returns the data of all secrets of t that could be read keyed by their path relative to t.
+func readSecrets(t *vault.SecretTree) map[string]map[string]interface{} {
+	res := map[string]map[string]interface{}{}
+
+	for _, s := range t.SecretNodes() {
+		if s.Err != nil {
+			continue
+		}
+
+		data := s.Data
+		if data == nil {
+			data = map[string]interface{}{}
+		}
+
+		res[relativePath(t, s)] = data
+	}
+
+	return res
+}
+
+// relativePath returns the path of the secret s relative to the tree t.
+func relativePath(t, s *vault.SecretTree) string {
+	return strings.TrimPrefix(strings.TrimPrefix(s.Path, t.Path), utils.Delimiter)
+}
+
+// isUnknown reports whether p is or is below one of the unknown paths.
+func isUnknown(unknown []string, p string) bool {
+	for _, u := range unknown {
+		if u == "" || p == u || strings.HasPrefix(p, u+utils.Delimiter) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// readSyncPlan reads a plan file created using --plan-file.
+func readSyncPlan(file string) (*syncPlan, error) {
+	out, err := fs.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &syncPlan{}
+	if err := utils.DecodeJSON(out, plan); err != nil {
+		return nil, fmt.Errorf("cannot parse plan file \"%s\": %w", file, err)
+	}
+
+	for _, d := range plan.Destinations {
+		if d.RootPath == "" {
+			return nil, fmt.Errorf("invalid plan file \"%s\": no KV engine path found", file)
+		}
+	}
+
+	return plan, nil
+}
+
+// counts returns the number of secrets to be written and to be deleted.
+func (p *syncPlan) counts() (int, int) {
+	written, deleted := 0, 0
+
+	for _, d := range p.Destinations {
+		for _, s := range d.Secrets {
+			if s.Delete {
+				deleted++
+
+				continue
+			}
+
+			written++
+		}
+	}
+
+	return written, deleted
+}
+
+// verify returns an error listing all destination secrets that have been changed since the plan has been created.
+func (d *syncPlanDestination) verify(l *copyLocation) error {
+	stale := []string{}
+
+	for _, s := range d.Secrets {
+		checksum, err := readChecksum(l.client, l.rootPath, path.Join(l.subPath, s.Path))
+		if err != nil {
+			return err
+		}
+
+		if checksum != s.Checksum {
+			stale = append(stale, path.Join(l.String(), s.Path))
+		}
+	}
+
+	if len(stale) > 0 {
+		sort.Strings(stale)
+
+		return fmt.Errorf("%w: \"%s\", create a new plan using --dry-run", errStalePlan, strings.Join(stale, "\", \""))
+	}
+
+	return nil
+}
+
+// plan prints the changes of a sync plan and optionally saves it to a plan file.
+func (o *syncOptions) plan(plan *syncPlan) error {
+	written, deleted := plan.counts()
+
+	fmt.Fprintln(writer, "")
+	fmt.Fprintf(writer, "%d secrets will be written, %d secrets will be deleted\n", written, deleted)
+
+	if err := checkMaxDeletions(deleted, o.MaxDeletions); err != nil {
+		return err
+	}
+
+	if written+deleted == 0 {
+		return nil
+	}
+
+	if o.PlanFile == "" {
+		fmt.Fprintln(writer, "apply changes by omitting the --dry-run flag")
+
+		return nil
+	}
+
+	out, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// the plan file contains the secrets to be synced, thus only the owner may read it
+	if err := os.WriteFile(o.PlanFile, out, 0o600); err != nil {
+		return fmt.Errorf("error writing plan file \"%s\": %w", o.PlanFile, err)
+	}
+
+	fmt.Fprintf(writer, "saved plan to \"%s\", apply it by using --apply-plan=%s\n", o.PlanFile, o.PlanFile)
+
+	return nil
+}
+
+// applyPlan syncs the secrets of a plan file, unless the destination secrets changed since the plan has been created.
+func (o *syncOptions) applyPlan() error {
+	plan, err := readSyncPlan(o.ApplyPlan)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(writer, "reading plan from %s\n", o.ApplyPlan)
+
+	// the plan is applied to the destination it has been created for
+	o.DestAddr = plan.DestAddr
+
+	_, dest, err := o.clients()
+	if err != nil {
+		return err
+	}
+
+	for _, d := range plan.Destinations {
+		if err := d.verify(newCopyLocation(dest, d.Namespace, d.RootPath, d.SubPath)); err != nil {
+			return err
+		}
+	}
+
+	_, deleted := plan.counts()
+
+	if err := checkMaxDeletions(deleted, o.MaxDeletions); err != nil {
+		return err
+	}
+
+	return o.apply(dest, plan)
+}
+
+// apply writes and deletes the secrets of a plan.
+func (o *syncOptions) apply(dest *vault.Vault, plan *syncPlan) error {
+	synced, deleted := 0, 0
+
+	for _, d := range plan.Destinations {
+		l := newCopyLocation(dest, d.Namespace, d.RootPath, d.SubPath)
+
+		if d.EnableEngine && len(d.Secrets) > 0 {
+			// the engine might have been enabled since the plan has been created
+			if _, _, err := l.client.GetEngineTypeVersion(rootContext, l.rootPath); err != nil {
+				if err := l.client.EnableKV2Engine(rootContext, l.rootPath); err != nil {
+					return fmt.Errorf("error enabling secret engine \"%s\": %w", path.Join(l.ns, l.rootPath), err)
+				}
+			}
+		}
+
+		for _, s := range d.Secrets {
+			subPath := path.Join(l.subPath, s.Path)
+			label := path.Join(l.ns, l.rootPath, subPath)
+
+			if s.Delete {
+				if err := l.remove(subPath); err != nil {
+					return fmt.Errorf("error deleting secret \"%s\": %w", label, err)
+				}
+
+				fmt.Fprintf(writer, "deleted secret \"%s\"\n", label)
+
+				deleted++
+
+				continue
+			}
+
+			if err := writeSecretCAS(l.client, "", l.rootPath, subPath, s.Data, o.CASRetries); err != nil {
+				return fmt.Errorf("error writing secret \"%s\": %w", label, err)
+			}
+
+			fmt.Fprintf(writer, "synced secret \"%s\"\n", label)
+
+			synced++
+		}
+	}
+
+	fmt.Fprintf(writer, "successfully synced %d secrets, %d secrets deleted\n", synced, deleted)
+
+	return nil
+}
@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"path"
+
+	"github.com/FalcoSuessgott/vkv/pkg/testutils"
+	"github.com/FalcoSuessgott/vkv/pkg/vault"
+)
+
+func (s *VaultSuite) TestValidateSyncFlags() {
+	testCases := []struct {
+		name string
+		args []string
+		err  bool
+	}{
+		{
+			name: "source missing",
+			args: []string{"--dest-path=target", "--dry-run"},
+			err:  true,
+		},
+		{
+			name: "all engines and path",
+			args: []string{"-p=secret", "--all-engines", "--dry-run"},
+			err:  true,
+		},
+		{
+			name: "mapping without all engines",
+			args: []string{"-p=secret", "--dest-path=target", "--map-engine=a=b", "--dry-run"},
+			err:  true,
+		},
+		{
+			name: "invalid mapping",
+			args: []string{"--all-engines", "--map-engine=secret", "--dry-run"},
+			err:  true,
+		},
+		{
+			name: "plan file without dry run",
+			args: []string{"-p=secret", "--dest-path=target", "--plan-file=plan.json"},
+			err:  true,
+		},
+		{
+			name: "apply plan and path",
+			args: []string{"-p=secret", "--apply-plan=plan.json"},
+			err:  true,
+		},
+		{
+			name: "apply plan and prune",
+			args: []string{"--prune", "--apply-plan=plan.json"},
+			err:  true,
+		},
+		{
+			name: "same source and destination",
+			args: []string{"-p=secret", "--dry-run"},
+			err:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		cmd := NewSyncCmd()
+		cmd.SetArgs(tc.args)
+
+		err := cmd.Execute()
+
+		s.Require().Equal(tc.err, err != nil, tc.name)
+	}
+}
+
+func (s *VaultSuite) TestSyncCommand() {
+	s.Run("sync two vault servers", func() {
+		ctx := context.Background()
+
+		dc, err := testutils.StartTestContainer()
+		s.Require().NoError(err)
+
+		defer dc.Terminate() //nolint: errcheck
+
+		dest, err := vault.NewClient(dc.URI, dc.Token)
+		s.Require().NoError(err)
+
+		s.Require().NoError(vaultClient.EnableKV2Engine(ctx, "primary"))
+		s.Require().NoError(vaultClient.WriteSecrets(ctx, "", "primary", "admin", map[string]interface{}{"user": "v2"}))
+		s.Require().NoError(vaultClient.WriteSecrets(ctx, "", "primary", "same", map[string]interface{}{"key": "value"}))
+
+		s.Require().NoError(dest.EnableKV2Engine(ctx, "primary"))
+		s.Require().NoError(dest.WriteSecrets(ctx, "", "primary", "admin", map[string]interface{}{"user": "v1"}))
+		s.Require().NoError(dest.WriteSecrets(ctx, "", "primary", "same", map[string]interface{}{"key": "value"}))
+		s.Require().NoError(dest.WriteSecrets(ctx, "", "primary", "extra", map[string]interface{}{"foo": "bar"}))
+
+		// plan
+		b := bytes.NewBufferString("")
+		writer = b
+
+		planCmd := NewSyncCmd()
+		planCmd.SetArgs([]string{"-p=primary", "--dest-addr=" + dc.URI, "--prune", "--dry-run", "--show-values"})
+
+		s.Require().NoError(planCmd.Execute())
+		s.Require().Contains(b.String(), "user=v1 -> v2")
+		s.Require().Contains(b.String(), "- extra")
+		s.Require().Contains(b.String(), "1 secrets will be written, 1 secrets will be deleted")
+
+		// apply
+		b.Reset()
+
+		syncCmd := NewSyncCmd()
+		syncCmd.SetArgs([]string{"-p=primary", "--dest-addr=" + dc.URI, "--prune"})
+
+		s.Require().NoError(syncCmd.Execute())
+		s.Require().Contains(b.String(), "successfully synced 1 secrets, 1 secrets deleted")
+
+		secrets, err := dest.ListRecursive(ctx, "", "primary", "", false)
+		s.Require().NoError(err)
+		s.Require().Equal(&vault.Secrets{
+			"admin": map[string]interface{}{"user": "v2"},
+			"same":  map[string]interface{}{"key": "value"},
+		}, secrets)
+
+		// unchanged secrets are not written again
+		md, err := dest.ReadMetadata(ctx, "primary", "same")
+		s.Require().NoError(err)
+		s.Require().Equal(1, md.CurrentVersion)
+
+		// map the engine
+		b.Reset()
+
+		mapCmd := NewSyncCmd()
+		mapCmd.SetArgs([]string{"--all-engines", "--map-engine=primary=mirror", "--dest-addr=" + dc.URI, "--skip-errors"})
+
+		s.Require().NoError(mapCmd.Execute())
+
+		secret, err := dest.ReadSecrets(ctx, "", "mirror", "admin")
+		s.Require().NoError(err)
+		s.Require().Equal(map[string]interface{}{"user": "v2"}, secret)
+	})
+}
+
+func (s *VaultSuite) TestSyncPlan() {
+	ctx := context.Background()
+	plan := path.Join(s.Suite.T().TempDir(), "plan.json")
+
+	writer = io.Discard
+
+	s.Require().NoError(vaultClient.EnableKV2Engine(ctx, "syncsrc"))
+	s.Require().NoError(vaultClient.WriteSecrets(ctx, "", "syncsrc", "admin", map[string]interface{}{"user": "v2"}))
+	s.Require().NoError(vaultClient.WriteSecrets(ctx, "", "syncsrc", "deleted", map[string]interface{}{"user": "v2"}))
+	s.Require().NoError(vaultClient.DeleteSecret(ctx, "syncsrc", "deleted", nil))
+	s.Require().NoError(vaultClient.WriteSecrets(ctx, "", "syncsrc", "empty", map[string]interface{}{}))
+
+	s.Require().NoError(vaultClient.EnableKV2Engine(ctx, "syncdst"))
+	s.Require().NoError(vaultClient.WriteSecrets(ctx, "", "syncdst", "admin", map[string]interface{}{"user": "v1"}))
+	s.Require().NoError(vaultClient.WriteSecrets(ctx, "", "syncdst", "deleted", map[string]interface{}{"user": "v1"}))
+	s.Require().NoError(vaultClient.WriteSecrets(ctx, "", "syncdst", "extra", map[string]interface{}{"foo": "bar"}))
+	s.Require().NoError(vaultClient.WriteSecrets(ctx, "", "syncdst", "extra2", map[string]interface{}{"foo": "bar"}))
+
+	// 1. too many deletions
+	cmd := NewSyncCmd()
+	cmd.SetArgs([]string{"-p=syncsrc", "--dest-path=syncdst", "--prune", "--skip-errors", "--max-deletions=1", "--dry-run"})
+	s.Require().ErrorIs(cmd.Execute(), errTooManyDeletions)
+
+	// 2. create the plan, the unreadable source secret is not pruned, the empty one is written
+	b := bytes.NewBufferString("")
+	writer = b
+
+	cmd = NewSyncCmd()
+	cmd.SetArgs([]string{"-p=syncsrc", "--dest-path=syncdst", "--prune", "--skip-errors", "--dry-run", "--plan-file=" + plan})
+	s.Require().NoError(cmd.Execute())
+	s.Require().Contains(b.String(), "1 secrets could not be read, their destination is left unchanged: \"syncsrc/deleted\"")
+	s.Require().Contains(b.String(), "2 secrets will be written, 2 secrets will be deleted")
+	s.Require().FileExists(plan)
+
+	// 3. apply the plan
+	cmd = NewSyncCmd()
+	cmd.SetArgs([]string{"--apply-plan=" + plan})
+	s.Require().NoError(cmd.Execute())
+
+	secrets, err := vaultClient.ListSecretTree(ctx, "", "syncdst", "", false, false)
+	s.Require().NoError(err)
+	s.Require().Equal(map[string]map[string]interface{}{
+		"admin":   {"user": "v2"},
+		"deleted": {"user": "v1"},
+	}, secrets.Flatten())
+
+	empty, err := vaultClient.ReadSecrets(ctx, "", "syncdst", "empty")
+	s.Require().NoError(err)
+	s.Require().Empty(empty)
+
+	// 4. the plan refuses to be applied once the destination changed
+	s.Require().NoError(vaultClient.WriteSecrets(ctx, "", "syncdst", "admin", map[string]interface{}{"user": "v1"}))
+
+	writer = io.Discard
+
+	cmd = NewSyncCmd()
+	cmd.SetArgs([]string{"-p=syncsrc", "--dest-path=syncdst", "--dry-run", "--plan-file=" + plan})
+	s.Require().NoError(cmd.Execute())
+
+	s.Require().NoError(vaultClient.WriteSecrets(ctx, "", "syncdst", "admin", map[string]interface{}{"user": "changed"}))
+
+	cmd = NewSyncCmd()
+	cmd.SetArgs([]string{"--apply-plan=" + plan})
+	s.Require().ErrorIs(cmd.Execute(), errStalePlan)
+}
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"sort"
+
+	prt "github.com/FalcoSuessgott/vkv/pkg/printer/secret"
+	"github.com/FalcoSuessgott/vkv/pkg/utils"
+	"github.com/FalcoSuessgott/vkv/pkg/vault"
+	"github.com/spf13/cobra"
+)
+
+// undeleteOptions holds all available commandline options.
+type undeleteOptions struct {
+	Path       string `env:"PATH"`
+	EnginePath string `env:"ENGINE_PATH"`
+
+	DryRun bool `env:"DRY_RUN"`
+
+	SkipErrors  bool `env:"SKIP_ERRORS" envDefault:"false"`
+	Concurrency int
+}
+
+// NewUndeleteCmd undelete subcommand.
+//
+//nolint:lll
+func NewUndeleteCmd() *cobra.Command {
+	o := &undeleteOptions{}
+
+	if err := utils.ParseEnvs(envVarUndeletePrefix, o); err != nil {
+		log.Fatal(err)
+	}
+
+	o.Concurrency = vault.DefaultConcurrency()
+
+	cmd := &cobra.Command{
+		Use:           "undelete",
+		Short:         "recursively recover the soft deleted latest versions of KVv2 secrets",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PreRunE:       o.validateFlags,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			vaultClient.SetConcurrency(o.Concurrency)
+
+			rootPath, subPath := utils.HandleEnginePath(o.EnginePath, o.Path)
+
+			deleted, err := vaultClient.ListRecursiveDeleted(rootContext, rootPath, subPath, o.SkipErrors)
+			if err != nil {
+				return err
+			}
+
+			if len(deleted) == 0 {
+				fmt.Fprintf(writer, "no deleted secrets found in \"%s\"\n", path.Join(rootPath, subPath))
+
+				return nil
+			}
+
+			recoverable, destroyed := splitRecoverable(deleted)
+
+			// the deleted secrets are always shown before recovering them
+			printer = prt.NewSecretPrinter(
+				prt.ToFormat(prt.Base),
+				prt.WithVaultClient(vaultClient),
+				prt.WithWriter(writer),
+				prt.WithEnginePath(utils.NormalizePath(rootPath)),
+				prt.WithContext(rootContext),
+			)
+
+			if err := printer.Out(deleted); err != nil {
+				return err
+			}
+
+			fmt.Fprintln(writer, "")
+			fmt.Fprintf(writer, "%d secrets can be recovered, %d secrets have been destroyed permanently\n", len(recoverable), len(destroyed))
+
+			if o.DryRun {
+				if len(recoverable) > 0 {
+					fmt.Fprintln(writer, "")
+					fmt.Fprintln(writer, "recover the secrets by omitting the --dry-run flag")
+				}
+
+				return nil
+			}
+
+			fmt.Fprintln(writer, "")
+
+			undeleted := 0
+
+			for _, p := range recoverable {
+				version := deleted[p].Versions[0].Version
+
+				if err := vaultClient.UndeleteSecret(rootContext, rootPath, p, []int{version}); err != nil {
+					if o.SkipErrors {
+						continue
+					}
+
+					return fmt.Errorf("error undeleting secret \"%s\": %w", path.Join(rootPath, p), err)
+				}
+
+				fmt.Fprintf(writer, "undeleted secret \"%s\" (version %d)\n", path.Join(rootPath, p), version)
+
+				undeleted++
+			}
+
+			fmt.Fprintf(writer, "successfully undeleted %d secrets\n", undeleted)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().SortFlags = false
+
+	// Input
+	cmd.Flags().StringVarP(&o.Path, "path", "p", o.Path, "KV Engine path (env: VKV_UNDELETE_PATH)")
+	cmd.Flags().StringVarP(&o.EnginePath, "engine-path", "e", o.EnginePath, "engine path in case your KV-engine contains special characters such as \"/\", the path (-p) flag will then be appended if specified (\"<engine-path>/<path>\") (env: VKV_UNDELETE_ENGINE_PATH)")
+	cmd.Flags().BoolVar(&o.SkipErrors, "skip-errors", o.SkipErrors, "don't exit on errors (permission denied, deleted secrets) (env: VKV_UNDELETE_SKIP_ERRORS)")
+	cmd.Flags().IntVar(&o.Concurrency, "concurrency", o.Concurrency, "maximum number of concurrent requests sent to Vault while reading secrets (env: VKV_CONCURRENCY)")
+
+	// Options
+	cmd.Flags().BoolVarP(&o.DryRun, "dry-run", "d", o.DryRun, "only print the secrets that would be recovered (env: VKV_UNDELETE_DRY_RUN)")
+
+	return cmd
+}
+
+func (o *undeleteOptions) validateFlags(cmd *cobra.Command, args []string) error {
+	if o.EnginePath == "" && o.Path == "" {
+		return errors.New("no KV-paths given. Either --engine-path/-e or --path/-p needs to be specified")
+	}
+
+	return nil
+}
+
+// splitRecoverable returns the sorted paths of the secrets whose current version can be undeleted
+// and of the secrets whose current version has been destroyed.
+func splitRecoverable(deleted vault.VersionedSecrets) (recoverable, destroyed []string) {
+	for p, vs := range deleted {
+		if vs.Versions[0].Destroyed {
+			destroyed = append(destroyed, p)
+
+			continue
+		}
+
+		recoverable = append(recoverable, p)
+	}
+
+	sort.Strings(recoverable)
+	sort.Strings(destroyed)
+
+	return recoverable, destroyed
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+)
+
+func (s *VaultSuite) TestValidateUndeleteFlags() {
+	cmd := NewUndeleteCmd()
+	cmd.SetArgs([]string{"--dry-run"})
+
+	s.Require().Error(cmd.Execute(), "path missing")
+}
+
+func (s *VaultSuite) TestUndeleteCommand() {
+	s.Run("undelete", func() {
+		ctx := context.Background()
+
+		s.Require().NoError(vaultClient.EnableKV2Engine(ctx, "undelete"))
+
+		s.Require().NoError(vaultClient.WriteSecrets(ctx, "", "undelete", "admin", map[string]interface{}{"user": "v1"}))
+		s.Require().NoError(vaultClient.WriteSecrets(ctx, "", "undelete", "sub/demo", map[string]interface{}{"foo": "bar"}))
+		s.Require().NoError(vaultClient.WriteSecrets(ctx, "", "undelete", "sub/destroyed", map[string]interface{}{"foo": "bar"}))
+
+		s.Require().NoError(vaultClient.DeleteSecret(ctx, "undelete", "sub/demo", nil))
+		s.Require().NoError(vaultClient.DestroySecret(ctx, "undelete", "sub/destroyed", []int{1}))
+
+		// dry run only prints the deleted secrets
+		b := bytes.NewBufferString("")
+		writer = b
+
+		dryRunCmd := NewUndeleteCmd()
+		dryRunCmd.SetArgs([]string{"-p=undelete", "--dry-run"})
+
+		s.Require().NoError(dryRunCmd.Execute())
+		s.Require().Contains(b.String(), "1 secrets can be recovered, 1 secrets have been destroyed permanently")
+
+		_, err := vaultClient.ReadSecrets(ctx, "", "undelete", "sub/demo")
+		s.Require().Error(err)
+
+		// undelete recovers the soft deleted version
+		b.Reset()
+
+		undeleteCmd := NewUndeleteCmd()
+		undeleteCmd.SetArgs([]string{"-p=undelete"})
+
+		s.Require().NoError(undeleteCmd.Execute())
+		s.Require().Contains(b.String(), "undeleted secret \"undelete/sub/demo\" (version 1)")
+		s.Require().NotContains(b.String(), "undeleted secret \"undelete/sub/destroyed\"")
+
+		secret, err := vaultClient.ReadSecrets(ctx, "", "undelete", "sub/demo")
+		s.Require().NoError(err)
+		s.Require().Equal(map[string]interface{}{"foo": "bar"}, secret)
+	})
+}
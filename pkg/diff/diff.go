@@ -0,0 +1,121 @@
+package diff
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/FalcoSuessgott/vkv/pkg/utils"
+)
+
+// ChangeType describes how a path or key differs between two KV trees.
+type ChangeType string
+
+const (
+	// Added means the path or key only exists in the target tree.
+	Added ChangeType = "added"
+
+	// Removed means the path or key only exists in the source tree.
+	Removed ChangeType = "removed"
+
+	// Changed means the path or key exists in both trees but differs.
+	Changed ChangeType = "changed"
+)
+
+// KeyChange represents a single differing key of a secret.
+type KeyChange struct {
+	Key  string      `json:"key"`
+	Type ChangeType  `json:"type"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// PathChange represents a differing secret path and its differing keys.
+type PathChange struct {
+	Path string      `json:"path"`
+	Type ChangeType  `json:"type"`
+	Keys []KeyChange `json:"keys,omitempty"`
+}
+
+// Result holds all differences between a source and a target KV tree.
+type Result struct {
+	Source  string       `json:"source"`
+	Target  string       `json:"target"`
+	Changes []PathChange `json:"changes"`
+}
+
+// Compare returns the differences between two flattened KV trees, sorted by path and key.
+func Compare(source, target map[string]map[string]interface{}) []PathChange {
+	paths := make([]string, 0, len(source)+len(target))
+
+	for p := range source {
+		paths = append(paths, p)
+	}
+
+	for p := range target {
+		if _, ok := source[p]; !ok {
+			paths = append(paths, p)
+		}
+	}
+
+	sort.Sort(utils.Keys(paths))
+
+	changes := []PathChange{}
+
+	for _, p := range paths {
+		src, inSource := source[p]
+		dst, inTarget := target[p]
+
+		var c PathChange
+
+		switch {
+		case !inTarget:
+			c = PathChange{Path: p, Type: Removed, Keys: compareKeys(src, nil)}
+		case !inSource:
+			c = PathChange{Path: p, Type: Added, Keys: compareKeys(nil, dst)}
+		default:
+			c = PathChange{Path: p, Type: Changed, Keys: compareKeys(src, dst)}
+		}
+
+		if len(c.Keys) == 0 && c.Type == Changed {
+			continue
+		}
+
+		changes = append(changes, c)
+	}
+
+	return changes
+}
+
+func compareKeys(source, target map[string]interface{}) []KeyChange {
+	keys := make([]string, 0, len(source)+len(target))
+
+	for k := range source {
+		keys = append(keys, k)
+	}
+
+	for k := range target {
+		if _, ok := source[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Strings(keys)
+
+	changes := []KeyChange{}
+
+	for _, k := range keys {
+		oldValue, inSource := source[k]
+		newValue, inTarget := target[k]
+
+		switch {
+		case !inTarget:
+			changes = append(changes, KeyChange{Key: k, Type: Removed, Old: oldValue})
+		case !inSource:
+			changes = append(changes, KeyChange{Key: k, Type: Added, New: newValue})
+		case !reflect.DeepEqual(oldValue, newValue):
+			changes = append(changes, KeyChange{Key: k, Type: Changed, Old: oldValue, New: newValue})
+		}
+	}
+
+	return changes
+}
@@ -0,0 +1,78 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompare(t *testing.T) {
+	testCases := []struct {
+		name     string
+		source   map[string]map[string]interface{}
+		target   map[string]map[string]interface{}
+		expected []PathChange
+	}{
+		{
+			name: "equal",
+			source: map[string]map[string]interface{}{
+				"admin": {"user": "password"},
+			},
+			target: map[string]map[string]interface{}{
+				"admin": {"user": "password"},
+			},
+			expected: []PathChange{},
+		},
+		{
+			name: "added and removed paths",
+			source: map[string]map[string]interface{}{
+				"a": {"user": "password"},
+			},
+			target: map[string]map[string]interface{}{
+				"b": {"key": false},
+			},
+			expected: []PathChange{
+				{Path: "a", Type: Removed, Keys: []KeyChange{{Key: "user", Type: Removed, Old: "password"}}},
+				{Path: "b", Type: Added, Keys: []KeyChange{{Key: "key", Type: Added, New: false}}},
+			},
+		},
+		{
+			name: "changed keys",
+			source: map[string]map[string]interface{}{
+				"admin": {"user": "password", "old": "value", "same": 1},
+			},
+			target: map[string]map[string]interface{}{
+				"admin": {"user": "secret", "new": "value", "same": 1},
+			},
+			expected: []PathChange{
+				{
+					Path: "admin",
+					Type: Changed,
+					Keys: []KeyChange{
+						{Key: "new", Type: Added, New: "value"},
+						{Key: "old", Type: Removed, Old: "value"},
+						{Key: "user", Type: Changed, Old: "password", New: "secret"},
+					},
+				},
+			},
+		},
+		{
+			name: "sorted by path",
+			source: map[string]map[string]interface{}{
+				"sub/b": {"k": "v"},
+				"sub/a": {"k": "v"},
+			},
+			target: map[string]map[string]interface{}{},
+			expected: []PathChange{
+				{Path: "sub/a", Type: Removed, Keys: []KeyChange{{Key: "k", Type: Removed, Old: "v"}}},
+				{Path: "sub/b", Type: Removed, Keys: []KeyChange{{Key: "k", Type: Removed, Old: "v"}}},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Compare(tc.source, tc.target), tc.name)
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package secret
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/FalcoSuessgott/vkv/pkg/utils"
+	"github.com/FalcoSuessgott/vkv/pkg/vault"
+	"github.com/olekukonko/tablewriter"
+	"github.com/xlab/treeprint"
+)
+
+// rootNamespace is the name displayed for the root namespace.
+const rootNamespace = "root"
+
+// printAllEngines prints the secrets of multiple engines as a single document: namespace -> engine -> path -> keys.
+// nolint: cyclop
+func (p *Printer) printAllEngines(secrets vault.AllEngineSecrets) error {
+	switch p.format {
+	case JSON:
+		return p.printJSON(p.allEnginesMap(secrets))
+	case YAML:
+		return p.printYAML(p.allEnginesMap(secrets))
+	case Base:
+		return p.printBaseAllEngines(secrets)
+	case Markdown:
+		return p.printMarkdownAllEngines(secrets)
+	case Export:
+		for _, es := range secrets {
+			e := p.enginePrinter(es)
+
+			if err := e.printExport(e.printedSecrets(engineSecretTree(es), e.enginePath)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case Template:
+		// the secrets are passed to the template with the namespace prepended to their paths
+		printed := []printedSecret{}
+
+		for _, es := range secrets {
+			e := p.enginePrinter(es)
+
+			printed = append(printed, e.printedSecrets(engineSecretTree(es), path.Join(es.Namespace, e.enginePath))...)
+		}
+
+		return p.printTemplate(printed)
+	case Policy:
+		capMap := make(map[string]*vault.Capability)
+
+		for _, es := range secrets {
+			e := p.enginePrinter(es)
+
+			caps, err := e.capabilities(e.printedSecrets(engineSecretTree(es), e.enginePath))
+			if err != nil {
+				return err
+			}
+
+			for k, c := range caps {
+				capMap[path.Join(es.Namespace, k)] = c
+			}
+		}
+
+		return p.printCapabilities(capMap)
+	default:
+		return ErrInvalidFormat
+	}
+}
+
+// allEnginesMap returns the secrets with flat, full-path keys per namespace and engine.
+func (p *Printer) allEnginesMap(secrets vault.AllEngineSecrets) map[string]interface{} {
+	m := make(map[string]interface{})
+
+	for _, es := range secrets {
+		e := p.enginePrinter(es)
+
+		flat := make(map[string]interface{})
+		for k, v := range engineSecretTree(es).Flatten() {
+			flat[k] = v
+		}
+
+		ns := namespaceName(es.Namespace)
+		if _, ok := m[ns]; !ok {
+			m[ns] = make(map[string]interface{})
+		}
+
+		//nolint: forcetypeassert
+		m[ns].(map[string]interface{})[es.Engine] = e.transform(flat)
+	}
+
+	return m
+}
+
+func (p *Printer) printBaseAllEngines(secrets vault.AllEngineSecrets) error {
+	trees := []treeprint.Tree{}
+
+	var tree treeprint.Tree
+
+	for i, es := range secrets {
+		if i == 0 || es.Namespace != secrets[i-1].Namespace {
+			tree = treeprint.NewWithRoot(fmt.Sprintf("%s %s", boldStyle(namespaceName(es.Namespace)), annotationStyle("[namespace]")))
+			trees = append(trees, tree)
+		}
+
+		e := p.enginePrinter(es)
+
+		tree.AddBranch(e.secretTree(engineSecretTree(es), es.Metadata))
+	}
+
+	for _, t := range trees {
+		fmt.Fprintln(p.writer, strings.TrimSpace(t.String()))
+	}
+
+	return nil
+}
+
+func (p *Printer) printMarkdownAllEngines(secrets vault.AllEngineSecrets) error {
+	headers := []string{}
+	data := [][]string{}
+
+	for _, es := range secrets {
+		e := p.enginePrinter(es)
+
+		h, rows := e.buildMarkdownTable(e.printedSecrets(engineSecretTree(es), e.enginePath), es.Metadata)
+		if len(h) > 0 {
+			headers = append([]string{"namespace"}, h...)
+		}
+
+		for _, r := range rows {
+			data = append(data, append([]string{namespaceName(es.Namespace)}, r...))
+		}
+	}
+
+	table := tablewriter.NewWriter(p.writer)
+	table.SetHeader(headers)
+	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+	table.SetCenterSeparator("|")
+	table.AppendBulk(data)
+	table.SetAutoMergeCellsByColumnIndex([]int{0, 1}) // merge namespaces and paths columns
+	table.Render()
+
+	return nil
+}
+
+// enginePrinter returns a copy of the printer for displaying the secrets of a single engine.
+func (p *Printer) enginePrinter(es *vault.EngineSecrets) *Printer {
+	e := *p
+	e.enginePath = utils.NormalizePath(es.Engine)
+
+	if p.vaultClient != nil {
+		e.vaultClient = p.vaultClient.WithNamespace(es.Namespace)
+	}
+
+	return &e
+}
+
+// engineSecretTree returns the secrets of the engine as tree.
+func engineSecretTree(es *vault.EngineSecrets) *vault.SecretTree {
+	if es.Secrets == nil {
+		return vault.NewSecretDirectory("")
+	}
+
+	return es.Secrets
+}
+
+// namespaceName returns the displayed name of a namespace.
+func namespaceName(ns string) string {
+	if ns == "" {
+		return rootNamespace
+	}
+
+	return ns
+}
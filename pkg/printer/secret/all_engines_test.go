@@ -0,0 +1,156 @@
+package secret
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/FalcoSuessgott/vkv/pkg/vault"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintAllEngines(t *testing.T) {
+	secrets := vault.AllEngineSecrets{
+		{
+			Namespace: "",
+			Engine:    "secret",
+			Secrets: vault.SecretTreeFromMap("", map[string]interface{}{
+				"admin": map[string]interface{}{"user": "password"},
+			}),
+		},
+		{
+			Namespace: "team-a",
+			Engine:    "kv",
+			Secrets: vault.SecretTreeFromMap("", map[string]interface{}{
+				"db": map[string]interface{}{"pass": "secret"},
+			}),
+		},
+		{
+			Namespace: "team-a",
+			Engine:    "other",
+			Secrets: vault.SecretTreeFromMap("", map[string]interface{}{
+				"api": map[string]interface{}{"token": "abc"},
+			}),
+		},
+	}
+
+	testCases := []struct {
+		name   string
+		opts   []Option
+		output string
+	}{
+		{
+			name: "json",
+			opts: []Option{
+				ToFormat(JSON),
+				ShowValues(true),
+			},
+			output: `{
+  "root": {
+    "secret": {
+      "admin": {
+        "user": "password"
+      }
+    }
+  },
+  "team-a": {
+    "kv": {
+      "db": {
+        "pass": "secret"
+      }
+    },
+    "other": {
+      "api": {
+        "token": "abc"
+      }
+    }
+  }
+}
+`,
+		},
+		{
+			name: "yaml",
+			opts: []Option{
+				ToFormat(YAML),
+				ShowValues(true),
+			},
+			output: `root:
+  secret:
+    admin:
+      user: password
+team-a:
+  kv:
+    db:
+      pass: secret
+  other:
+    api:
+      token: abc
+`,
+		},
+		{
+			name: "base",
+			opts: []Option{
+				ToFormat(Base),
+			},
+			output: `root [namespace]
+└── secret/
+    └── admin
+        └── user=********
+team-a [namespace]
+├── kv/
+│   └── db
+│       └── pass=******
+│       
+│   
+└── other/
+    └── api
+        └── token=***
+`,
+		},
+		{
+			name: "markdown",
+			opts: []Option{
+				ToFormat(Markdown),
+			},
+			output: `| NAMESPACE |     PATH     |  KEY  |  VALUE   |
+|-----------|--------------|-------|----------|
+| root      | secret/admin | user  | ******** |
+| team-a    | kv/db        | pass  | ******   |
+|           | other/api    | token | ***      |
+`,
+		},
+		{
+			name: "export",
+			opts: []Option{
+				ToFormat(Export),
+				ShowValues(true),
+			},
+			output: `export user='password'
+export pass='secret'
+export token='abc'
+`,
+		},
+		{
+			name: "template",
+			opts: []Option{
+				ToFormat(Template),
+				ShowValues(true),
+				WithTemplate(`{{ range $path, $data := . }}{{ $path }}
+{{ end }}`, ""),
+			},
+			output: `secret/admin
+team-a/kv/db
+team-a/other/api
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		var b bytes.Buffer
+
+		p := NewSecretPrinter(append(tc.opts, WithWriter(&b))...)
+
+		require.NoError(t, p.Out(secrets), tc.name)
+		assert.Equal(t, tc.output, b.String(), tc.name)
+	}
+}
@@ -38,7 +38,13 @@ func (p *Printer) printBaseAllVersions(vs vault.VersionedSecrets) error {
 
 			branch, ok := branches[prefix]
 			if !ok {
-				branch = parent.AddBranch(dir)
+				// a directory sharing its name with a secret keeps its delimiter, so that both can be told apart
+				name := dir
+				if _, ok := vs[prefix]; ok {
+					name += utils.Delimiter
+				}
+
+				branch = parent.AddBranch(name)
 				branches[prefix] = branch
 			}
 
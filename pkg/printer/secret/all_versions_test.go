@@ -117,6 +117,32 @@ func TestPrintBaseAllVersions(t *testing.T) {
     └── demo
         └── [Version 1 created 6 minutes ago]
             └── user=admin
+`,
+		},
+		{
+			name:     "secret and directory with the same name",
+			rootPath: "secret",
+			vs: vault.VersionedSecrets{
+				"app": {
+					Versions: []*vault.SecretVersion{
+						{Version: 1, CreatedTime: min6, Data: map[string]interface{}{"user": "password"}},
+					},
+				},
+				"app/db": {
+					Versions: []*vault.SecretVersion{
+						{Version: 1, CreatedTime: min6, Data: map[string]interface{}{"user": "admin"}},
+					},
+				},
+			},
+			opts: []Option{ShowValues(true)},
+			output: `secret/
+├── app
+│   └── [Version 1 created 6 minutes ago]
+│       └── user=password
+└── app/
+    └── db
+        └── [Version 1 created 6 minutes ago]
+            └── user=admin
 `,
 		},
 	}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/FalcoSuessgott/vkv/pkg/utils"
+	"github.com/FalcoSuessgott/vkv/pkg/vault"
 	"github.com/savioxavier/termlink"
 	"github.com/xlab/treeprint"
 )
@@ -15,58 +16,78 @@ import (
 func (p *Printer) printBase(secrets map[string]interface{}) error {
 	var tree treeprint.Tree
 
-	m := make(map[string]interface{})
-
 	for _, k := range utils.SortMapKeys(secrets) {
-		display := p.enginePath
+		m, _ := secrets[k].(map[string]interface{})
+		tree = p.engineTree(m)
+	}
 
-		if p.withHyperLinks {
-			addr := fmt.Sprintf("%s/ui/vault/secrets/%s/kv", p.vaultClient.Client.Address(), p.enginePath)
+	fmt.Fprintln(p.writer, strings.TrimSpace(tree.String()))
 
-			display = termlink.Link(p.enginePath, addr, false)
-		}
+	return nil
+}
 
-		baseName := boldStyle(display)
+// engineTree returns the tree of the secrets m of the printers engine.
+func (p *Printer) engineTree(m map[string]interface{}) treeprint.Tree {
+	tree := p.engineRoot()
 
-		if p.vaultClient != nil {
-			// append description
-			desc, err := p.vaultClient.GetEngineDescription(p.ctx, p.enginePath)
-			if err == nil && desc != "" {
-				baseName = fmt.Sprintf("%s %s", baseName, annotationStyle(fmt.Sprintf("[desc=%s]", desc)))
-			}
+	p.addBranches(tree, p.enginePath, "", m)
 
-			// append type + version
-			engineType, version, err := p.vaultClient.GetEngineTypeVersion(p.ctx, p.enginePath)
-			if err == nil {
-				baseName = fmt.Sprintf("%s %s", baseName, annotationStyle(fmt.Sprintf("[type=%s]", engineType+version)))
-			}
-		}
+	return tree
+}
+
+// engineRoot returns an empty tree named after the printers engine.
+func (p *Printer) engineRoot() treeprint.Tree {
+	display := p.enginePath
 
-		tree = treeprint.NewWithRoot(baseName)
+	if p.withHyperLinks {
+		addr := fmt.Sprintf("%s/ui/vault/secrets/%s/kv", p.vaultClient.Client.Address(), p.enginePath)
 
-		m = utils.ToMapStringInterface(secrets[k])
+		display = termlink.Link(p.enginePath, addr, false)
 	}
 
-	for _, i := range utils.SortMapKeys(m) {
-		//nolint: forcetypeassert
-		tree.AddBranch(p.printTree(p.enginePath, i, m[i].(map[string]interface{})))
+	baseName := boldStyle(display)
+
+	if p.vaultClient != nil {
+		// append description
+		desc, err := p.vaultClient.GetEngineDescription(p.ctx, p.enginePath)
+		if err == nil && desc != "" {
+			baseName = fmt.Sprintf("%s %s", baseName, annotationStyle(fmt.Sprintf("[desc=%s]", desc)))
+		}
+
+		// append type + version
+		engineType, version, err := p.vaultClient.GetEngineTypeVersion(p.ctx, p.enginePath)
+		if err == nil {
+			baseName = fmt.Sprintf("%s %s", baseName, annotationStyle(fmt.Sprintf("[type=%s]", engineType+version)))
+		}
 	}
 
-	fmt.Fprintln(p.writer, strings.TrimSpace(tree.String()))
+	return treeprint.NewWithRoot(baseName)
+}
 
-	return nil
+// addBranches adds the directories and secrets of the directory subPath to the tree.
+func (p *Printer) addBranches(tree treeprint.Tree, rootPath, subPath string, m map[string]interface{}) {
+	for _, i := range utils.SortMapKeys(m) {
+		data, ok := m[i].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		branch := p.printTree(rootPath, subPath+i, data)
+
+		// a directory sharing its name with a secret keeps its delimiter, so that both can be told apart
+		if _, ok := m[strings.TrimSuffix(i, utils.Delimiter)]; ok && strings.HasSuffix(i, utils.Delimiter) {
+			branch.SetValue(boldStyle(path.Base(subPath+i) + utils.Delimiter))
+		}
+
+		tree.AddBranch(branch)
+	}
 }
 
 func (p *Printer) printTree(rootPath, subPath string, m map[string]interface{}) treeprint.Tree {
-	tree := treeprint.NewWithRoot(p.buildTreeName(rootPath, subPath))
+	tree := treeprint.NewWithRoot(p.buildTreeName(rootPath, subPath, p.metadata))
 
-	//nolint: nestif
 	if strings.HasSuffix(subPath, utils.Delimiter) {
-		for _, i := range utils.SortMapKeys(m) {
-			if data, ok := m[i].(map[string]interface{}); ok {
-				tree.AddBranch(p.printTree(rootPath, subPath+i, data))
-			}
-		}
+		p.addBranches(tree, rootPath, subPath, m)
 	} else {
 		for _, k := range utils.SortMapKeys(m) {
 			if p.onlyKeys {
@@ -83,7 +104,7 @@ func (p *Printer) printTree(rootPath, subPath string, m map[string]interface{})
 }
 
 // nolint: cyclop
-func (p *Printer) buildTreeName(rootPath, subPath string) string {
+func (p *Printer) buildTreeName(rootPath, subPath string, metadata vault.SecretsMetadata) string {
 	name := strings.TrimSuffix(subPath, utils.Delimiter)
 
 	subPathParts := strings.Split(strings.TrimSuffix(subPath, utils.Delimiter), utils.Delimiter)
@@ -105,12 +126,20 @@ func (p *Printer) buildTreeName(rootPath, subPath string) string {
 	// path elements are shown in bold
 	name = boldStyle(name)
 
+	// directories have no versions or metadata, even if a secret with the same name exists
+	if (!p.showVersion && !p.showMetadata) || strings.HasSuffix(subPath, utils.Delimiter) {
+		return name
+	}
+
+	md, ok := p.secretMetadata(metadata, rootPath, subPath)
+	if !ok {
+		return name
+	}
+
 	if p.showVersion {
-		if v, err := p.vaultClient.ReadSecretVersion(p.ctx, rootPath, subPath); err == nil {
-			name = fmt.Sprintf("%s %s", name, versionStyle(fmt.Sprintf("[v=%v]", v)))
-		}
+		name = fmt.Sprintf("%s %s", name, versionStyle(fmt.Sprintf("[v=%d]", md.CurrentVersion)))
 
-		if t, err := p.vaultClient.ReadCurrentVersionCreatedTime(p.ctx, rootPath, subPath); err == nil && !t.IsZero() {
+		if t := md.CurrentVersionCreatedTime(); !t.IsZero() {
 			now := p.now
 			if now.IsZero() {
 				now = time.Now()
@@ -118,22 +147,15 @@ func (p *Printer) buildTreeName(rootPath, subPath string) string {
 
 			name = fmt.Sprintf("%s %s", name, versionStyle(fmt.Sprintf("(created %s)", humanizeTimeAgo(t, now))))
 		}
-	}
 
-	if p.showMetadata {
-		if v, err := p.vaultClient.ReadSecretMetadata(p.ctx, rootPath, subPath); err == nil {
-			md := ""
-			metadata, ok := v.(map[string]interface{})
-
-			if ok {
-				for k, v := range metadata {
-					md = fmt.Sprintf("%s %s=%v", md, k, v)
-				}
-
-				name = fmt.Sprintf("%s %s", name, annotationStyle(fmt.Sprintf("[%v]", strings.TrimPrefix(md, " "))))
-			}
+		if p.deletedAsOf(md) {
+			name = fmt.Sprintf("%s %s", name, versionStyle("[deleted]"))
 		}
 	}
 
+	if p.showMetadata && md.CustomMetadata != nil {
+		name = fmt.Sprintf("%s %s", name, annotationStyle(fmt.Sprintf("[%v]", formatCustomMetadata(md.CustomMetadata))))
+	}
+
 	return name
 }
@@ -3,8 +3,10 @@ package secret
 import (
 	"bytes"
 	"testing"
+	"time"
 
 	"github.com/FalcoSuessgott/vkv/pkg/utils"
+	"github.com/FalcoSuessgott/vkv/pkg/vault"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -109,6 +111,94 @@ func TestPrintBase(t *testing.T) {
 └── secret
     ├── key=value
     └── user=value
+`,
+		},
+		{
+			name:     "test: version and metadata",
+			rootPath: "root",
+			s: map[string]interface{}{
+				"sub/": map[string]interface{}{
+					"demo": map[string]interface{}{
+						"user": "password",
+					},
+				},
+			},
+			opts: []Option{
+				ToFormat(Base),
+				ShowValues(true),
+				ShowVersion(true),
+				ShowMetadata(true),
+				WithSecretsMetadata(vault.SecretsMetadata{
+					"sub/demo": {
+						CurrentVersion: 2,
+						CustomMetadata: map[string]interface{}{"owner": "team-a", "env": "prod"},
+						Versions: []*vault.SecretVersion{
+							{Version: 2, CreatedTime: time.Now().Add(-2 * time.Hour)},
+							{Version: 1, CreatedTime: time.Now().Add(-48 * time.Hour)},
+						},
+					},
+				}),
+			},
+			output: `root/
+└── sub
+    └── demo [v=2] (created 2 hours ago) [env=prod owner=team-a]
+        └── user=password
+`,
+		},
+		{
+			name:     "test: as of deleted version",
+			rootPath: "root",
+			s: map[string]interface{}{
+				"demo": map[string]interface{}{},
+			},
+			opts: []Option{
+				ToFormat(Base),
+				ShowVersion(true),
+				AsOf(time.Now().Add(-time.Hour)),
+				WithSecretsMetadata(vault.SecretsMetadata{
+					"demo": {
+						CurrentVersion: 1,
+						Versions: []*vault.SecretVersion{
+							{Version: 1, CreatedTime: time.Now().Add(-48 * time.Hour), DeletionTime: ptr(time.Now().Add(-3 * time.Hour))},
+						},
+					},
+				}),
+			},
+			output: `root/
+└── demo [v=1] (created 2 days ago) [deleted]
+`,
+		},
+		{
+			name:     "test: secret and directory with the same name",
+			rootPath: "root",
+			s: map[string]interface{}{
+				"app": map[string]interface{}{
+					"user": "password",
+				},
+				"app/": map[string]interface{}{
+					"db": map[string]interface{}{
+						"user": "admin",
+					},
+				},
+			},
+			opts: []Option{
+				ToFormat(Base),
+				ShowValues(true),
+				ShowVersion(true),
+				WithSecretsMetadata(vault.SecretsMetadata{
+					"app": {
+						CurrentVersion: 3,
+						Versions:       []*vault.SecretVersion{{Version: 3}},
+					},
+				}),
+			},
+			output: `root/
+├── app [v=3]
+│   └── user=password
+│   
+└── app/
+    └── db
+        └── user=admin
 `,
 		},
 	}
@@ -130,3 +220,7 @@ func TestPrintBase(t *testing.T) {
 		assert.Equal(t, tc.output, b.String(), tc.name)
 	}
 }
+
+func ptr[T any](v T) *T {
+	return &v
+}
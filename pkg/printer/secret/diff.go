@@ -0,0 +1,161 @@
+package secret
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/FalcoSuessgott/vkv/pkg/diff"
+	"github.com/FalcoSuessgott/vkv/pkg/utils"
+	"github.com/olekukonko/tablewriter"
+	"github.com/xlab/treeprint"
+)
+
+// diffSymbols maps a change type to the prefix used in the base and markdown output.
+var diffSymbols = map[diff.ChangeType]string{
+	diff.Added:   "+",
+	diff.Removed: "-",
+	diff.Changed: "~",
+}
+
+// printDiff renders the differences between two KV trees in the configured format.
+func (p *Printer) printDiff(res *diff.Result) error {
+	masked := p.maskDiff(res)
+
+	switch p.format {
+	case JSON:
+		out, err := utils.ToJSON(masked)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprint(p.writer, string(out))
+	case YAML:
+		out, err := utils.ToYAML(masked)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprint(p.writer, string(out))
+	case Markdown:
+		p.printMarkdownDiff(masked)
+	case Base:
+		p.printBaseDiff(masked)
+	default:
+		return ErrInvalidFormat
+	}
+
+	return nil
+}
+
+// printBaseDiff renders the differences as a tree, one branch per differing path.
+func (p *Printer) printBaseDiff(res *diff.Result) {
+	if len(res.Changes) == 0 {
+		fmt.Fprintf(p.writer, "no differences found between %s and %s\n", res.Source, res.Target)
+
+		return
+	}
+
+	tree := treeprint.NewWithRoot(boldStyle(fmt.Sprintf("%s -> %s", res.Source, res.Target)))
+
+	for _, c := range res.Changes {
+		branch := tree.AddBranch(fmt.Sprintf("%s %s", diffSymbols[c.Type], boldStyle(c.Path)))
+
+		if p.onlyPaths {
+			continue
+		}
+
+		for _, k := range c.Keys {
+			branch.AddNode(fmt.Sprintf("%s %s", diffSymbols[k.Type], p.diffKeyValue(k)))
+		}
+	}
+
+	fmt.Fprintln(p.writer, strings.TrimSpace(tree.String()))
+}
+
+// printMarkdownDiff renders the differences as a markdown table.
+func (p *Printer) printMarkdownDiff(res *diff.Result) {
+	headers := []string{"path", "key", "change", res.Source, res.Target}
+	data := [][]string{}
+
+	for _, c := range res.Changes {
+		for _, k := range c.Keys {
+			data = append(data, []string{c.Path, k.Key, string(k.Type), diffValue(k.Old), diffValue(k.New)})
+		}
+	}
+
+	table := tablewriter.NewWriter(p.writer)
+	table.SetHeader(headers)
+	table.SetAutoFormatHeaders(false)
+	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+	table.SetCenterSeparator("|")
+	table.AppendBulk(data)
+	table.SetAutoMergeCellsByColumnIndex([]int{0})
+	table.Render()
+}
+
+// diffKeyValue returns the label of a differing key, e.g. "user=old -> new".
+func (p *Printer) diffKeyValue(k diff.KeyChange) string {
+	switch {
+	case p.onlyKeys:
+		return k.Key
+	case k.Type == diff.Added:
+		return fmt.Sprintf("%s=%v", k.Key, k.New)
+	case k.Type == diff.Removed:
+		return fmt.Sprintf("%s=%v", k.Key, k.Old)
+	default:
+		return fmt.Sprintf("%s=%v -> %v", k.Key, k.Old, k.New)
+	}
+}
+
+// maskDiff returns a copy of the result with masked or trimmed values according to the printer options.
+func (p *Printer) maskDiff(res *diff.Result) *diff.Result {
+	masked := &diff.Result{
+		Source:  res.Source,
+		Target:  res.Target,
+		Changes: make([]diff.PathChange, 0, len(res.Changes)),
+	}
+
+	for _, c := range res.Changes {
+		mc := diff.PathChange{
+			Path: c.Path,
+			Type: c.Type,
+			Keys: make([]diff.KeyChange, 0, len(c.Keys)),
+		}
+
+		// only the paths are shown, regardless of the output format
+		if p.onlyPaths {
+			masked.Changes = append(masked.Changes, mc)
+
+			continue
+		}
+
+		for _, k := range c.Keys {
+			switch {
+			case p.onlyKeys:
+				k.Old, k.New = nil, nil
+			case !p.showValues:
+				if k.Old != nil {
+					k.Old = p.formatVersionValue(k.Old)
+				}
+
+				if k.New != nil {
+					k.New = p.formatVersionValue(k.New)
+				}
+			}
+
+			mc.Keys = append(mc.Keys, k)
+		}
+
+		masked.Changes = append(masked.Changes, mc)
+	}
+
+	return masked
+}
+
+func diffValue(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%v", v)
+}
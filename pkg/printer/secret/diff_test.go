@@ -0,0 +1,160 @@
+package secret
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/FalcoSuessgott/vkv/pkg/diff"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintDiff(t *testing.T) {
+	res := &diff.Result{
+		Source: "secret/",
+		Target: "secret_2/",
+		Changes: []diff.PathChange{
+			{
+				Path: "admin",
+				Type: diff.Changed,
+				Keys: []diff.KeyChange{
+					{Key: "sub", Type: diff.Changed, Old: "password", New: "secret"},
+					{Key: "user", Type: diff.Added, New: "root"},
+				},
+			},
+			{
+				Path: "demo",
+				Type: diff.Removed,
+				Keys: []diff.KeyChange{
+					{Key: "foo", Type: diff.Removed, Old: "bar"},
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name   string
+		res    *diff.Result
+		opts   []Option
+		output string
+	}{
+		{
+			name: "base",
+			res:  res,
+			opts: []Option{ToFormat(Base)},
+			output: `secret/ -> secret_2/
+├── ~ admin
+│   ├── ~ sub=******** -> ******
+│   └── + user=****
+└── - demo
+    └── - foo=***
+`,
+		},
+		{
+			name: "base show values",
+			res:  res,
+			opts: []Option{ToFormat(Base), ShowValues(true)},
+			output: `secret/ -> secret_2/
+├── ~ admin
+│   ├── ~ sub=password -> secret
+│   └── + user=root
+└── - demo
+    └── - foo=bar
+`,
+		},
+		{
+			name: "base only paths",
+			res:  res,
+			opts: []Option{ToFormat(Base), OnlyPaths(true)},
+			output: `secret/ -> secret_2/
+├── ~ admin
+└── - demo
+`,
+		},
+		{
+			name: "json only paths",
+			res:  res,
+			opts: []Option{ToFormat(JSON), OnlyPaths(true)},
+			output: `{
+  "source": "secret/",
+  "target": "secret_2/",
+  "changes": [
+    {
+      "path": "admin",
+      "type": "changed"
+    },
+    {
+      "path": "demo",
+      "type": "removed"
+    }
+  ]
+}
+`,
+		},
+		{
+			name:   "base no differences",
+			res:    &diff.Result{Source: "secret/", Target: "secret_2/"},
+			opts:   []Option{ToFormat(Base)},
+			output: "no differences found between secret/ and secret_2/\n",
+		},
+		{
+			name: "json",
+			res:  res,
+			opts: []Option{ToFormat(JSON), OnlyKeys(true)},
+			output: `{
+  "source": "secret/",
+  "target": "secret_2/",
+  "changes": [
+    {
+      "path": "admin",
+      "type": "changed",
+      "keys": [
+        {
+          "key": "sub",
+          "type": "changed"
+        },
+        {
+          "key": "user",
+          "type": "added"
+        }
+      ]
+    },
+    {
+      "path": "demo",
+      "type": "removed",
+      "keys": [
+        {
+          "key": "foo",
+          "type": "removed"
+        }
+      ]
+    }
+  ]
+}
+`,
+		},
+		{
+			name: "markdown",
+			res:  res,
+			opts: []Option{ToFormat(Markdown), ShowValues(true)},
+			output: `| path  | key  | change  | secret/  | secret_2/ |
+|-------|------|---------|----------|-----------|
+| admin | sub  | changed | password | secret    |
+|       | user | added   |          | root      |
+| demo  | foo  | removed | bar      |           |
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var b bytes.Buffer
+			tc.opts = append(tc.opts, WithWriter(&b))
+
+			p := NewSecretPrinter(tc.opts...)
+
+			require.NoError(t, p.Out(tc.res))
+			assert.Equal(t, tc.output, b.String(), tc.name)
+		})
+	}
+}
@@ -10,29 +10,20 @@ const (
 	exportFmtString = "export %s='%v'\n"
 )
 
-var exportMap map[string]interface{}
-
-func (p *Printer) printExport(secrets map[string]interface{}) error {
-	exportMap = make(map[string]interface{})
-
-	buildExport(secrets)
+// printExport prints the keys of all secrets as environment variables. Keys of multiple secrets are taken from the
+// secret sorted last, object values are printed as JSON.
+func (p *Printer) printExport(secrets []printedSecret) error {
+	exportMap := make(map[string]interface{})
+
+	for _, s := range secrets {
+		for k, v := range s.data {
+			exportMap[k] = v
+		}
+	}
 
 	for _, k := range utils.SortMapKeys(exportMap) {
-		fmt.Fprintf(p.writer, exportFmtString, k, exportMap[k])
+		fmt.Fprintf(p.writer, exportFmtString, k, formatValue(exportMap[k]))
 	}
 
 	return nil
 }
-
-func buildExport(secrets map[string]interface{}) {
-	for _, v := range secrets {
-		m, ok := v.(map[string]interface{})
-		if ok {
-			buildExport(m)
-		} else {
-			for k, v := range secrets {
-				exportMap[k] = v
-			}
-		}
-	}
-}
@@ -1,50 +1,36 @@
 package secret
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
 	"github.com/FalcoSuessgott/vkv/pkg/utils"
+	"github.com/FalcoSuessgott/vkv/pkg/vault"
 )
 
-func (p *Printer) printOnlykeys(secrets map[string]interface{}) map[string]interface{} {
-	res := map[string]interface{}{}
+// printOnlyKeys returns the keys of a secret with empty values.
+func (p *Printer) printOnlyKeys(data map[string]interface{}) map[string]interface{} {
+	res := make(map[string]interface{}, len(data))
 
-	for k, v := range secrets {
-		m, ok := v.(map[string]interface{})
-		if ok {
-			res[k] = p.printOnlykeys(m)
-		} else {
-			res[k] = ""
-		}
+	for k := range data {
+		res[k] = ""
 	}
 
 	return res
 }
 
-func (p *Printer) printOnlyPaths(secrets map[string]interface{}) map[string]interface{} {
-	res := map[string]interface{}{}
+// printOnlyPaths returns the keys of a secret without values.
+func (p *Printer) printOnlyPaths(data map[string]interface{}) map[string]interface{} {
+	res := make(map[string]interface{}, len(data))
 
-	for k, v := range secrets {
-		m, ok := v.(map[string]interface{})
-		if ok {
-			res[k] = p.printOnlyPaths(m)
-		} else {
-			res[k] = nil
-		}
+	for k := range data {
+		res[k] = nil
 	}
 
 	return res
 }
 
-func (p *Printer) printMergePaths(secrets map[string]interface{}, root string) map[string]interface{} {
-	m := make(map[string]interface{})
-
-	utils.FlattenMap(secrets, m, root)
-
-	return m
-}
-
 func (p *Printer) maskValues(secrets map[string]interface{}) map[string]interface{} {
 	res := map[string]interface{}{}
 
@@ -64,3 +50,58 @@ func (p *Printer) maskValues(secrets map[string]interface{}) map[string]interfac
 
 	return secrets
 }
+
+// formatValue returns the printed value of a secrets key, object values are printed as JSON.
+func formatValue(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		if out, err := json.Marshal(v); err == nil {
+			return string(out)
+		}
+	}
+
+	return fmt.Sprintf("%v", v)
+}
+
+// deletedAsOf reports whether the current version of the secret was deleted at the point in time of the printer.
+func (p *Printer) deletedAsOf(md *vault.SecretMetadata) bool {
+	if p.asOf.IsZero() {
+		return false
+	}
+
+	sv := md.Current()
+
+	return sv != nil && sv.DeletedAt(p.asOf)
+}
+
+// secretMetadata returns the metadata of a secret, either from the metadata read during the traversal
+// or, if not available, by reading it from Vault.
+func (p *Printer) secretMetadata(metadata vault.SecretsMetadata, rootPath, subPath string) (*vault.SecretMetadata, bool) {
+	key := strings.TrimSuffix(subPath, utils.Delimiter)
+
+	if md, ok := metadata[key]; ok {
+		return md, true
+	}
+
+	if metadata != nil || p.vaultClient == nil {
+		return nil, false
+	}
+
+	md, err := p.vaultClient.ReadMetadata(p.ctx, rootPath, subPath)
+	if err != nil {
+		return nil, false
+	}
+
+	return md, true
+}
+
+// formatCustomMetadata renders custom metadata as space separated, sorted "key=value" pairs.
+func formatCustomMetadata(m map[string]interface{}) string {
+	parts := make([]string, 0, len(m))
+
+	for _, k := range utils.SortMapKeys(m) {
+		parts = append(parts, fmt.Sprintf("%s=%v", k, m[k]))
+	}
+
+	return strings.Join(parts, " ")
+}
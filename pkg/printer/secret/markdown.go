@@ -1,15 +1,15 @@
 package secret
 
 import (
-	"fmt"
-	"strings"
+	"strconv"
 
 	"github.com/FalcoSuessgott/vkv/pkg/utils"
+	"github.com/FalcoSuessgott/vkv/pkg/vault"
 	"github.com/olekukonko/tablewriter"
 )
 
-func (p *Printer) printMarkdownTable(secrets map[string]interface{}) error {
-	headers, data := p.buildMarkdownTable(secrets)
+func (p *Printer) printMarkdownTable(secrets []printedSecret, metadata vault.SecretsMetadata) error {
+	headers, data := p.buildMarkdownTable(secrets, metadata)
 
 	table := tablewriter.NewWriter(p.writer)
 	table.SetHeader(headers)
@@ -23,15 +23,12 @@ func (p *Printer) printMarkdownTable(secrets map[string]interface{}) error {
 }
 
 // nolint: gocognit, nestif, cyclop
-func (p *Printer) buildMarkdownTable(secrets map[string]interface{}) ([]string, [][]string) {
+func (p *Printer) buildMarkdownTable(secrets []printedSecret, metadata vault.SecretsMetadata) ([]string, [][]string) {
 	data := [][]string{}
 	headers := []string{}
 
-	m := make(map[string]interface{})
-	utils.FlattenMap(secrets, m, "")
-
-	for _, k := range utils.SortMapKeys(m) {
-		v := utils.ToMapStringInterface(m[k])
+	for _, s := range secrets {
+		k, v := s.path, s.data
 
 		switch {
 		case p.onlyPaths:
@@ -47,35 +44,25 @@ func (p *Printer) buildMarkdownTable(secrets map[string]interface{}) ([]string,
 		default:
 			headers = []string{"path", "key", "value"}
 
-			rootPath := p.enginePath
-			subPath := strings.ReplaceAll(k, rootPath, "")
-
 			for i, j := range utils.SortMapKeys(v) {
-				d := []string{k, j, fmt.Sprintf("%v", v[j])} // path, key, value
+				d := []string{k, j, formatValue(v[j])} // path, key, value
 
 				if i == 0 {
+					md, ok := p.secretMetadata(metadata, p.enginePath, s.subPath)
+
 					if p.showVersion {
 						headers = append(headers, "version")
 
-						if v, err := p.vaultClient.ReadSecretVersion(p.ctx, rootPath, subPath); err == nil {
-							d = append(d, fmt.Sprintf("%v", v)) // version
+						if ok {
+							d = append(d, strconv.Itoa(md.CurrentVersion)) // version
 						}
 					}
 
 					if p.showMetadata {
 						headers = append(headers, "metadata")
 
-						if v, err := p.vaultClient.ReadSecretMetadata(p.ctx, rootPath, subPath); err == nil {
-							m := ""
-
-							md, ok := v.(map[string]interface{})
-							if ok {
-								for k, v := range md {
-									m = fmt.Sprintf("%v %s=%v", m, k, v)
-								}
-							}
-
-							d = append(d, strings.TrimPrefix(m, " ")) // metadata
+						if ok {
+							d = append(d, formatCustomMetadata(md.CustomMetadata)) // metadata
 						}
 					}
 				} else {
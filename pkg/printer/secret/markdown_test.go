@@ -71,6 +71,23 @@ func TestPrintMarkdown(t *testing.T) {
 			output: `|    PATH     |
 |-------------|
 | root/secret |
+`,
+		},
+		{
+			name:     "test: markdown object values",
+			rootPath: "root",
+			s: map[string]interface{}{
+				"secret": map[string]interface{}{
+					"config": map[string]interface{}{"port": 5432},
+				},
+			},
+			opts: []Option{
+				ToFormat(Markdown),
+				ShowValues(true),
+			},
+			output: `|    PATH     |  KEY   |     VALUE     |
+|-------------|--------|---------------|
+| root/secret | config | {"port":5432} |
 `,
 		},
 	}
@@ -139,11 +156,8 @@ func TestMarkdownHeader(t *testing.T) {
 		var b bytes.Buffer
 		tc.opts = append(tc.opts, WithWriter(&b))
 
-		m := map[string]interface{}{}
-		m["root"] = tc.s
-
 		p := NewSecretPrinter(tc.opts...)
-		headers, _ := p.buildMarkdownTable(m)
+		headers, _ := p.buildMarkdownTable(p.printedSecrets(vault.SecretTreeFromMap("", tc.s), ""), nil)
 
 		assert.Equal(t, tc.expected, headers, tc.name)
 	}
@@ -3,7 +3,6 @@ package secret
 import (
 	"fmt"
 
-	"github.com/FalcoSuessgott/vkv/pkg/utils"
 	"github.com/FalcoSuessgott/vkv/pkg/vault"
 	"github.com/juju/ansiterm"
 )
@@ -17,22 +16,29 @@ const (
 	padding  = 2
 )
 
-func (p *Printer) printPolicy(secrets map[string]interface{}) error {
-	transformMap := make(map[string]interface{})
-	utils.FlattenMap(secrets, transformMap, "")
+func (p *Printer) printPolicy(secrets []printedSecret) error {
+	capMap, err := p.capabilities(secrets)
+	if err != nil {
+		return err
+	}
+
+	return p.printCapabilities(capMap)
+}
 
+// capabilities returns the capabilities of the current token for every secret path.
+func (p *Printer) capabilities(secrets []printedSecret) (map[string]*vault.Capability, error) {
 	capMap := make(map[string]*vault.Capability)
 
-	for k := range transformMap {
-		c, err := p.vaultClient.GetCapabilities(p.ctx, k)
+	for _, s := range secrets {
+		c, err := p.vaultClient.GetCapabilities(p.ctx, s.path)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		capMap[k] = c
+		capMap[s.path] = c
 	}
 
-	return p.printCapabilities(capMap)
+	return capMap, nil
 }
 
 func (p *Printer) printCapabilities(caps map[string]*vault.Capability) error {
@@ -6,8 +6,10 @@ import (
 	"io"
 	"log"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/FalcoSuessgott/vkv/pkg/diff"
 	"github.com/FalcoSuessgott/vkv/pkg/fs"
 	"github.com/FalcoSuessgott/vkv/pkg/utils"
 	"github.com/FalcoSuessgott/vkv/pkg/vault"
@@ -71,8 +73,11 @@ type Printer struct {
 	valueLength    int
 	template       string
 	vaultClient    *vault.Vault
+	metadata       vault.SecretsMetadata
 	// now is the reference time for relative timestamps; defaults to time.Now() when zero.
 	now time.Time
+	// asOf is the point in time the secrets versions were selected for, zero for the current versions.
+	asOf time.Time
 }
 
 // WithContext option for passing a custom context.
@@ -184,6 +189,21 @@ func WithVaultClient(v *vault.Vault) Option {
 	}
 }
 
+// WithSecretsMetadata passes the secrets metadata read during the traversal,
+// so it does not have to be read again for every secret.
+func WithSecretsMetadata(m vault.SecretsMetadata) Option {
+	return func(p *Printer) {
+		p.metadata = m
+	}
+}
+
+// AsOf marks the secrets as read at the point in time t, so versions deleted at that time are shown as deleted.
+func AsOf(t time.Time) Option {
+	return func(p *Printer) {
+		p.asOf = t
+	}
+}
+
 func WithEnginePath(path string) Option {
 	return func(p *Printer) {
 		p.enginePath = path
@@ -227,42 +247,89 @@ func (p *Printer) Out(secrets interface{}) error {
 		}
 	}
 
-	secretMap := utils.ToMapStringInterface(secrets)
-
-	for k, v := range secretMap {
-		if !p.showValues {
-			secretMap[k] = p.maskValues(utils.ToMapStringInterface(v))
-		}
+	// diff results are rendered as a change report rather than a secret tree
+	if res, ok := secrets.(*diff.Result); ok {
+		return p.printDiff(res)
+	}
 
-		if p.onlyPaths {
-			secretMap[k] = p.printOnlyPaths(utils.ToMapStringInterface(v))
-		}
+	// typed secret trees keep directories and secrets apart without converting them to nested maps
+	if t, ok := secrets.(*vault.SecretTree); ok {
+		return p.printSecretTree(t)
+	}
 
-		if p.onlyKeys {
-			secretMap[k] = p.printOnlykeys(utils.ToMapStringInterface(v))
-		}
+	// secrets of multiple engines are combined into a single document
+	if es, ok := secrets.(vault.AllEngineSecrets); ok {
+		return p.printAllEngines(es)
+	}
 
-		if p.mergePaths {
-			secretMap = p.printMergePaths(utils.ToMapStringInterface(v), k)
-		}
+	// legacy maps, e.g. *vault.Secrets, are converted to a plain map
+	secretMap, err := utils.LegacyToMap(secrets)
+	if err != nil {
+		return err
 	}
 
 	switch p.format {
 	case YAML:
-		return p.printYAML(secretMap)
+		return p.printYAML(p.transform(secretMap))
 	case JSON:
-		return p.printJSON(secretMap)
+		return p.printJSON(p.transform(secretMap))
 	case Export:
-		return p.printExport(secretMap)
+		return p.printExport(p.legacySecrets(secretMap))
 	case Markdown:
-		return p.printMarkdownTable(secretMap)
+		return p.printMarkdownTable(p.legacySecrets(secretMap), p.metadata)
 	case Template:
-		return p.printTemplate(secretMap)
+		return p.printTemplate(p.legacySecrets(secretMap))
 	case Base:
-		return p.printBase(secretMap)
+		return p.printBase(p.transform(secretMap))
 	case Policy:
-		return p.printPolicy(secretMap)
+		return p.printPolicy(p.legacySecrets(secretMap))
 	default:
 		return ErrInvalidFormat
 	}
 }
+
+// transform applies the masking, only-keys, only-paths and merge-paths options to the secrets of a legacy map.
+// Only keys ending with a "/" are directories, all other keys are secrets, whose object values are kept as is.
+// Merged paths are keyed by the full paths of the secrets and printed as is.
+func (p *Printer) transform(secretMap map[string]interface{}) map[string]interface{} {
+	if p.mergePaths {
+		merged := make(map[string]interface{})
+
+		for k, data := range vault.SecretTreeFromMap("", secretMap).Flatten() {
+			merged[k] = data
+		}
+
+		return merged
+	}
+
+	res := make(map[string]interface{}, len(secretMap))
+
+	for k, v := range secretMap {
+		m, ok := v.(map[string]interface{})
+
+		switch {
+		case !ok:
+			res[k] = v
+		case strings.HasSuffix(k, utils.Delimiter):
+			res[k] = p.transform(m)
+		default:
+			res[k] = p.transformSecret(m)
+		}
+	}
+
+	return res
+}
+
+// transformSecret applies the masking, only-keys and only-paths options to the data of a secret.
+func (p *Printer) transformSecret(data map[string]interface{}) map[string]interface{} {
+	switch {
+	case p.onlyKeys:
+		return p.printOnlyKeys(data)
+	case p.onlyPaths:
+		return p.printOnlyPaths(data)
+	case !p.showValues:
+		return p.maskValues(copySecret(data))
+	default:
+		return data
+	}
+}
@@ -5,12 +5,15 @@ import (
 	"strings"
 
 	"github.com/FalcoSuessgott/vkv/pkg/render"
-	"github.com/FalcoSuessgott/vkv/pkg/utils"
 )
 
-func (p *Printer) printTemplate(secrets map[string]interface{}) error {
-	m := make(map[string]interface{})
-	utils.FlattenMap(secrets, m, "")
+// printTemplate renders the template with the data of the secrets keyed by their path.
+func (p *Printer) printTemplate(secrets []printedSecret) error {
+	m := make(map[string]interface{}, len(secrets))
+
+	for _, s := range secrets {
+		m[s.path] = s.data
+	}
 
 	output, err := render.Apply([]byte(p.template), m)
 	if err != nil {
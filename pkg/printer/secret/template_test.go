@@ -12,12 +12,11 @@ import (
 
 func TestPrintTemplate(t *testing.T) {
 	testCases := []struct {
-		name     string
-		s        vault.Secrets
-		rootPath string
-		opts     []Option
-		output   string
-		err      bool
+		name   string
+		s      vault.Secrets
+		opts   []Option
+		output string
+		err    bool
 	}{
 		{
 			name: "test: template",
@@ -66,10 +65,7 @@ user=password
 
 		p := NewSecretPrinter(tc.opts...)
 
-		m := map[string]interface{}{}
-
-		m[tc.rootPath] = tc.s
-		require.NoError(t, p.Out(m))
+		require.NoError(t, p.Out(tc.s))
 		assert.Equal(t, tc.output, utils.RemoveCarriageReturns(b.String()), tc.name)
 	}
 }
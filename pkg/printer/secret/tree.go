@@ -0,0 +1,193 @@
+package secret
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/FalcoSuessgott/vkv/pkg/utils"
+	"github.com/FalcoSuessgott/vkv/pkg/vault"
+	"github.com/xlab/treeprint"
+)
+
+// printSecretTree prints the directories and secrets of a tree.
+// nolint: cyclop
+func (p *Printer) printSecretTree(t *vault.SecretTree) error {
+	switch p.format {
+	case Base:
+		return p.printBaseTree(t)
+	case JSON, YAML:
+		flat := make(map[string]interface{})
+		for k, v := range t.Flatten() {
+			flat[k] = v
+		}
+
+		flat = p.transform(flat)
+
+		if p.format == JSON {
+			return p.printJSON(flat)
+		}
+
+		return p.printYAML(flat)
+	case Export:
+		return p.printExport(p.printedSecrets(t, p.enginePath))
+	case Markdown:
+		return p.printMarkdownTable(p.printedSecrets(t, p.enginePath), p.treeMetadata(t))
+	case Template:
+		return p.printTemplate(p.printedSecrets(t, p.enginePath))
+	case Policy:
+		return p.printPolicy(p.printedSecrets(t, p.enginePath))
+	default:
+		return ErrInvalidFormat
+	}
+}
+
+// printedSecret is a secret as rendered by the export, markdown, template and policy formats.
+type printedSecret struct {
+	// path is the path of the secret prefixed by the engine path.
+	path string
+	// subPath is the path of the secret within the engine.
+	subPath string
+	// data holds the key-value pairs of the secret with all printer options applied.
+	data map[string]interface{}
+}
+
+// printedSecrets returns the non-empty secrets of a tree sorted by their path, which is prefixed by prefix.
+func (p *Printer) printedSecrets(t *vault.SecretTree, prefix string) []printedSecret {
+	res := []printedSecret{}
+
+	for _, s := range t.SecretNodes() {
+		if len(s.Data) == 0 {
+			continue
+		}
+
+		res = append(res, printedSecret{
+			path:    path.Join(prefix, s.Path),
+			subPath: s.Path,
+			data:    p.transformSecret(s.Data),
+		})
+	}
+
+	return res
+}
+
+// legacySecrets returns the secrets of a legacy map, which is keyed by the printers engine path
+// or by the full paths of the directories and secrets.
+func (p *Printer) legacySecrets(m map[string]interface{}) []printedSecret {
+	if secrets, ok := m[p.enginePath].(map[string]interface{}); ok && p.enginePath != "" && len(m) == 1 {
+		return p.printedSecrets(vault.SecretTreeFromMap("", secrets), p.enginePath)
+	}
+
+	return p.printedSecrets(vault.SecretTreeFromMap("", m), "")
+}
+
+// printBaseTree prints a tree in the base format.
+func (p *Printer) printBaseTree(t *vault.SecretTree) error {
+	fmt.Fprintln(p.writer, strings.TrimSpace(p.secretTree(t, p.treeMetadata(t)).String()))
+
+	return nil
+}
+
+// treeMetadata returns the metadata passed to the printer or, if none has been passed, the metadata of the secrets of
+// the tree. It is nil if neither is available, so that the metadata is read from Vault if needed.
+func (p *Printer) treeMetadata(t *vault.SecretTree) vault.SecretsMetadata {
+	if p.metadata != nil {
+		return p.metadata
+	}
+
+	if md := t.SecretsMetadata(); len(md) > 0 {
+		return md
+	}
+
+	return nil
+}
+
+// secretTree returns the base format tree of the directories and secrets of a tree, rooted at the printers engine.
+func (p *Printer) secretTree(t *vault.SecretTree, md vault.SecretsMetadata) treeprint.Tree {
+	tree := p.engineRoot()
+
+	if t.Path == "" {
+		p.addTreeBranches(tree, t, md)
+
+		return tree
+	}
+
+	// the path elements leading to the listed directory or secret
+	parent := tree
+	elems := strings.Split(t.Path, utils.Delimiter)
+
+	for i := range elems[:len(elems)-1] {
+		branch := treeprint.NewWithRoot(p.buildTreeName(p.enginePath, strings.Join(elems[:i+1], utils.Delimiter)+utils.Delimiter, md))
+
+		parent.AddBranch(branch)
+		parent = branch
+	}
+
+	parent.AddBranch(p.treeBranch(t, md))
+
+	return tree
+}
+
+// addTreeBranches adds the secrets and sub directories of a directory to the tree.
+func (p *Printer) addTreeBranches(tree treeprint.Tree, dir *vault.SecretTree, md vault.SecretsMetadata) {
+	for i, c := range dir.Children {
+		// secrets that could not be read have no data
+		if !c.Dir && c.Data == nil {
+			continue
+		}
+
+		branch := p.treeBranch(c, md)
+
+		// a directory sharing its name with a secret keeps its delimiter, so that both can be told apart
+		if c.Dir && i > 0 && !dir.Children[i-1].Dir && dir.Children[i-1].Name == c.Name {
+			branch.SetValue(boldStyle(c.Name + utils.Delimiter))
+		}
+
+		tree.AddBranch(branch)
+	}
+}
+
+// treeBranch returns the branch of a directory or secret.
+func (p *Printer) treeBranch(t *vault.SecretTree, md vault.SecretsMetadata) treeprint.Tree {
+	if t.Dir {
+		tree := treeprint.NewWithRoot(p.buildTreeName(p.enginePath, t.Path+utils.Delimiter, md))
+
+		p.addTreeBranches(tree, t, md)
+
+		return tree
+	}
+
+	tree := treeprint.NewWithRoot(p.buildTreeName(p.enginePath, t.Path, md))
+
+	data := t.Data
+	if !p.showValues {
+		data = p.maskValues(copySecret(data))
+	}
+
+	for _, k := range utils.SortMapKeys(data) {
+		if p.onlyKeys {
+			tree.AddNode(k)
+		}
+
+		if !p.onlyKeys && !p.onlyPaths {
+			tree.AddNode(fmt.Sprintf("%s=%v", k, data[k]))
+		}
+	}
+
+	return tree
+}
+
+// copySecret returns a deep copy of the nested objects of a secret, so that masking does not modify the secret.
+func copySecret(m map[string]interface{}) map[string]interface{} {
+	res := make(map[string]interface{}, len(m))
+
+	for k, v := range m {
+		if nested, ok := v.(map[string]interface{}); ok {
+			v = copySecret(nested)
+		}
+
+		res[k] = v
+	}
+
+	return res
+}
@@ -0,0 +1,255 @@
+package secret
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/FalcoSuessgott/vkv/pkg/utils"
+	"github.com/FalcoSuessgott/vkv/pkg/vault"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintSecretTree(t *testing.T) {
+	// object values of a secret are never rendered as sub paths
+	objectValues := func() *vault.SecretTree {
+		t := vault.NewSecretDirectory("")
+		t.Insert("app", map[string]interface{}{"tls": map[string]interface{}{"enabled": true}})
+		t.Insert("sub/db", map[string]interface{}{"config": map[string]interface{}{"port": 5432}, "user": "admin"})
+
+		return t
+	}
+
+	testCases := []struct {
+		name     string
+		tree     func() *vault.SecretTree
+		rootPath string
+		opts     []Option
+		output   string
+	}{
+		{
+			name:     "base: masked values",
+			rootPath: "root",
+			tree: func() *vault.SecretTree {
+				t := vault.NewSecretDirectory("")
+				t.Insert("sub/demo", map[string]interface{}{"user": "password"})
+				t.Insert("admin", map[string]interface{}{"key": "value"})
+
+				return t
+			},
+			opts: []Option{ToFormat(Base)},
+			output: `root/
+├── admin
+│   └── key=*****
+│   
+└── sub
+    └── demo
+        └── user=********
+`,
+		},
+		{
+			name:     "base: sub directory",
+			rootPath: "root",
+			tree: func() *vault.SecretTree {
+				t := vault.NewSecretDirectory("sub/sub2")
+				t.Insert("sub/sub2/demo", map[string]interface{}{"user": "password"})
+
+				return t
+			},
+			opts: []Option{ToFormat(Base), ShowValues(true)},
+			output: `root/
+└── sub
+    └── sub2
+        └── demo
+            └── user=password
+`,
+		},
+		{
+			name:     "base: secret",
+			rootPath: "root",
+			tree: func() *vault.SecretTree {
+				return vault.NewSecret("sub/demo", map[string]interface{}{"user": "password"})
+			},
+			opts: []Option{ToFormat(Base), OnlyKeys(true)},
+			output: `root/
+└── sub
+    └── demo
+        └── user
+`,
+		},
+		{
+			name:     "base: metadata of the tree",
+			rootPath: "root",
+			tree: func() *vault.SecretTree {
+				t := vault.NewSecretDirectory("")
+				t.Insert("app", map[string]interface{}{"user": "password"})
+				t.Insert("app/db", map[string]interface{}{"user": "admin"})
+				t.SetMetadata(vault.SecretsMetadata{
+					"app": {
+						CurrentVersion: 3,
+						CustomMetadata: map[string]interface{}{"owner": "team-a"},
+						Versions:       []*vault.SecretVersion{{Version: 3}},
+					},
+				})
+
+				return t
+			},
+			opts: []Option{ToFormat(Base), ShowValues(true), ShowVersion(true), ShowMetadata(true)},
+			output: `root/
+├── app [v=3] [owner=team-a]
+│   └── user=password
+│   
+└── app/
+    └── db
+        └── user=admin
+`,
+		},
+		{
+			name:     "json",
+			rootPath: "root",
+			tree: func() *vault.SecretTree {
+				t := vault.NewSecretDirectory("sub")
+				t.Insert("sub/demo", map[string]interface{}{"user": "password"})
+				t.Insert("sub/empty", map[string]interface{}{})
+
+				return t
+			},
+			opts: []Option{ToFormat(JSON), ShowValues(true)},
+			output: `{
+  "sub/demo": {
+    "user": "password"
+  }
+}
+`,
+		},
+		{
+			name:     "export",
+			rootPath: "root",
+			tree: func() *vault.SecretTree {
+				t := vault.NewSecretDirectory("")
+				t.Insert("sub/demo", map[string]interface{}{"user": "password"})
+
+				return t
+			},
+			opts: []Option{ToFormat(Export), ShowValues(true)},
+			output: `export user='password'
+`,
+		},
+		{
+			name:     "base: object values",
+			rootPath: "root",
+			tree:     objectValues,
+			opts:     []Option{ToFormat(Base), OnlyKeys(true)},
+			output: `root/
+├── app
+│   └── tls
+│   
+└── sub
+    └── db
+        ├── config
+        └── user
+`,
+		},
+		{
+			name:     "json: object values only paths",
+			rootPath: "root",
+			tree:     objectValues,
+			opts:     []Option{ToFormat(JSON), OnlyPaths(true)},
+			output: `{
+  "app": {
+    "tls": null
+  },
+  "sub/db": {
+    "config": null,
+    "user": null
+  }
+}
+`,
+		},
+		{
+			name:     "yaml: object values only keys",
+			rootPath: "root",
+			tree:     objectValues,
+			opts:     []Option{ToFormat(YAML), OnlyKeys(true)},
+			output: `app:
+  tls: ""
+sub/db:
+  config: ""
+  user: ""
+`,
+		},
+		{
+			name:     "json: object values merge paths",
+			rootPath: "root",
+			tree:     objectValues,
+			opts:     []Option{ToFormat(JSON), MergePaths(true)},
+			output: `{
+  "app": {
+    "tls": {
+      "enabled": true
+    }
+  },
+  "sub/db": {
+    "config": {
+      "port": 5432
+    },
+    "user": "admin"
+  }
+}
+`,
+		},
+		{
+			name:     "export: object values",
+			rootPath: "root",
+			tree:     objectValues,
+			opts:     []Option{ToFormat(Export), ShowValues(true)},
+			output: `export config='{"port":5432}'
+export tls='{"enabled":true}'
+export user='admin'
+`,
+		},
+		{
+			name:     "markdown: object values",
+			rootPath: "root",
+			tree:     objectValues,
+			opts:     []Option{ToFormat(Markdown), ShowValues(true)},
+			output: `|    PATH     |  KEY   |      VALUE       |
+|-------------|--------|------------------|
+| root/app    | tls    | {"enabled":true} |
+| root/sub/db | config | {"port":5432}    |
+|             | user   | admin            |
+`,
+		},
+		{
+			name:     "template: object values",
+			rootPath: "root",
+			tree:     objectValues,
+			opts: []Option{ToFormat(Template), ShowValues(true), WithTemplate(`{{ range $path, $secret := . }}
+{{- $path }}: {{ len $secret }}
+{{ end -}}`, "")},
+			output: `root/app: 1
+root/sub/db: 2
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		var b bytes.Buffer
+
+		tc.opts = append(tc.opts,
+			WithWriter(&b),
+			WithEnginePath(utils.NormalizePath(tc.rootPath)),
+		)
+
+		tree := tc.tree()
+		before := tree.Map()
+
+		p := NewSecretPrinter(tc.opts...)
+
+		require.NoError(t, p.Out(tree), tc.name)
+		assert.Equal(t, tc.output, b.String(), tc.name)
+
+		// masking must not modify the secrets
+		assert.Equal(t, before, tree.Map(), tc.name)
+	}
+}
@@ -5,11 +5,13 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
-	"log"
-	"path"
+	"fmt"
+	"io"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/caarlos0/env/v6"
 	"github.com/ghodss/yaml"
@@ -27,51 +29,6 @@ func NormalizePath(path string) string {
 	return filepath.Clean(path) + Delimiter
 }
 
-// FlattenMap flattens a nested map into a single map with its.
-func FlattenMap(a, b map[string]interface{}, key string) {
-	for k, v := range a {
-		// if its a map -> go deeper
-		m, ok := v.(map[string]interface{})
-		if ok {
-			FlattenMap(m, b, path.Join(key, k))
-		} else { // otherwise add the key and value to the map
-			b[key] = a
-		}
-	}
-}
-
-// UnflattenMap takes a path like "a/b/c" and returns a map like map[a] -> map[b] -> map[c].
-// elements in ignoreElements are not splitted.
-func UnflattenMap(path string, data map[string]interface{}, ignoreElements ...string) map[string]interface{} {
-	m := map[string]interface{}{}
-
-	parts := strings.Split(path, Delimiter)
-
-	if path == "" {
-		return data
-	}
-
-	path = NormalizePath(path)
-
-	// if element is to be ignored
-	for _, ignore := range ignoreElements {
-		if strings.HasPrefix(path, NormalizePath(ignore)) {
-			m[NormalizePath(ignore)] = UnflattenMap(strings.TrimPrefix(path, NormalizePath(ignore)), data, ignoreElements...)
-
-			return m
-		}
-	}
-
-	if len(parts) > 1 {
-		m[NormalizePath(parts[0])] = UnflattenMap(strings.Join(parts[1:], Delimiter), data, ignoreElements...)
-	} else {
-		// or dont if there is no parts left
-		m[strings.TrimSuffix(path, Delimiter)] = data
-	}
-
-	return m
-}
-
 // SplitPath splits a given path by / and returns the first element and the joined rest paths.
 func SplitPath(path string) (string, string) {
 	parts := removeEmptyElements(strings.Split(path, Delimiter))
@@ -107,20 +64,21 @@ func removeEmptyElements(s []string) []string {
 	return r
 }
 
-// ToMapStringInterface takes any value and returns the map string interface.
-func ToMapStringInterface(i interface{}) map[string]interface{} {
+// LegacyToMap converts a value of the legacy map shape (e.g. *vault.Secrets) to a map using a JSON round trip.
+// Numbers are kept as json.Number, so that they are not converted to floats.
+func LegacyToMap(i interface{}) (map[string]interface{}, error) {
 	var m map[string]interface{}
 
 	data, err := json.Marshal(i)
 	if err != nil {
-		log.Fatalf("cannot convert %v to map[string]interface: %v", i, err)
+		return nil, fmt.Errorf("cannot convert %T to a map: %w", i, err)
 	}
 
-	if err := json.Unmarshal(data, &m); err != nil {
-		log.Fatalf("cannot convert %v to map[string]interface: %v", i, err)
+	if err := DecodeJSON(data, &m); err != nil {
+		return nil, fmt.Errorf("cannot convert %T to a map: %w", i, err)
 	}
 
-	return m
+	return m, nil
 }
 
 // ToJSON marshalls a given map to json.
@@ -147,13 +105,30 @@ func ToJSON(m interface{}) ([]byte, error) {
 // FromJSON takes a json byte array and marshalls it into a map.
 func FromJSON(b []byte) (map[string]interface{}, error) {
 	m := make(map[string]interface{})
-	if err := json.Unmarshal(b, &m); err != nil {
+	if err := DecodeJSON(b, &m); err != nil {
 		return nil, err
 	}
 
 	return m, nil
 }
 
+// DecodeJSON unmarshalls json keeping numbers as json.Number, so that their exact value is preserved.
+func DecodeJSON(b []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+
+	// reject trailing data the same way json.Unmarshal does
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return errors.New("invalid character after top-level value")
+	}
+
+	return nil
+}
+
 // ToYAML marshalls a given map to yaml.
 func ToYAML(m interface{}) ([]byte, error) {
 	out, err := yaml.Marshal(m)
@@ -167,13 +142,20 @@ func ToYAML(m interface{}) ([]byte, error) {
 // FromYAML takes a yaml byte array and marshalls it into a map.
 func FromYAML(b []byte) (map[string]interface{}, error) {
 	m := make(map[string]interface{})
-	if err := yaml.Unmarshal(b, &m); err != nil {
+	if err := yaml.Unmarshal(b, &m, useNumber); err != nil {
 		return nil, err
 	}
 
 	return m, nil
 }
 
+// useNumber keeps numbers decoded from yaml as json.Number.
+func useNumber(d *json.Decoder) *json.Decoder {
+	d.UseNumber()
+
+	return d
+}
+
 // SortMapKeys sorts the keys of a map.
 func SortMapKeys(m map[string]interface{}) []string {
 	keys := make(Keys, 0, len(m))
@@ -268,6 +250,20 @@ func HandleEnginePath(enginePath, path string) (string, string) {
 	return SplitPath(path)
 }
 
+// ParseDuration parses a duration like time.ParseDuration, additionally supporting days, e.g. "90d".
+func ParseDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	return time.ParseDuration(s)
+}
+
 func ParseEnvs(prefix string, i interface{}) error {
 	opts := env.Options{
 		Prefix: prefix,
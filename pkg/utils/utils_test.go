@@ -1,7 +1,9 @@
 package utils
 
 import (
+	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -23,128 +25,6 @@ func TestRemoveCarriageReturn(t *testing.T) {
 	assert.Equal(t, "new line\n", RemoveCarriageReturns(s))
 }
 
-func TestFlattenMap(t *testing.T) {
-	testCases := []struct {
-		name     string
-		m        map[string]interface{}
-		expected map[string]interface{}
-	}{
-		{
-			name: "2 level",
-			m: map[string]interface{}{
-				"secret": map[string]interface{}{
-					"root": map[string]interface{}{
-						"kv":   12,
-						"bool": false,
-					},
-				},
-			},
-			expected: map[string]interface{}{
-				"secret/root": map[string]interface{}{
-					"kv":   12,
-					"bool": false,
-				},
-			},
-		},
-		{
-			name: "1 level",
-			m: map[string]interface{}{
-				"secret": map[string]interface{}{
-					"kv":   12,
-					"bool": false,
-				},
-			},
-			expected: map[string]interface{}{
-				"secret": map[string]interface{}{
-					"kv":   12,
-					"bool": false,
-				},
-			},
-		},
-		{
-			name: "3 level",
-			m: map[string]interface{}{
-				"secret": map[string]interface{}{
-					"root": map[string]interface{}{
-						"sub": map[string]interface{}{
-							"kv":   12,
-							"bool": false,
-						},
-					},
-				},
-			},
-			expected: map[string]interface{}{
-				"secret/root/sub": map[string]interface{}{
-					"kv":   12,
-					"bool": false,
-				},
-			},
-		},
-		{
-			name: "3 multi level",
-			m: map[string]interface{}{
-				"secret": map[string]interface{}{
-					"root": map[string]interface{}{
-						"sub": map[string]interface{}{
-							"kv":   12,
-							"bool": false,
-						},
-						"sub2": map[string]interface{}{
-							"kv":   12,
-							"bool": false,
-						},
-					},
-				},
-			},
-			expected: map[string]interface{}{
-				"secret/root/sub": map[string]interface{}{
-					"kv":   12,
-					"bool": false,
-				},
-				"secret/root/sub2": map[string]interface{}{
-					"kv":   12,
-					"bool": false,
-				},
-			},
-		},
-		{
-			name: "3 multi level 2",
-			m: map[string]interface{}{
-				"secret": map[string]interface{}{
-					"root": map[string]interface{}{
-						"sub": map[string]interface{}{
-							"kv":   12,
-							"bool": false,
-						},
-					},
-					"root2": map[string]interface{}{
-						"kv":   12,
-						"bool": false,
-					},
-				},
-			},
-			expected: map[string]interface{}{
-				"secret/root/sub": map[string]interface{}{
-					"kv":   12,
-					"bool": false,
-				},
-				"secret/root2": map[string]interface{}{
-					"kv":   12,
-					"bool": false,
-				},
-			},
-		},
-	}
-
-	for _, tc := range testCases {
-		res := make(map[string]interface{})
-
-		FlattenMap(tc.m, res, "")
-
-		assert.Equal(t, tc.expected, res, tc.expected)
-	}
-}
-
 func TestGetRootElement(t *testing.T) {
 	m := map[string]interface{}{
 		"k": false,
@@ -163,71 +43,6 @@ func TestGetRootElement(t *testing.T) {
 	require.Error(t, err)
 }
 
-func TestUnflattenMap(t *testing.T) {
-	testCases := []struct {
-		name           string
-		path           string
-		m              map[string]interface{}
-		ignoreElements []string
-		expected       map[string]interface{}
-	}{
-		{
-			name: "simple",
-			path: "root/sub",
-			m: map[string]interface{}{
-				"k":  "v",
-				"k2": 12,
-			},
-			expected: map[string]interface{}{
-				"root/": map[string]interface{}{
-					"sub": map[string]interface{}{
-						"k":  "v",
-						"k2": 12,
-					},
-				},
-			},
-		},
-		{
-			name: "simple with ignored fields",
-			path: "root/sub",
-			m: map[string]interface{}{
-				"k":  "v",
-				"k2": 12,
-			},
-			ignoreElements: []string{"root/sub"},
-			expected: map[string]interface{}{
-				"root/sub/": map[string]interface{}{
-					"k":  "v",
-					"k2": 12,
-				},
-			},
-		},
-		{
-			name: "complex with ignored fields",
-			path: "root/sub/a/b/c",
-			m: map[string]interface{}{
-				"k":  "v",
-				"k2": 12,
-			},
-			ignoreElements: []string{"root/sub", "b/c"},
-			expected: map[string]interface{}{
-				"root/sub/": map[string]interface{}{
-					"a/": map[string]interface{}{
-						"b/c/": map[string]interface{}{
-							"k":  "v",
-							"k2": 12,
-						},
-					},
-				},
-			},
-		},
-	}
-
-	for _, tc := range testCases {
-		assert.Equal(t, tc.expected, UnflattenMap(tc.path, tc.m, tc.ignoreElements...), tc.name)
-	}
-}
-
 func TestRemoveEmptyElements(t *testing.T) {
 	testCases := []struct {
 		name     string
@@ -298,7 +113,7 @@ func TestSplitPath(t *testing.T) {
 	}
 }
 
-func TestToMapStringInterface(t *testing.T) {
+func TestLegacyToMap(t *testing.T) {
 	testCases := []struct {
 		name     string
 		input    interface{}
@@ -315,10 +130,22 @@ func TestToMapStringInterface(t *testing.T) {
 				"key_2": map[string]interface{}{"key": false},
 			},
 		},
+		{
+			name: "test: numbers",
+			input: map[string]interface{}{
+				"key_1": map[string]interface{}{"int": 9007199254740993, "float": 1.5},
+			},
+			expected: map[string]interface{}{
+				"key_1": map[string]interface{}{"int": json.Number("9007199254740993"), "float": json.Number("1.5")},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
-		assert.Equal(t, tc.expected, ToMapStringInterface(tc.input), tc.name)
+		m, err := LegacyToMap(tc.input)
+
+		require.NoError(t, err, tc.name)
+		assert.Equal(t, tc.expected, m, tc.name)
 	}
 }
 
@@ -427,6 +254,20 @@ func TestFromJSON(t *testing.T) {
 			input:    []byte("{}"),
 			expected: map[string]interface{}{},
 		},
+		{
+			name:  "test: numbers",
+			input: []byte(`{"key_1": 9007199254740993, "key_2": 1.0, "key_3": {"port": 8080}}`),
+			expected: map[string]interface{}{
+				"key_1": json.Number("9007199254740993"),
+				"key_2": json.Number("1.0"),
+				"key_3": map[string]interface{}{"port": json.Number("8080")},
+			},
+		},
+		{
+			name:  "test: trailing data",
+			input: []byte(`{"key_1": "value"} {}`),
+			err:   true,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -521,6 +362,19 @@ key_3:
 				"key_3": map[string]interface{}{"foo": "bar", "user": "password"},
 			},
 		},
+		{
+			name: "test: numbers",
+			input: []byte(`key_1: 8080
+key_2: "8080"
+key_3:
+  ratio: 0.5
+`),
+			expected: map[string]interface{}{
+				"key_1": json.Number("8080"),
+				"key_2": "8080",
+				"key_3": map[string]interface{}{"ratio": json.Number("0.5")},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -607,6 +461,43 @@ func TestHandleEnginePath(t *testing.T) {
 	}
 }
 
+func TestParseDuration(t *testing.T) {
+	testCases := []struct {
+		name     string
+		s        string
+		err      bool
+		expected time.Duration
+	}{
+		{
+			name:     "days",
+			s:        "90d",
+			expected: 90 * 24 * time.Hour,
+		},
+		{
+			name:     "hours",
+			s:        "12h",
+			expected: 12 * time.Hour,
+		},
+		{
+			name: "invalid days",
+			s:    "xd",
+			err:  true,
+		},
+		{
+			name: "invalid",
+			s:    "90",
+			err:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		d, err := ParseDuration(tc.s)
+
+		require.Equal(t, tc.err, err != nil, tc.name)
+		assert.Equal(t, tc.expected, d, tc.name)
+	}
+}
+
 func TestParseEnvs(t *testing.T) {
 	type test struct {
 		Test string `env:"TEST"`
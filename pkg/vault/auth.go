@@ -0,0 +1,233 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/FalcoSuessgott/vkv/pkg/utils"
+	"github.com/hashicorp/vault/api"
+)
+
+const (
+	envVarAuthPrefix = "VKV_AUTH_"
+	envVarAuthMethod = envVarAuthPrefix + "METHOD"
+
+	authMethodAppRole    = "approle"
+	authMethodKubernetes = "kubernetes"
+	authMethodJWT        = "jwt"
+	authMethodOIDC       = "oidc"
+	authMethodUserpass   = "userpass"
+
+	authLoginPath = "auth/%s/login"
+)
+
+// ErrInvalidAuthMethod invalid auth method.
+var ErrInvalidAuthMethod = errors.New("invalid auth method (valid options: approle, kubernetes, jwt, oidc, userpass)")
+
+// AuthOptions holds the configuration of a native Vault auth method, configured via VKV_AUTH_* env vars or flags.
+// Values can also be read from files, which are read on every login, so rotated credentials are picked up.
+type AuthOptions struct {
+	Method string `env:"METHOD"`
+	Mount  string `env:"MOUNT"`
+	Role   string `env:"ROLE"`
+
+	// approle
+	RoleID       string `env:"ROLE_ID"`
+	RoleIDFile   string `env:"ROLE_ID_FILE"`
+	SecretID     string `env:"SECRET_ID"`
+	SecretIDFile string `env:"SECRET_ID_FILE"`
+
+	// kubernetes
+	KubernetesTokenFile string `env:"KUBERNETES_TOKEN_FILE" envDefault:"/var/run/secrets/kubernetes.io/serviceaccount/token"`
+
+	// jwt, oidc
+	JWT     string `env:"JWT"`
+	JWTFile string `env:"JWT_FILE"`
+
+	// userpass
+	Username     string `env:"USERNAME"`
+	Password     string `env:"PASSWORD"`
+	PasswordFile string `env:"PASSWORD_FILE"`
+}
+
+// AuthOptionsFromEnv returns the auth options configured via the VKV_AUTH_* env vars, they are not validated,
+// since they might be completed by flags.
+func AuthOptionsFromEnv() (*AuthOptions, error) {
+	o := &AuthOptions{}
+
+	if err := utils.ParseEnvs(envVarAuthPrefix, o); err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+// authOptionsFromEnv returns the auth method configured via VKV_AUTH_METHOD, or nil if none is configured.
+func authOptionsFromEnv() (*AuthOptions, error) {
+	if _, ok := os.LookupEnv(envVarAuthMethod); !ok {
+		return nil, nil
+	}
+
+	o, err := AuthOptionsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+// validate defaults the mount to the auth method and verifies that all values required for a login are configured.
+func (o *AuthOptions) validate() error {
+	o.Method = strings.ToLower(o.Method)
+
+	if o.Mount == "" {
+		o.Mount = o.Method
+	}
+
+	_, err := o.loginData()
+
+	return err
+}
+
+// login authenticates against the configured auth method of the Vault server at addr and returns the client token.
+func (o *AuthOptions) login(ctx context.Context, c *api.Client, addr string) (string, error) {
+	data, err := o.loginData()
+	if err != nil {
+		return "", err
+	}
+
+	// never send an existing token along with the login request
+	lc, err := c.CloneWithHeaders()
+	if err != nil {
+		return "", err
+	}
+
+	lc.ClearToken()
+
+	if err := lc.SetAddress(addr); err != nil {
+		return "", err
+	}
+
+	secret, err := lc.Logical().WriteWithContext(ctx, o.loginPath(), data)
+	if err != nil {
+		return "", fmt.Errorf("error logging in using auth method \"%s\" (mount: \"%s\"): %w", o.Method, o.Mount, err)
+	}
+
+	if secret == nil || secret.Auth == nil || secret.Auth.ClientToken == "" {
+		return "", fmt.Errorf("no token returned by auth method \"%s\" (mount: \"%s\")", o.Method, o.Mount)
+	}
+
+	return secret.Auth.ClientToken, nil
+}
+
+// loginPath returns the login endpoint of the configured auth method.
+func (o *AuthOptions) loginPath() string {
+	p := fmt.Sprintf(authLoginPath, strings.Trim(o.Mount, utils.Delimiter))
+
+	// userpass expects the username as part of the login path
+	if o.Method == authMethodUserpass {
+		p = path.Join(p, o.Username)
+	}
+
+	return p
+}
+
+// loginData returns the payload of the login request for the configured auth method.
+// nolint: cyclop
+func (o *AuthOptions) loginData() (map[string]interface{}, error) {
+	switch o.Method {
+	case authMethodAppRole:
+		roleID, err := valueOrFile(o.RoleID, o.RoleIDFile, "VKV_AUTH_ROLE_ID")
+		if err != nil {
+			return nil, err
+		}
+
+		data := map[string]interface{}{"role_id": roleID}
+
+		// secret_id is optional, since approles can be configured with bind_secret_id=false
+		if o.SecretID != "" || o.SecretIDFile != "" {
+			secretID, err := valueOrFile(o.SecretID, o.SecretIDFile, "VKV_AUTH_SECRET_ID")
+			if err != nil {
+				return nil, err
+			}
+
+			data["secret_id"] = secretID
+		}
+
+		return data, nil
+	case authMethodKubernetes:
+		if o.Role == "" {
+			return nil, errors.New("VKV_AUTH_ROLE is required for the kubernetes auth method")
+		}
+
+		jwt, err := readFile(o.KubernetesTokenFile)
+		if err != nil {
+			return nil, err
+		}
+
+		return map[string]interface{}{"role": o.Role, "jwt": jwt}, nil
+	case authMethodJWT, authMethodOIDC:
+		jwt, err := valueOrFile(o.JWT, o.JWTFile, "VKV_AUTH_JWT")
+		if err != nil {
+			return nil, err
+		}
+
+		data := map[string]interface{}{"jwt": jwt}
+
+		// role is optional, if the auth method has a default role configured
+		if o.Role != "" {
+			data["role"] = o.Role
+		}
+
+		return data, nil
+	case authMethodUserpass:
+		if o.Username == "" {
+			return nil, errors.New("VKV_AUTH_USERNAME is required for the userpass auth method")
+		}
+
+		password, err := valueOrFile(o.Password, o.PasswordFile, "VKV_AUTH_PASSWORD")
+		if err != nil {
+			return nil, err
+		}
+
+		return map[string]interface{}{"password": password}, nil
+	default:
+		return nil, fmt.Errorf("%w: \"%s\"", ErrInvalidAuthMethod, o.Method)
+	}
+}
+
+// valueOrFile returns the value, or if empty, the trimmed content of the file.
+func valueOrFile(value, file, envVar string) (string, error) {
+	if value != "" {
+		return value, nil
+	}
+
+	if file == "" {
+		return "", fmt.Errorf("either %s or %s_FILE is required", envVar, envVar)
+	}
+
+	return readFile(file)
+}
+
+// readFile returns the trimmed content of a file, errors if the file is empty.
+func readFile(file string) (string, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("error reading %s: %w", file, err)
+	}
+
+	v := strings.TrimSpace(string(content))
+	if v == "" {
+		return "", fmt.Errorf("%s is empty", file)
+	}
+
+	return v, nil
+}
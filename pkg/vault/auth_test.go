@@ -0,0 +1,182 @@
+package vault
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthOptionsFromEnv(t *testing.T) {
+	dir := t.TempDir()
+
+	secretIDFile := filepath.Join(dir, "secret-id")
+	require.NoError(t, os.WriteFile(secretIDFile, []byte("secret-id\n"), 0o600))
+
+	emptyFile := filepath.Join(dir, "empty")
+	require.NoError(t, os.WriteFile(emptyFile, []byte(""), 0o600))
+
+	testCases := []struct {
+		name      string
+		envVars   map[string]string
+		loginPath string
+		data      map[string]interface{}
+		err       bool
+	}{
+		{
+			name: "approle with secret id file",
+			envVars: map[string]string{
+				"VKV_AUTH_METHOD":         "approle",
+				"VKV_AUTH_ROLE_ID":        "role-id",
+				"VKV_AUTH_SECRET_ID_FILE": secretIDFile,
+			},
+			loginPath: "auth/approle/login",
+			data:      map[string]interface{}{"role_id": "role-id", "secret_id": "secret-id"},
+		},
+		{
+			name: "approle without role id",
+			envVars: map[string]string{
+				"VKV_AUTH_METHOD": "approle",
+			},
+			err: true,
+		},
+		{
+			name: "kubernetes with custom mount",
+			envVars: map[string]string{
+				"VKV_AUTH_METHOD":                "kubernetes",
+				"VKV_AUTH_MOUNT":                 "k8s/cluster-a/",
+				"VKV_AUTH_ROLE":                  "vkv",
+				"VKV_AUTH_KUBERNETES_TOKEN_FILE": secretIDFile,
+			},
+			loginPath: "auth/k8s/cluster-a/login",
+			data:      map[string]interface{}{"role": "vkv", "jwt": "secret-id"},
+		},
+		{
+			name: "kubernetes without role",
+			envVars: map[string]string{
+				"VKV_AUTH_METHOD":                "kubernetes",
+				"VKV_AUTH_KUBERNETES_TOKEN_FILE": secretIDFile,
+			},
+			err: true,
+		},
+		{
+			name: "jwt",
+			envVars: map[string]string{
+				"VKV_AUTH_METHOD": "JWT",
+				"VKV_AUTH_JWT":    "ey...",
+				"VKV_AUTH_ROLE":   "ci",
+			},
+			loginPath: "auth/jwt/login",
+			data:      map[string]interface{}{"jwt": "ey...", "role": "ci"},
+		},
+		{
+			name: "jwt empty file",
+			envVars: map[string]string{
+				"VKV_AUTH_METHOD":   "jwt",
+				"VKV_AUTH_JWT_FILE": emptyFile,
+			},
+			err: true,
+		},
+		{
+			name: "userpass",
+			envVars: map[string]string{
+				"VKV_AUTH_METHOD":   "userpass",
+				"VKV_AUTH_USERNAME": "admin",
+				"VKV_AUTH_PASSWORD": "password",
+			},
+			loginPath: "auth/userpass/login/admin",
+			data:      map[string]interface{}{"password": "password"},
+		},
+		{
+			name: "invalid method",
+			envVars: map[string]string{
+				"VKV_AUTH_METHOD": "ldap",
+			},
+			err: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for k, v := range tc.envVars {
+				t.Setenv(k, v)
+			}
+
+			o, err := authOptionsFromEnv()
+			if tc.err {
+				require.Error(t, err, tc.name)
+
+				return
+			}
+
+			require.NoError(t, err, tc.name)
+
+			data, err := o.loginData()
+			require.NoError(t, err, tc.name)
+
+			assert.Equal(t, tc.loginPath, o.loginPath(), tc.name)
+			assert.Equal(t, tc.data, data, tc.name)
+		})
+	}
+}
+
+func TestAuthOptionsFromEnvNotConfigured(t *testing.T) {
+	o, err := authOptionsFromEnv()
+
+	require.NoError(t, err)
+	assert.Nil(t, o)
+}
+
+func TestNewDefaultClientWithAuthInvalid(t *testing.T) {
+	_, err := NewDefaultClientWithAuth(context.Background(), &AuthOptions{Method: "userpass"})
+
+	require.Error(t, err)
+}
+
+func (s *VaultSuite) TestAuthMethodLogin() {
+	s.Run("userpass login", func() {
+		ctx := context.Background()
+
+		require.NoError(s.T(), s.client.Client.Sys().EnableAuthWithOptionsWithContext(ctx, "userpass", &api.EnableAuthOptions{Type: "userpass"}))
+
+		_, err := s.client.Client.Logical().WriteWithContext(ctx, "auth/userpass/users/vkv", map[string]interface{}{
+			"password": "password",
+		})
+		require.NoError(s.T(), err)
+
+		os.Unsetenv("VAULT_TOKEN")
+
+		s.T().Setenv("VAULT_ADDR", s.c.URI)
+		s.T().Setenv("VKV_AUTH_METHOD", "userpass")
+		s.T().Setenv("VKV_AUTH_USERNAME", "vkv")
+		s.T().Setenv("VKV_AUTH_PASSWORD", "password")
+
+		v, err := NewDefaultClient(ctx)
+		require.NoError(s.T(), err)
+		assert.NotEqual(s.T(), s.c.Token, v.Client.Token())
+	})
+
+	s.Run("userpass login with auth options", func() {
+		ctx := context.Background()
+
+		os.Unsetenv("VAULT_TOKEN")
+
+		s.T().Setenv("VAULT_ADDR", s.c.URI)
+		s.T().Setenv("VKV_AUTH_PASSWORD", "password")
+
+		auth, err := AuthOptionsFromEnv()
+		require.NoError(s.T(), err)
+
+		auth.Method = "USERPASS"
+		auth.Username = "vkv"
+
+		v, err := NewDefaultClientWithAuth(ctx, auth)
+		require.NoError(s.T(), err)
+		assert.NotEqual(s.T(), s.c.Token, v.Client.Token())
+		assert.Equal(s.T(), "userpass", auth.Mount)
+	})
+}
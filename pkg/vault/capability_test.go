@@ -37,11 +37,11 @@ func (s *VaultSuite) TestGetCapabilities() {
 			require.Error(s.T(), s.client.EnableKV2Engine(context.Background(), tc.rootPath))
 
 			// read secrets- find none, so it errors
-			_, err := s.client.ReadSecrets(context.Background(), tc.rootPath, tc.subPath)
+			_, err := s.client.ReadSecrets(context.Background(), "", tc.rootPath, tc.subPath)
 			require.Error(s.T(), err)
 
 			// actual write the secrets
-			if err = s.client.WriteSecrets(context.Background(), tc.rootPath, tc.subPath, tc.s); err != nil {
+			if err = s.client.WriteSecrets(context.Background(), "", tc.rootPath, tc.subPath, tc.s); err != nil {
 				s.T().Fail()
 			}
 
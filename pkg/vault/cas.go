@@ -0,0 +1,135 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// ErrCASConflict is returned if a secret has been modified since its version has been read.
+var ErrCASConflict = errors.New("check-and-set conflict, the secret has been modified concurrently")
+
+// ReadSecretsVersion returns the secrets of a path and their KVv2 version, which is
+// required for writing the secret using check-and-set. If the secret does not exist
+// or its current version has been deleted, no secrets are returned.
+// KVv1 secrets have no versions, hence their version is always 0.
+// A non-empty namespace ns overrides the namespace of the client for this call.
+func (v *Vault) ReadSecretsVersion(ctx context.Context, ns, rootPath, subPath string) (map[string]interface{}, int, error) {
+	v = v.WithNamespace(ns)
+
+	isV1, err := v.IsKVv1(ctx, rootPath)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if isV1 {
+		data, err := v.Client.Logical().ReadWithContext(ctx, fmt.Sprintf(kvv1ReadWriteSecretsPath, rootPath, subPath))
+		if err != nil || data == nil {
+			return nil, 0, err
+		}
+
+		return data.Data, 0, nil
+	}
+
+	data, err := v.Client.Logical().ReadWithContext(ctx, fmt.Sprintf(kvv2ReadWriteSecretsPath, rootPath, subPath))
+	if err != nil || data == nil {
+		return nil, 0, err
+	}
+
+	version := 0
+	if md, ok := data.Data["metadata"].(map[string]interface{}); ok {
+		version = parseVaultInt(md["version"])
+	}
+
+	secrets, _ := data.Data["data"].(map[string]interface{})
+
+	return secrets, version, nil
+}
+
+// WriteSecretsCAS writes kv secrets to a specified path, given the current version of the secret
+// is still the specified version (0 if the secret does not exist), otherwise ErrCASConflict is returned.
+// KVv1 engines do not support check-and-set, their secrets are written unconditionally.
+// A non-empty namespace ns overrides the namespace of the client for this call.
+func (v *Vault) WriteSecretsCAS(ctx context.Context, ns, rootPath, subPath string, secrets map[string]interface{}, version int) error {
+	v = v.WithNamespace(ns)
+
+	isV1, err := v.IsKVv1(ctx, rootPath)
+	if err != nil {
+		return err
+	}
+
+	if isV1 {
+		return v.writeSecrets(ctx, rootPath, subPath, secrets)
+	}
+
+	_, err = v.Client.Logical().WriteWithContext(ctx, fmt.Sprintf(kvv2ReadWriteSecretsPath, rootPath, subPath), map[string]interface{}{
+		"options": map[string]interface{}{
+			"cas": version,
+		},
+		"data": secrets,
+	})
+	if isCASConflict(err) {
+		return fmt.Errorf("%w: \"%s\" (expected version %d)", ErrCASConflict, path.Join(rootPath, subPath), version)
+	}
+
+	return err
+}
+
+// PatchSecrets changes only the given keys of an existing secret and preserves all other keys.
+// KVv2 secrets are patched using check-and-set with the specified version, KVv1 secrets are read,
+// merged and written again.
+// A non-empty namespace ns overrides the namespace of the client for this call.
+func (v *Vault) PatchSecrets(ctx context.Context, ns, rootPath, subPath string, secrets map[string]interface{}, version int) error {
+	v = v.WithNamespace(ns)
+
+	isV1, err := v.IsKVv1(ctx, rootPath)
+	if err != nil {
+		return err
+	}
+
+	if isV1 {
+		existing, err := v.readSecrets(ctx, rootPath, subPath)
+		if err != nil {
+			return err
+		}
+
+		merged := make(map[string]interface{}, len(existing)+len(secrets))
+
+		for k, val := range existing {
+			merged[k] = val
+		}
+
+		for k, val := range secrets {
+			merged[k] = val
+		}
+
+		return v.writeSecrets(ctx, rootPath, subPath, merged)
+	}
+
+	_, err = v.Client.Logical().JSONMergePatch(ctx, fmt.Sprintf(kvv2ReadWriteSecretsPath, rootPath, subPath), map[string]interface{}{
+		"options": map[string]interface{}{
+			"cas": version,
+		},
+		"data": secrets,
+	})
+	if isCASConflict(err) {
+		return fmt.Errorf("%w: \"%s\" (expected version %d)", ErrCASConflict, path.Join(rootPath, subPath), version)
+	}
+
+	return err
+}
+
+// isCASConflict reports whether Vault rejected a write due to a mismatching check-and-set version.
+func isCASConflict(err error) bool {
+	var respErr *api.ResponseError
+	if !errors.As(err, &respErr) || respErr.StatusCode != http.StatusBadRequest {
+		return false
+	}
+
+	return strings.Contains(strings.Join(respErr.Errors, " "), "check-and-set")
+}
@@ -0,0 +1,145 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *VaultSuite) TestWriteSecretsCAS() {
+	s.Run("kvv2", func() {
+		ctx := context.Background()
+		rootPath := "cas"
+
+		require.NoError(s.T(), s.client.EnableKV2Engine(ctx, rootPath))
+
+		// not existing secret
+		secrets, version, err := s.client.ReadSecretsVersion(ctx, "", rootPath, "admin")
+		require.NoError(s.T(), err)
+		assert.Nil(s.T(), secrets)
+		assert.Equal(s.T(), 0, version)
+
+		require.NoError(s.T(), s.client.WriteSecretsCAS(ctx, "", rootPath, "admin", map[string]interface{}{"user": "v1"}, 0))
+
+		// writing a not existing secret fails once it exists
+		err = s.client.WriteSecretsCAS(ctx, "", rootPath, "admin", map[string]interface{}{"user": "v2"}, 0)
+		require.ErrorIs(s.T(), err, ErrCASConflict)
+
+		secrets, version, err = s.client.ReadSecretsVersion(ctx, "", rootPath, "admin")
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), map[string]interface{}{"user": "v1"}, secrets)
+		assert.Equal(s.T(), 1, version)
+
+		require.NoError(s.T(), s.client.WriteSecretsCAS(ctx, "", rootPath, "admin", map[string]interface{}{"user": "v2"}, version))
+
+		// outdated version
+		err = s.client.WriteSecretsCAS(ctx, "", rootPath, "admin", map[string]interface{}{"user": "v3"}, version)
+		require.ErrorIs(s.T(), err, ErrCASConflict)
+
+		// deleted secrets keep their version
+		require.NoError(s.T(), s.client.DeleteSecret(ctx, rootPath, "admin", nil))
+
+		secrets, version, err = s.client.ReadSecretsVersion(ctx, "", rootPath, "admin")
+		require.NoError(s.T(), err)
+		assert.Nil(s.T(), secrets)
+		assert.Equal(s.T(), 2, version)
+	})
+
+	s.Run("kvv1", func() {
+		ctx := context.Background()
+		rootPath := "casv1"
+
+		require.NoError(s.T(), s.client.EnableKV1Engine(ctx, rootPath))
+
+		require.NoError(s.T(), s.client.WriteSecretsCAS(ctx, "", rootPath, "admin", map[string]interface{}{"user": "v1"}, 0))
+		require.NoError(s.T(), s.client.WriteSecretsCAS(ctx, "", rootPath, "admin", map[string]interface{}{"user": "v2"}, 0))
+
+		secrets, version, err := s.client.ReadSecretsVersion(ctx, "", rootPath, "admin")
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), map[string]interface{}{"user": "v2"}, secrets)
+		assert.Equal(s.T(), 0, version)
+	})
+}
+
+func (s *VaultSuite) TestPatchSecrets() {
+	s.Run("kvv2", func() {
+		ctx := context.Background()
+		rootPath := "patch"
+
+		require.NoError(s.T(), s.client.EnableKV2Engine(ctx, rootPath))
+		require.NoError(s.T(), s.client.WriteSecrets(ctx, "", rootPath, "admin", map[string]interface{}{"user": "admin", "team": "a"}))
+
+		require.NoError(s.T(), s.client.PatchSecrets(ctx, "", rootPath, "admin", map[string]interface{}{"user": "root"}, 1))
+
+		secrets, version, err := s.client.ReadSecretsVersion(ctx, "", rootPath, "admin")
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), map[string]interface{}{"user": "root", "team": "a"}, secrets)
+		assert.Equal(s.T(), 2, version)
+
+		// outdated version
+		err = s.client.PatchSecrets(ctx, "", rootPath, "admin", map[string]interface{}{"user": "other"}, 1)
+		require.ErrorIs(s.T(), err, ErrCASConflict)
+	})
+
+	s.Run("kvv1", func() {
+		ctx := context.Background()
+		rootPath := "patchv1"
+
+		require.NoError(s.T(), s.client.EnableKV1Engine(ctx, rootPath))
+		require.NoError(s.T(), s.client.WriteSecrets(ctx, "", rootPath, "admin", map[string]interface{}{"user": "admin", "team": "a"}))
+
+		require.NoError(s.T(), s.client.PatchSecrets(ctx, "", rootPath, "admin", map[string]interface{}{"user": "root"}, 0))
+
+		secrets, err := s.client.ReadSecrets(ctx, "", rootPath, "admin")
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), map[string]interface{}{"user": "root", "team": "a"}, secrets)
+	})
+}
+
+func TestIsCASConflict(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{
+			name: "cas mismatch",
+			err: fmt.Errorf("write: %w", &api.ResponseError{
+				StatusCode: http.StatusBadRequest,
+				Errors:     []string{"check-and-set parameter did not match the current version"},
+			}),
+			expected: true,
+		},
+		{
+			name: "other bad request",
+			err: &api.ResponseError{
+				StatusCode: http.StatusBadRequest,
+				Errors:     []string{"no data provided"},
+			},
+		},
+		{
+			name: "permission denied",
+			err: &api.ResponseError{
+				StatusCode: http.StatusForbidden,
+				Errors:     []string{"permission denied"},
+			},
+		},
+		{
+			name: "other error",
+			err:  errors.New("connection refused"),
+		},
+		{
+			name: "no error",
+		},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, isCASConflict(tc.err), tc.name)
+	}
+}
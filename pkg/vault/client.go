@@ -15,21 +15,58 @@ import (
 // Vault represents a vault struct used for reading and writing secrets.
 type Vault struct {
 	Client *api.Client
+
+	// concurrency is the maximum number of concurrent requests during traversals.
+	concurrency int
+
+	// mounts caches the mount details of every engine looked up by this client, created on first use.
+	// It is shared with all namespace scoped copies of the client.
+	mounts *mountCache
+
+	// login performs a new login, nil if the token source does not support re-authentication.
+	login loginFunc
 }
 
-// NewDefaultClient returns a new vault client wrapper.
+// loginFunc performs a login using the configured login method against the Vault server at addr
+// and returns the new token.
+type loginFunc func(ctx context.Context, addr string) (string, error)
+
+// NewDefaultClient returns a new vault client wrapper, an auth method is read from the VKV_AUTH_* env vars.
 func NewDefaultClient(ctx context.Context) (*Vault, error) {
-	token, err := getToken()
+	auth, err := authOptionsFromEnv()
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("invalid auth method configuration: %w", err)
 	}
 
+	return newDefaultClient(ctx, auth)
+}
+
+// NewDefaultClientWithAuth returns a new vault client wrapper logging in using the auth method auth.
+// An auth method without a method configured is ignored.
+func NewDefaultClientWithAuth(ctx context.Context, auth *AuthOptions) (*Vault, error) {
+	if auth == nil || auth.Method == "" {
+		return newDefaultClient(ctx, nil)
+	}
+
+	if err := auth.validate(); err != nil {
+		return nil, fmt.Errorf("invalid auth method configuration: %w", err)
+	}
+
+	return newDefaultClient(ctx, auth)
+}
+
+func newDefaultClient(ctx context.Context, auth *AuthOptions) (*Vault, error) {
 	// create vault client using defaults (recommended)
 	c, err := api.NewClient(nil)
 	if err != nil {
 		return nil, err
 	}
 
+	token, login, err := getToken(ctx, c, auth)
+	if err != nil {
+		return nil, err
+	}
+
 	c.SetToken(token)
 
 	// self lookup current auth for verification
@@ -37,7 +74,7 @@ func NewDefaultClient(ctx context.Context) (*Vault, error) {
 		return nil, fmt.Errorf("not authenticated, perhaps not a valid token: %w", err)
 	}
 
-	return &Vault{Client: c}, nil
+	return &Vault{Client: c, login: login}, nil
 }
 
 // NewClient returns a new vault client wrapper.
@@ -56,23 +93,24 @@ func NewClient(addr, token string) (*Vault, error) {
 	return &Vault{Client: c}, nil
 }
 
-// getToken finds the token configured by the user via env vars or token helpers
-// Precedence: 1. VAULT_TOKEN, 2. VKV_LOGIN_COMMAND, 3. Vault Token Helper.
+// getToken finds the token configured by the user via env vars, the auth method auth (nil if none is configured) or token helpers
+// Precedence: 1. VAULT_TOKEN, 2. VKV_LOGIN_COMMAND, 3. VKV_AUTH_METHOD, 4. Vault Token Helper.
+// For VKV_LOGIN_COMMAND and VKV_AUTH_METHOD the login is returned as well, so it can be repeated once the token expires.
 //
-//nolint:cyclop
-func getToken() (string, error) {
+//nolint:cyclop, funlen, gocognit
+func getToken(ctx context.Context, c *api.Client, auth *AuthOptions) (string, loginFunc, error) {
 	// warn user if more than one is configured
 	envToken, envTokenOk := os.LookupEnv("VAULT_TOKEN")
 	tokenCommand, tokenCommandOk := os.LookupEnv("VKV_LOGIN_COMMAND")
 
 	th, err := tokenhelper.NewInternalTokenHelper()
 	if err != nil {
-		return "", fmt.Errorf("error creating default token helper: %w", err)
+		return "", nil, fmt.Errorf("error creating default token helper: %w", err)
 	}
 
 	thToken, err := th.Get()
 	if err != nil {
-		return "", fmt.Errorf("error getting token from default token helper: %w", err)
+		return "", nil, fmt.Errorf("error getting token from default token helper: %w", err)
 	}
 
 	var (
@@ -91,6 +129,10 @@ func getToken() (string, error) {
 		tokenSources++
 	}
 
+	if auth != nil {
+		tokenSources++
+	}
+
 	if thToken != "" {
 		tokenSources++
 	}
@@ -102,7 +144,7 @@ func getToken() (string, error) {
 		warn = true
 
 		if !disableWarn {
-			fmt.Println("[WARN] More than one token source configured (either VAULT_TOKEN, VKV_LOGIN_COMMAND or ~/.vault-token).")
+			fmt.Println("[WARN] More than one token source configured (either VAULT_TOKEN, VKV_LOGIN_COMMAND, VKV_AUTH_METHOD or ~/.vault-token).")
 			fmt.Println("[WARN] See https://falcosuessgott.github.io/vkv/authentication/ for vkv's token precedence logic. Disable this warning with VKV_DISABLE_WARNING.")
 		}
 	}
@@ -114,7 +156,7 @@ func getToken() (string, error) {
 			fmt.Println()
 		}
 
-		return envToken, nil
+		return envToken, nil, nil
 	}
 
 	// if VKV_LOGIN_COMMAND
@@ -124,7 +166,29 @@ func getToken() (string, error) {
 			fmt.Println()
 		}
 
-		return runVaultTokenCommand(tokenCommand)
+		login := func(context.Context, string) (string, error) {
+			return runVaultTokenCommand(tokenCommand)
+		}
+
+		token, err := login(ctx, c.Address())
+
+		return token, login, err
+	}
+
+	// if VKV_AUTH_METHOD
+	if auth != nil {
+		if warn && !disableWarn {
+			fmt.Printf("[INFO] Using VKV_AUTH_METHOD (%s).\n", auth.Method)
+			fmt.Println()
+		}
+
+		login := func(ctx context.Context, addr string) (string, error) {
+			return auth.login(ctx, c, addr)
+		}
+
+		token, err := login(ctx, c.Address())
+
+		return token, login, err
 	}
 
 	if thToken != "" {
@@ -133,10 +197,10 @@ func getToken() (string, error) {
 			fmt.Println()
 		}
 
-		return thToken, nil
+		return thToken, nil, nil
 	}
 
-	return "", errors.New("no token provided")
+	return "", nil, errors.New("no token provided")
 }
 
 func runVaultTokenCommand(cmd string) (string, error) {
@@ -154,3 +218,42 @@ func runVaultTokenCommand(cmd string) (string, error) {
 
 	return vaultToken, nil
 }
+
+// Clone returns a copy of the vault client wrapper using the same token and login method.
+// A non-empty address or namespace overrides the one of the original client, the copy logs in at its own address.
+func (v *Vault) Clone(addr, ns string) (*Vault, error) {
+	c, err := v.Client.CloneWithHeaders()
+	if err != nil {
+		return nil, err
+	}
+
+	c.SetToken(v.Client.Token())
+
+	if addr != "" {
+		if err := c.SetAddress(addr); err != nil {
+			return nil, err
+		}
+	}
+
+	if ns != "" {
+		c.SetNamespace(ns)
+	}
+
+	return &Vault{Client: c, concurrency: v.concurrency, login: v.login}, nil
+}
+
+// WithNamespace returns a copy of the vault client wrapper whose requests are sent to the namespace ns.
+// The shared client is not modified, so the copy can be used concurrently with the original one.
+// An empty namespace keeps the namespace configured for the original client.
+func (v *Vault) WithNamespace(ns string) *Vault {
+	if ns == "" {
+		return v
+	}
+
+	return &Vault{
+		Client:      v.Client.WithNamespace(ns),
+		concurrency: v.concurrency,
+		mounts:      v.mountCache(),
+		login:       v.login,
+	}
+}
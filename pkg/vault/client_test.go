@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/FalcoSuessgott/vkv/pkg/testutils"
+	"github.com/hashicorp/vault/api"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
@@ -77,8 +78,14 @@ func (s *VaultSuite) TestGetToken() {
 				s.T().Setenv(k, v)
 			}
 
+			c, err := api.NewClient(&api.Config{Address: s.c.URI})
+			s.Require().NoError(err)
+
 			// invoke token
-			t, err := getToken()
+			auth, err := authOptionsFromEnv()
+			s.Require().NoError(err)
+
+			t, _, err := getToken(context.Background(), c, auth)
 
 			// assert
 			if tc.err {
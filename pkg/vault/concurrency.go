@@ -0,0 +1,57 @@
+package vault
+
+import (
+	"os"
+	"strconv"
+)
+
+const (
+	defaultConcurrency = 10
+
+	envVarConcurrency = "VKV_CONCURRENCY"
+)
+
+// DefaultConcurrency returns the maximum number of concurrent Vault requests configured via VKV_CONCURRENCY.
+// If unset or invalid, a default of 10 is returned.
+func DefaultConcurrency() int {
+	if v, ok := os.LookupEnv(envVarConcurrency); ok {
+		if i, err := strconv.Atoi(v); err == nil && i > 0 {
+			return i
+		}
+	}
+
+	return defaultConcurrency
+}
+
+// SetConcurrency sets the maximum number of concurrent Vault requests used when traversing KV engines.
+// A value lower than 1 falls back to DefaultConcurrency.
+func (v *Vault) SetConcurrency(n int) {
+	v.concurrency = n
+}
+
+func (v *Vault) workers() int {
+	if v.concurrency > 0 {
+		return v.concurrency
+	}
+
+	return DefaultConcurrency()
+}
+
+// limiter bounds the number of in-flight Vault requests during a traversal.
+type limiter chan struct{}
+
+func newLimiter(n int) limiter {
+	if n < 1 {
+		n = 1
+	}
+
+	return make(limiter, n)
+}
+
+// do runs f once a slot is available.
+func (l limiter) do(f func()) {
+	l <- struct{}{}
+	defer func() { <-l }()
+
+	f()
+}
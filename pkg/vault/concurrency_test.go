@@ -0,0 +1,81 @@
+package vault
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultConcurrency(t *testing.T) {
+	testCases := []struct {
+		name     string
+		env      string
+		expected int
+	}{
+		{
+			name:     "default",
+			expected: defaultConcurrency,
+		},
+		{
+			name:     "env var",
+			env:      "50",
+			expected: 50,
+		},
+		{
+			name:     "invalid env var",
+			env:      "invalid",
+			expected: defaultConcurrency,
+		},
+		{
+			name:     "zero",
+			env:      "0",
+			expected: defaultConcurrency,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.env != "" {
+				t.Setenv(envVarConcurrency, tc.env)
+			}
+
+			assert.Equal(t, tc.expected, DefaultConcurrency(), tc.name)
+		})
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	l := newLimiter(3)
+
+	var (
+		wg      sync.WaitGroup
+		current atomic.Int32
+		maximum atomic.Int32
+	)
+
+	for range 50 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			l.do(func() {
+				n := current.Add(1)
+				defer current.Add(-1)
+
+				for {
+					m := maximum.Load()
+					if n <= m || maximum.CompareAndSwap(m, n) {
+						break
+					}
+				}
+			})
+		}()
+	}
+
+	wg.Wait()
+
+	assert.LessOrEqual(t, maximum.Load(), int32(3))
+}
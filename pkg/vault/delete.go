@@ -0,0 +1,148 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// nolint: gosec
+const (
+	kvv2DeleteVersionsPath   = "%s/delete/%s"
+	kvv2DestroyVersionsPath  = "%s/destroy/%s"
+	kvv2UndeleteVersionsPath = "%s/undelete/%s"
+)
+
+// ErrKVv1NotSupported the operation requires a KVv2 engine.
+var ErrKVv1NotSupported = errors.New("operation is only supported for KVv2 engines")
+
+// DeleteSecret soft deletes the given versions of a secret, the latest version if no versions are given.
+// Secrets of KVv1 engines are not versioned, they are removed permanently.
+func (v *Vault) DeleteSecret(ctx context.Context, rootPath, subPath string, versions []int) error {
+	isV1, err := v.IsKVv1(ctx, rootPath)
+	if err != nil {
+		return err
+	}
+
+	if isV1 {
+		if len(versions) > 0 {
+			return fmt.Errorf("%w: cannot delete versions of secret %s/%s", ErrKVv1NotSupported, rootPath, subPath)
+		}
+
+		_, err := v.Client.Logical().DeleteWithContext(ctx, fmt.Sprintf(kvv1ReadWriteSecretsPath, rootPath, subPath))
+
+		return err
+	}
+
+	if len(versions) == 0 {
+		_, err := v.Client.Logical().DeleteWithContext(ctx, fmt.Sprintf(kvv2ReadWriteSecretsPath, rootPath, subPath))
+
+		return err
+	}
+
+	_, err = v.Client.Logical().WriteWithContext(ctx, fmt.Sprintf(kvv2DeleteVersionsPath, rootPath, subPath), map[string]interface{}{
+		"versions": versions,
+	})
+
+	return err
+}
+
+// DestroySecret permanently removes the data of the given versions of a KVv2 secret.
+func (v *Vault) DestroySecret(ctx context.Context, rootPath, subPath string, versions []int) error {
+	isV1, err := v.IsKVv1(ctx, rootPath)
+	if err != nil {
+		return err
+	}
+
+	if isV1 {
+		return fmt.Errorf("%w: cannot destroy secret %s/%s", ErrKVv1NotSupported, rootPath, subPath)
+	}
+
+	if len(versions) == 0 {
+		return fmt.Errorf("no versions of secret %s/%s to destroy specified", rootPath, subPath)
+	}
+
+	_, err = v.Client.Logical().WriteWithContext(ctx, fmt.Sprintf(kvv2DestroyVersionsPath, rootPath, subPath), map[string]interface{}{
+		"versions": versions,
+	})
+
+	return err
+}
+
+// DeleteSecretMetadata permanently removes a KVv2 secret, including all of its versions and its metadata.
+func (v *Vault) DeleteSecretMetadata(ctx context.Context, rootPath, subPath string) error {
+	isV1, err := v.IsKVv1(ctx, rootPath)
+	if err != nil {
+		return err
+	}
+
+	if isV1 {
+		return fmt.Errorf("%w: cannot delete the metadata of secret %s/%s", ErrKVv1NotSupported, rootPath, subPath)
+	}
+
+	_, err = v.Client.Logical().DeleteWithContext(ctx, fmt.Sprintf(kvv2ListSecretsPath, rootPath, subPath))
+
+	return err
+}
+
+// UndeleteSecret restores the given soft deleted versions of a KVv2 secret.
+func (v *Vault) UndeleteSecret(ctx context.Context, rootPath, subPath string, versions []int) error {
+	isV1, err := v.IsKVv1(ctx, rootPath)
+	if err != nil {
+		return err
+	}
+
+	if isV1 {
+		return fmt.Errorf("%w: cannot undelete secret %s/%s", ErrKVv1NotSupported, rootPath, subPath)
+	}
+
+	if len(versions) == 0 {
+		return fmt.Errorf("no versions of secret %s/%s to undelete specified", rootPath, subPath)
+	}
+
+	_, err = v.Client.Logical().WriteWithContext(ctx, fmt.Sprintf(kvv2UndeleteVersionsPath, rootPath, subPath), map[string]interface{}{
+		"versions": versions,
+	})
+
+	return err
+}
+
+// ListRecursiveDeleted recursively reads every KVv2 secret under subPath whose current version has been deleted or destroyed.
+// Every secret holds only its current version, without any data.
+func (v *Vault) ListRecursiveDeleted(ctx context.Context, rootPath, subPath string, skipErrors bool) (VersionedSecrets, error) {
+	isV1, err := v.IsKVv1(ctx, rootPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if isV1 {
+		return nil, fmt.Errorf("%w: %q is a KVv1 engine", ErrKVv1NotSupported, rootPath)
+	}
+
+	now := time.Now()
+
+	read := func(ctx context.Context, rootPath, subPath string) (*VersionedSecret, error) {
+		md, err := v.ReadMetadata(ctx, rootPath, subPath)
+		if err != nil {
+			return nil, err
+		}
+
+		sv := md.Current()
+		if sv == nil || (!sv.Destroyed && !sv.DeletedAt(now)) {
+			return nil, nil //nolint: nilnil
+		}
+
+		return &VersionedSecret{
+			CustomMetadata: md.CustomMetadata,
+			Versions:       []*SecretVersion{sv},
+		}, nil
+	}
+
+	acc := &versionedSecretsAccumulator{secrets: make(VersionedSecrets)}
+	if err := v.listRecursiveAllVersions(ctx, newLimiter(v.workers()), rootPath, subPath, skipErrors, read, acc); err != nil {
+		return nil, err
+	}
+
+	return acc.secrets, nil
+}
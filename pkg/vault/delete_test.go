@@ -0,0 +1,85 @@
+package vault
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *VaultSuite) TestDeleteSecret() {
+	s.Run("soft delete versions", func() {
+		ctx := context.Background()
+		rootPath := "kvv2"
+
+		require.NoError(s.T(), s.client.EnableKV2Engine(ctx, rootPath))
+
+		require.NoError(s.T(), s.client.WriteSecrets(ctx, "", rootPath, "admin", map[string]interface{}{"user": "v1"}))
+		require.NoError(s.T(), s.client.WriteSecrets(ctx, "", rootPath, "admin", map[string]interface{}{"user": "v2"}))
+
+		// the latest version
+		require.NoError(s.T(), s.client.DeleteSecret(ctx, rootPath, "admin", nil))
+
+		md, err := s.client.ReadMetadata(ctx, rootPath, "admin")
+		require.NoError(s.T(), err)
+		assert.NotNil(s.T(), md.Versions[0].DeletionTime)
+		assert.Nil(s.T(), md.Versions[1].DeletionTime)
+
+		// a specific version
+		require.NoError(s.T(), s.client.DeleteSecret(ctx, rootPath, "admin", []int{1}))
+
+		md, err = s.client.ReadMetadata(ctx, rootPath, "admin")
+		require.NoError(s.T(), err)
+		assert.NotNil(s.T(), md.Versions[1].DeletionTime)
+		assert.False(s.T(), md.Versions[1].Destroyed)
+	})
+
+	s.Run("kvv1", func() {
+		ctx := context.Background()
+		rootPath := "kvv1"
+
+		require.NoError(s.T(), s.client.EnableKV1Engine(ctx, rootPath))
+		require.NoError(s.T(), s.client.WriteSecrets(ctx, "", rootPath, "admin", map[string]interface{}{"user": "v1"}))
+
+		require.ErrorIs(s.T(), s.client.DeleteSecret(ctx, rootPath, "admin", []int{1}), ErrKVv1NotSupported)
+		require.ErrorIs(s.T(), s.client.DestroySecret(ctx, rootPath, "admin", []int{1}), ErrKVv1NotSupported)
+		require.ErrorIs(s.T(), s.client.DeleteSecretMetadata(ctx, rootPath, "admin"), ErrKVv1NotSupported)
+
+		require.NoError(s.T(), s.client.DeleteSecret(ctx, rootPath, "admin", nil))
+
+		_, err := s.client.ReadSecrets(ctx, "", rootPath, "admin")
+		require.Error(s.T(), err)
+	})
+}
+
+func (s *VaultSuite) TestDestroySecret() {
+	s.Run("destroy versions", func() {
+		ctx := context.Background()
+		rootPath := "kvv2"
+
+		require.NoError(s.T(), s.client.EnableKV2Engine(ctx, rootPath))
+		require.NoError(s.T(), s.client.WriteSecrets(ctx, "", rootPath, "admin", map[string]interface{}{"user": "v1"}))
+
+		require.Error(s.T(), s.client.DestroySecret(ctx, rootPath, "admin", nil), "versions are required")
+		require.NoError(s.T(), s.client.DestroySecret(ctx, rootPath, "admin", []int{1}))
+
+		md, err := s.client.ReadMetadata(ctx, rootPath, "admin")
+		require.NoError(s.T(), err)
+		assert.True(s.T(), md.Versions[0].Destroyed)
+	})
+}
+
+func (s *VaultSuite) TestDeleteSecretMetadata() {
+	s.Run("delete metadata", func() {
+		ctx := context.Background()
+		rootPath := "kvv2"
+
+		require.NoError(s.T(), s.client.EnableKV2Engine(ctx, rootPath))
+		require.NoError(s.T(), s.client.WriteSecrets(ctx, "", rootPath, "admin", map[string]interface{}{"user": "v1"}))
+
+		require.NoError(s.T(), s.client.DeleteSecretMetadata(ctx, rootPath, "admin"))
+
+		_, err := s.client.ReadMetadata(ctx, rootPath, "admin")
+		require.Error(s.T(), err)
+	})
+}
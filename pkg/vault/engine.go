@@ -4,68 +4,71 @@ import (
 	"context"
 	"fmt"
 	"path"
+	"sort"
+	"strings"
+
+	"github.com/FalcoSuessgott/vkv/pkg/utils"
 )
 
 const (
 	mountEnginePath   = "sys/mounts/%s"
 	listSecretEngines = "sys/mounts"
+	kvv2ConfigPath    = "%s/config"
 )
 
 // Engines struct that hols all engines key is the namespace.
 type Engines map[string][]string
 
+// EngineSecrets holds the secrets of a single KV engine within a namespace.
+type EngineSecrets struct {
+	Namespace string
+	Engine    string
+	Secrets   *SecretTree
+	// Metadata is nil, if the metadata was not read.
+	Metadata SecretsMetadata
+}
+
+// AllEngineSecrets holds the secrets of multiple KV engines, sorted by namespace and engine.
+type AllEngineSecrets []*EngineSecrets
+
 // GetEngineDescription returns the description of the engine.
 func (v *Vault) GetEngineDescription(ctx context.Context, rootPath string) (string, error) {
-	data, err := v.Client.Logical().ReadWithContext(ctx, fmt.Sprintf(mountEnginePath, rootPath))
+	info, err := v.GetMountInfo(ctx, rootPath)
 	if err != nil {
-		return "", err
-	}
-
-	if data != nil {
-		desc, ok := data.Data["description"]
-		if !ok {
-			return "", nil
-		}
-
-		//nolint: forcetypeassert
-		return desc.(string), nil
+		return "", fmt.Errorf("could not get engine description for path: \"%s\": %w", rootPath, err)
 	}
 
-	return "", fmt.Errorf("could not get engine description for path: \"%s\"", rootPath)
+	return info.Description, nil
 }
 
+// GetEngineTypeVersion returns the type and version of the engine.
 func (v *Vault) GetEngineTypeVersion(ctx context.Context, rootPath string) (string, string, error) {
-	data, err := v.Client.Logical().ReadWithContext(ctx, fmt.Sprintf(mountEnginePath, rootPath))
+	info, err := v.GetMountInfo(ctx, rootPath)
 	if err != nil {
-		return "", "", err
+		return "", "", fmt.Errorf("could not get engine type for path: \"%s\": %w", rootPath, err)
 	}
 
-	if data != nil {
-		var eType, eVersion string
-
-		t, ok := data.Data["type"]
-
-		if ok {
-			//nolint: forcetypeassert
-			eType = t.(string)
-		}
+	// "options" is nil in "generic" type
+	//nolint: goconst
+	if info.Type == "generic" {
+		return "kv", "1", nil
+	}
 
-		// "options" is nil in "generic" type
-		//nolint: goconst
-		if eType == "generic" {
-			return "kv", "1", nil
-		}
+	return info.Type, info.Version, nil
+}
 
-		v, ok := data.Data["options"]
-		if ok {
-			//nolint: forcetypeassert
-			eVersion = v.(map[string]interface{})["version"].(string)
-		}
+// ReadEngineMaxVersions returns the number of versions a KVv2 engine keeps of every secret, 0 if it is not configured.
+func (v *Vault) ReadEngineMaxVersions(ctx context.Context, rootPath string) (int, error) {
+	config, err := v.Client.Logical().ReadWithContext(ctx, fmt.Sprintf(kvv2ConfigPath, rootPath))
+	if err != nil {
+		return 0, fmt.Errorf("could not read the configuration of engine \"%s\": %w", rootPath, err)
+	}
 
-		return eType, eVersion, nil
+	if config == nil {
+		return 0, nil
 	}
 
-	return "", "", fmt.Errorf("could not get engine type for path: \"%s\"", rootPath)
+	return parseVaultInt(config.Data["max_versions"]), nil
 }
 
 // EnableKV2Engine enables the kv2 engine at a specified path.
@@ -83,6 +86,8 @@ func (v *Vault) EnableKV2Engine(ctx context.Context, rootPath string) error {
 		return err
 	}
 
+	v.invalidateMountInfo(rootPath)
+
 	return nil
 }
 
@@ -101,6 +106,8 @@ func (v *Vault) EnableKV1Engine(ctx context.Context, rootPath string) error {
 		return err
 	}
 
+	v.invalidateMountInfo(rootPath)
+
 	return nil
 }
 
@@ -133,15 +140,11 @@ func (v *Vault) EnableKV2EngineErrorIfNotForced(ctx context.Context, force bool,
 
 // ListKVSecretEngines returns a list of all visible KV secret engines.
 func (v *Vault) ListKVSecretEngines(ctx context.Context, ns string) ([]string, error) {
-	v.Client.SetNamespace(ns)
-
-	data, err := v.Client.Logical().ReadWithContext(ctx, listSecretEngines)
+	data, err := v.Client.WithNamespace(ns).Logical().ReadWithContext(ctx, listSecretEngines)
 	if err != nil {
 		return nil, err
 	}
 
-	v.Client.ClearNamespace()
-
 	engineList := []string{}
 
 	if data != nil {
@@ -191,3 +194,46 @@ func (v *Vault) ListAllKVSecretEngines(ctx context.Context, ns string) (Engines,
 
 	return res, nil
 }
+
+// ListRecursiveAllEngines returns the secrets of all given engines recursively.
+// If withMetadata is set, the secrets metadata is read within the same traversal.
+func (v *Vault) ListRecursiveAllEngines(ctx context.Context, engines Engines, skipErrors, withMetadata bool) (AllEngineSecrets, error) {
+	res := AllEngineSecrets{}
+
+	namespaces := make([]string, 0, len(engines))
+	for ns := range engines {
+		namespaces = append(namespaces, ns)
+	}
+
+	sort.Strings(namespaces)
+
+	for _, ns := range namespaces {
+		enginePaths := append([]string{}, engines[ns]...)
+		sort.Strings(enginePaths)
+
+		for _, e := range enginePaths {
+			e = strings.TrimSuffix(e, utils.Delimiter)
+
+			es := &EngineSecrets{Namespace: ns, Engine: e}
+
+			var err error
+
+			es.Secrets, err = v.ListSecretTree(ctx, ns, e, "", skipErrors, withMetadata)
+			if err != nil && ns != "" {
+				return nil, fmt.Errorf("namespace \"%s\": %w", ns, err)
+			}
+
+			if err != nil {
+				return nil, err
+			}
+
+			if withMetadata {
+				es.Metadata = es.Secrets.SecretsMetadata()
+			}
+
+			res = append(res, es)
+		}
+	}
+
+	return res, nil
+}
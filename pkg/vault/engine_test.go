@@ -2,7 +2,9 @@ package vault
 
 import (
 	"context"
+	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -27,6 +29,23 @@ func (s *VaultSuite) TestGetEngineVersionType() {
 	})
 }
 
+func (s *VaultSuite) TestReadEngineMaxVersions() {
+	s.Run("max versions", func() {
+		require.NoError(s.T(), s.client.EnableKV2Engine(s.T().Context(), "max-versions"))
+
+		maxVersions, err := s.client.ReadEngineMaxVersions(s.T().Context(), "max-versions")
+		s.Require().NoError(err)
+		s.Require().Equal(0, maxVersions)
+
+		_, err = s.client.Client.Logical().Write("max-versions/config", map[string]interface{}{"max_versions": 3})
+		s.Require().NoError(err)
+
+		maxVersions, err = s.client.ReadEngineMaxVersions(s.T().Context(), "max-versions")
+		s.Require().NoError(err)
+		s.Require().Equal(3, maxVersions)
+	})
+}
+
 func (s *VaultSuite) TestEnableKV2EngineErrorIfNotForced() {
 	testCases := []struct {
 		name    string
@@ -105,3 +124,18 @@ func (s *VaultSuite) TestListAllKVSecretEngines() {
 		})
 	}
 }
+
+func TestListRecursiveAllEnginesEmptyEngine(t *testing.T) {
+	srv := newVersionsServer(t, map[string][]fakeVersion{})
+
+	v, err := NewClient(srv.URL, "token")
+	require.NoError(t, err)
+
+	for _, withMetadata := range []bool{false, true} {
+		res, err := v.ListRecursiveAllEngines(context.Background(), Engines{"": {"secret/"}}, false, withMetadata)
+		require.NoError(t, err)
+
+		require.Len(t, res, 1)
+		assert.Equal(t, NewSecretDirectory(""), res[0].Secrets)
+	}
+}
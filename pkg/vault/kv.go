@@ -7,6 +7,7 @@ import (
 	"log"
 	"path"
 	"strings"
+	"sync"
 
 	"github.com/FalcoSuessgott/vkv/pkg/utils"
 )
@@ -22,49 +23,134 @@ const (
 	mountDetailsPath = "sys/internal/ui/mounts/%s"
 )
 
+// errNoKeys is returned by ListKeys, if a path contains no keys.
+var errNoKeys = errors.New("no keys found")
+
 // Secrets holds all recursive secrets of a certain path.
 type Secrets map[string]interface{}
 
-// ListRecursive returns secrets to a path recursive.
+// ListRecursive returns secrets to a path recursive in the legacy nested map shape, in which directories end with a "/".
+// Use ListSecretTree to tell secrets and directories apart by their type.
+// Sub paths are traversed concurrently, with at most SetConcurrency requests in flight.
+// A non-empty namespace ns overrides the namespace of the client for this call.
+func (v *Vault) ListRecursive(ctx context.Context, ns, rootPath, subPath string, skipErrors bool) (*Secrets, error) {
+	t, err := v.WithNamespace(ns).listTree(ctx, newLimiter(v.workers()), rootPath, subPath, skipErrors, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.ToSecrets(), nil
+}
+
+// ListSecretTree returns the directories and secrets below a path as tree.
+// Sub paths are traversed concurrently, with at most SetConcurrency requests in flight.
+// If withMetadata is set, the metadata of every KVv2 secret is read during the same traversal.
+func (v *Vault) ListSecretTree(ctx context.Context, ns, rootPath, subPath string, skipErrors, withMetadata bool) (*SecretTree, error) {
+	v = v.WithNamespace(ns)
+
+	if withMetadata {
+		isV1, err := v.IsKVv1(ctx, rootPath)
+		if err != nil && !skipErrors {
+			return nil, err
+		}
+
+		withMetadata = err == nil && !isV1
+	}
+
+	return v.listTree(ctx, newLimiter(v.workers()), rootPath, subPath, skipErrors, withMetadata)
+}
+
 // nolint: cyclop
-func (v *Vault) ListRecursive(ctx context.Context, rootPath, subPath string, skipErrors bool) (*Secrets, error) {
-	s := make(Secrets)
+func (v *Vault) listTree(ctx context.Context, l limiter, rootPath, subPath string, skipErrors, withMetadata bool) (*SecretTree, error) {
+	var (
+		keys []string
+		err  error
+	)
+
+	l.do(func() { keys, err = v.ListKeys(ctx, rootPath, subPath) })
+
+	// the root of an empty engine cannot be a secret
+	if errors.Is(err, errNoKeys) && strings.Trim(subPath, utils.Delimiter) == "" {
+		return NewSecretDirectory(subPath), nil
+	}
 
-	keys, err := v.ListKeys(ctx, rootPath, subPath)
 	if err != nil {
 		// no sub directories in here, but lets check for normal kv pairs then..
-		secrets, err := v.ReadSecrets(ctx, rootPath, subPath)
+		var secrets map[string]interface{}
+
+		l.do(func() { secrets, err = v.readSecrets(ctx, rootPath, subPath) })
+
 		if !skipErrors && err != nil {
 			return nil, fmt.Errorf("could not read secrets from %s/%s: %w.\n\nYou can skip this error using --skip-errors", rootPath, subPath, err)
 		}
 
-		return (*Secrets)(&secrets), nil
+		secret := NewSecret(subPath, secrets)
+		secret.Err = err
+
+		if err == nil && withMetadata {
+			secret.Metadata = v.readOptionalMetadata(ctx, l, rootPath, subPath)
+		}
+
+		return secret, nil
 	}
 
-	for _, k := range keys {
-		if strings.HasSuffix(k, utils.Delimiter) {
-			secrets, err := v.ListRecursive(ctx, rootPath, path.Join(subPath, k), skipErrors)
-			if err != nil {
-				return &s, err
+	// every key is handled in its own goroutine, results and errors are
+	// collected by index so the outcome does not depend on scheduling
+	children := make([]*SecretTree, len(keys))
+	errs := make([]error, len(keys))
+
+	var wg sync.WaitGroup
+
+	for i, k := range keys {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if strings.HasSuffix(k, utils.Delimiter) {
+				children[i], errs[i] = v.listTree(ctx, l, rootPath, path.Join(subPath, k), skipErrors, withMetadata)
+
+				return
 			}
 
-			(s)[k] = secrets
-		} else {
-			secrets, err := v.ReadSecrets(ctx, rootPath, path.Join(subPath, k))
-			if !skipErrors && err != nil {
-				return nil, err
+			var secrets map[string]interface{}
+
+			l.do(func() { secrets, errs[i] = v.readSecrets(ctx, rootPath, path.Join(subPath, k)) })
+
+			secret := NewSecret(path.Join(subPath, k), secrets)
+
+			if errs[i] == nil && withMetadata {
+				secret.Metadata = v.readOptionalMetadata(ctx, l, rootPath, path.Join(subPath, k))
 			}
 
-			// do not exit on errors, just an empty map, so json/yaml export still works
-			if skipErrors && secrets == nil {
-				secrets = make(Secrets)
+			if skipErrors {
+				secret.Err, errs[i] = errs[i], nil
+
+				// do not exit on errors, just an empty map, so json/yaml export still works
+				if secret.Data == nil {
+					secret.Data = make(map[string]interface{})
+				}
 			}
 
-			(s)[k] = secrets
+			children[i] = secret
+		}()
+	}
+
+	wg.Wait()
+
+	dir := NewSecretDirectory(subPath)
+
+	for i := range keys {
+		if errs[i] != nil {
+			return nil, errs[i]
 		}
+
+		dir.Children = append(dir.Children, children[i])
 	}
 
-	return &s, nil
+	dir.sortChildren()
+
+	return dir, nil
 }
 
 // ListKeys returns all keys from vault kv secret path.
@@ -86,7 +172,7 @@ func (v *Vault) ListKeys(ctx context.Context, rootPath, subPath string) ([]strin
 	}
 
 	if data == nil {
-		return nil, fmt.Errorf("no keys found in \"%s\"", path.Join(rootPath, subPath))
+		return nil, fmt.Errorf("%w in \"%s\"", errNoKeys, path.Join(rootPath, subPath))
 	}
 
 	if data.Data != nil {
@@ -109,32 +195,21 @@ func (v *Vault) ListKeys(ctx context.Context, rootPath, subPath string) ([]strin
 
 // IsKVv1 returns true if the current path is a KVv1 Engine.
 func (v *Vault) IsKVv1(ctx context.Context, rootPath string) (bool, error) {
-	data, err := v.Client.Logical().ReadWithContext(ctx, fmt.Sprintf(mountDetailsPath, rootPath))
+	info, err := v.GetMountInfo(ctx, rootPath)
 	if err != nil {
 		return false, err
 	}
 
-	if data == nil {
-		return false, errors.New("cannot lookup mount type")
-	}
-
-	// early versions of Vaults KV engine are of type "generic"
-	if data.Data["type"] == "generic" {
-		return true, nil
-	}
-
-	if opts, ok := data.Data["options"].(map[string]interface{}); ok {
-		//nolint: forcetypeassert
-		if opts["version"].(string) == "1" {
-			return true, nil
-		}
-	}
-
-	return false, nil
+	return info.IsKVv1(), nil
 }
 
 // ReadSecrets returns a map with all secrets from a kv engine path.
-func (v *Vault) ReadSecrets(ctx context.Context, rootPath, subPath string) (map[string]interface{}, error) {
+// A non-empty namespace ns overrides the namespace of the client for this call.
+func (v *Vault) ReadSecrets(ctx context.Context, ns, rootPath, subPath string) (map[string]interface{}, error) {
+	return v.WithNamespace(ns).readSecrets(ctx, rootPath, subPath)
+}
+
+func (v *Vault) readSecrets(ctx context.Context, rootPath, subPath string) (map[string]interface{}, error) {
 	apiPath := fmt.Sprintf(kvv2ReadWriteSecretsPath, rootPath, subPath)
 
 	isV1, err := v.IsKVv1(ctx, rootPath)
@@ -169,7 +244,12 @@ func (v *Vault) ReadSecrets(ctx context.Context, rootPath, subPath string) (map[
 }
 
 // WriteSecrets writes kv secrets to a specified path.
-func (v *Vault) WriteSecrets(ctx context.Context, rootPath, subPath string, secrets map[string]interface{}) error {
+// A non-empty namespace ns overrides the namespace of the client for this call.
+func (v *Vault) WriteSecrets(ctx context.Context, ns, rootPath, subPath string, secrets map[string]interface{}) error {
+	return v.WithNamespace(ns).writeSecrets(ctx, rootPath, subPath, secrets)
+}
+
+func (v *Vault) writeSecrets(ctx context.Context, rootPath, subPath string, secrets map[string]interface{}) error {
 	apiPath := fmt.Sprintf(kvv2ReadWriteSecretsPath, rootPath, subPath)
 	options := map[string]interface{}{}
 
@@ -236,5 +316,7 @@ func (v *Vault) DisableKV2Engine(ctx context.Context, rootPath string) error {
 		return err
 	}
 
+	v.invalidateMountInfo(rootPath)
+
 	return nil
 }
@@ -78,13 +78,13 @@ func (s *VaultSuite) TestListRecursive() {
 
 			for k, secrets := range tc.secrets {
 				if m, ok := secrets.(map[string]interface{}); ok {
-					require.NoError(s.T(), s.client.WriteSecrets(context.Background(), tc.rootPath, path.Join(tc.subPath, k), m))
+					require.NoError(s.T(), s.client.WriteSecrets(context.Background(), "", tc.rootPath, path.Join(tc.subPath, k), m))
 				}
 			}
 
 			// read secrets
 			res := make(Secrets)
-			secrets, err := s.client.ListRecursive(context.Background(), tc.rootPath, tc.subPath, false)
+			secrets, err := s.client.ListRecursive(context.Background(), "", tc.rootPath, tc.subPath, false)
 			require.NoError(s.T(), err)
 
 			res[tc.rootPath] = *secrets
@@ -135,7 +135,7 @@ func (s *VaultSuite) TestReadSecretMetadataVersion() {
 
 			for k, v := range tc.secrets {
 				if m, ok := v.(map[string]interface{}); ok {
-					require.NoError(s.T(), s.client.WriteSecrets(context.Background(), tc.rootPath, path.Join(tc.subPath, k), m), tc.name)
+					require.NoError(s.T(), s.client.WriteSecrets(context.Background(), "", tc.rootPath, path.Join(tc.subPath, k), m), tc.name)
 				}
 			}
 
@@ -156,3 +156,28 @@ func (s *VaultSuite) TestReadSecretMetadataVersion() {
 		})
 	}
 }
+
+func (s *VaultSuite) TestListRecursiveConcurrency() {
+	s.Run("concurrent traversal matches sequential traversal", func() {
+		ctx := context.Background()
+		rootPath := "kvv2"
+
+		require.NoError(s.T(), s.client.EnableKV2Engine(ctx, rootPath))
+
+		for _, p := range []string{"a", "b/c", "b/d/e", "b/d/f", "g/h/i/j"} {
+			require.NoError(s.T(), s.client.WriteSecrets(ctx, "", rootPath, p, map[string]interface{}{"key": p}))
+		}
+
+		s.client.SetConcurrency(1)
+
+		sequential, err := s.client.ListRecursive(ctx, "", rootPath, "", false)
+		require.NoError(s.T(), err)
+
+		s.client.SetConcurrency(8)
+
+		concurrent, err := s.client.ListRecursive(ctx, "", rootPath, "", false)
+		require.NoError(s.T(), err)
+
+		assert.Equal(s.T(), sequential, concurrent)
+	})
+}
@@ -2,7 +2,9 @@ package vault
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
 	"os"
 	"strconv"
 	"time"
@@ -17,10 +19,19 @@ const (
 	envVarRefresherEnabled = "VKV_LEASE_REFRESHER_ENABLED"
 )
 
+// errNoLogin is returned if a token cannot be renewed and there is no login method to obtain a new one.
+var errNoLogin = errors.New("no login method configured (VKV_LOGIN_COMMAND or VKV_AUTH_METHOD), cannot re-authenticate")
+
+// tokenInfo holds the lease details of the current token.
+type tokenInfo struct {
+	ttl         int
+	creationTTL int
+	renewable   bool
+}
+
 // LeaseRefresher periodically checks the ttl of the current lease and attempts to renew it if the ttl is less than half of the creation ttl.
-// if the token renewal fails, a new login with the configured auth method is performed
+// if the token renewal fails, the token is not renewable or its max ttl is reached, a new login with the configured login method is performed
 // this func is supposed to run as a goroutine.
-// nolint: funlen, gocognit, cyclop
 func (v *Vault) LeaseRefresher(ctx context.Context) {
 	if _, ok := os.LookupEnv(envVarRefresherEnabled); ok {
 		return
@@ -48,40 +59,85 @@ func (v *Vault) LeaseRefresher(ctx context.Context) {
 	for {
 		select {
 		case <-ticker.C:
-			token, err := v.Client.Auth().Token().LookupSelfWithContext(ctx)
-			if err != nil {
-				continue
+			if err := v.refreshToken(ctx, renewalIncrement); err != nil {
+				slog.Warn("token refresh failed", slog.String("err", err.Error()))
 			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
 
-			creationTTL, ok := token.Data["creation_ttl"].(json.Number)
-			if !ok {
-				continue
-			}
+// refreshToken renews the current token if less than half of its creation ttl is left.
+// If the token cannot be renewed (any longer), a new login is performed.
+func (v *Vault) refreshToken(ctx context.Context, increment int) error {
+	info, err := v.lookupToken(ctx)
+	if err != nil {
+		return v.relogin(ctx, fmt.Sprintf("token lookup failed: %v", err))
+	}
 
-			ttl, ok := token.Data["ttl"].(json.Number)
-			if !ok {
-				continue
-			}
+	// tokens without ttl (e.g. root tokens) never expire
+	if info.creationTTL == 0 || info.ttl >= info.creationTTL/2 {
+		return nil
+	}
 
-			creationTTLFloat, err := creationTTL.Float64()
-			if err != nil {
-				continue
-			}
+	if !info.renewable {
+		return v.relogin(ctx, "token is not renewable")
+	}
 
-			ttlFloat, err := ttl.Float64()
-			if err != nil {
-				continue
-			}
+	secret, err := v.Client.Auth().Token().RenewSelfWithContext(ctx, increment)
+	if err != nil {
+		return v.relogin(ctx, fmt.Sprintf("token renewal failed: %v", err))
+	}
 
-			//nolint: nestif
-			if ttlFloat < creationTTLFloat/2 {
-				if _, err := v.Client.Auth().Token().RenewSelfWithContext(ctx, renewalIncrement); err != nil {
-					continue
-				}
-			}
+	// the renewal is capped by the tokens max ttl, the token will expire soon no matter what
+	if secret != nil && secret.Auth != nil && secret.Auth.LeaseDuration < increment {
+		return v.relogin(ctx, "token max ttl reached")
+	}
 
-		case <-ctx.Done():
-			return
-		}
+	slog.Debug("token renewed", slog.Int("increment", increment))
+
+	return nil
+}
+
+// relogin performs a new login and swaps the token of the client.
+func (v *Vault) relogin(ctx context.Context, reason string) error {
+	if v.login == nil {
+		return fmt.Errorf("%s: %w", reason, errNoLogin)
+	}
+
+	token, err := v.login(ctx, v.Client.Address())
+	if err != nil {
+		return fmt.Errorf("%s: re-authentication failed: %w", reason, err)
 	}
+
+	// the client guards its token, so it is safe to swap while requests are in flight
+	v.Client.SetToken(token)
+
+	slog.Info("re-authenticated", slog.String("reason", reason))
+
+	return nil
+}
+
+// lookupToken returns the lease details of the current token.
+func (v *Vault) lookupToken(ctx context.Context) (*tokenInfo, error) {
+	token, err := v.Client.Auth().Token().LookupSelfWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if token == nil || token.Data == nil {
+		return nil, errors.New("empty token lookup response")
+	}
+
+	info := &tokenInfo{
+		ttl:         parseVaultInt(token.Data["ttl"]),
+		creationTTL: parseVaultInt(token.Data["creation_ttl"]),
+	}
+
+	if renewable, ok := token.Data["renewable"].(bool); ok {
+		info.renewable = renewable
+	}
+
+	return info, nil
 }
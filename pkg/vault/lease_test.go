@@ -0,0 +1,151 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTokenServer returns a fake Vault server serving the token lookup and renewal endpoints.
+func newTokenServer(t *testing.T, ttl, creationTTL int, renewable bool, renewedTTL int, renewals *int) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/auth/token/lookup-self", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"data":{"ttl":%d,"creation_ttl":%d,"renewable":%t}}`, ttl, creationTTL, renewable)
+	})
+	mux.HandleFunc("/v1/auth/token/renew-self", func(w http.ResponseWriter, r *http.Request) {
+		*renewals++
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"auth":{"client_token":"token","lease_duration":%d,"renewable":true}}`, renewedTTL)
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestRefreshToken(t *testing.T) {
+	testCases := []struct {
+		name        string
+		ttl         int
+		creationTTL int
+		renewable   bool
+		renewedTTL  int
+		login       loginFunc
+		renewals    int
+		token       string
+		err         bool
+	}{
+		{
+			name:        "ttl left, nothing to do",
+			ttl:         50,
+			creationTTL: 60,
+			renewable:   true,
+			token:       "token",
+		},
+		{
+			name:        "root token without ttl",
+			token:       "token",
+			creationTTL: 0,
+		},
+		{
+			name:        "renewable token is renewed",
+			ttl:         10,
+			creationTTL: 60,
+			renewable:   true,
+			renewedTTL:  30,
+			renewals:    1,
+			token:       "token",
+		},
+		{
+			name:        "non renewable token triggers login",
+			ttl:         10,
+			creationTTL: 60,
+			login:       func(context.Context, string) (string, error) { return "new-token", nil },
+			token:       "new-token",
+		},
+		{
+			name:        "max ttl reached triggers login",
+			ttl:         10,
+			creationTTL: 60,
+			renewable:   true,
+			renewedTTL:  5,
+			renewals:    1,
+			login:       func(context.Context, string) (string, error) { return "new-token", nil },
+			token:       "new-token",
+		},
+		{
+			name:        "non renewable token without login",
+			ttl:         10,
+			creationTTL: 60,
+			token:       "token",
+			err:         true,
+		},
+		{
+			name:        "failing login keeps token",
+			ttl:         10,
+			creationTTL: 60,
+			login:       func(context.Context, string) (string, error) { return "", errors.New("login failed") },
+			token:       "token",
+			err:         true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			renewals := 0
+
+			srv := newTokenServer(t, tc.ttl, tc.creationTTL, tc.renewable, tc.renewedTTL, &renewals)
+
+			v, err := NewClient(srv.URL, "token")
+			require.NoError(t, err)
+
+			v.login = tc.login
+
+			err = v.refreshToken(context.Background(), 30)
+			if tc.err {
+				require.Error(t, err, tc.name)
+			} else {
+				require.NoError(t, err, tc.name)
+			}
+
+			assert.Equal(t, tc.renewals, renewals, tc.name)
+			assert.Equal(t, tc.token, v.Client.Token(), tc.name)
+		})
+	}
+}
+
+func TestRefreshTokenClone(t *testing.T) {
+	renewals := 0
+
+	srv := newTokenServer(t, 10, 60, false, 0, &renewals)
+
+	v, err := NewClient("http://127.0.0.1:8200", "token")
+	require.NoError(t, err)
+
+	addrs := []string{}
+	v.login = func(_ context.Context, addr string) (string, error) {
+		addrs = append(addrs, addr)
+
+		return "new-token", nil
+	}
+
+	clone, err := v.Clone(srv.URL, "")
+	require.NoError(t, err)
+
+	// the clone logs in at its own address, the token of the original client is kept
+	require.NoError(t, clone.refreshToken(context.Background(), 30))
+	assert.Equal(t, []string{srv.URL}, addrs)
+	assert.Equal(t, "new-token", clone.Client.Token())
+	assert.Equal(t, "token", v.Client.Token())
+}
@@ -0,0 +1,361 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/FalcoSuessgott/vkv/pkg/utils"
+)
+
+// errMetadataNotFound is returned by ReadMetadata, if a secret does not exist.
+var errMetadataNotFound = errors.New("not found")
+
+// SecretMetadata holds the metadata of a KVv2 secret as returned by its metadata endpoint.
+type SecretMetadata struct {
+	CurrentVersion     int                    `json:"current_version"`
+	OldestVersion      int                    `json:"oldest_version"`
+	CreatedTime        time.Time              `json:"created_time"`
+	UpdatedTime        time.Time              `json:"updated_time"`
+	CustomMetadata     map[string]interface{} `json:"custom_metadata,omitempty"`
+	MaxVersions        int                    `json:"max_versions"`
+	CASRequired        bool                   `json:"cas_required"`
+	DeleteVersionAfter string                 `json:"delete_version_after"`
+	// Versions holds the state of every version, ordered newest first. Their Data is always nil.
+	Versions []*SecretVersion `json:"versions"`
+}
+
+// SecretsMetadata maps a secret subPath to its metadata.
+type SecretsMetadata map[string]*SecretMetadata
+
+// CurrentVersionCreatedTime returns the creation time of the current version,
+// falling back to the time the secret was last updated.
+func (m *SecretMetadata) CurrentVersionCreatedTime() time.Time {
+	for _, sv := range m.Versions {
+		if sv.Version == m.CurrentVersion && !sv.CreatedTime.IsZero() {
+			return sv.CreatedTime
+		}
+	}
+
+	return m.UpdatedTime
+}
+
+// Current returns the state of the current version, nil if unknown.
+func (m *SecretMetadata) Current() *SecretVersion {
+	for _, sv := range m.Versions {
+		if sv.Version == m.CurrentVersion {
+			return sv
+		}
+	}
+
+	return nil
+}
+
+// ReadMetadata reads the metadata of a KVv2 secret with a single request.
+// nolint: cyclop
+func (v *Vault) ReadMetadata(ctx context.Context, rootPath, subPath string) (*SecretMetadata, error) {
+	data, err := v.Client.Logical().ReadWithContext(ctx, fmt.Sprintf(kvv2ListSecretsPath, rootPath, subPath))
+	if err != nil {
+		return nil, err
+	}
+
+	if data == nil || data.Data == nil {
+		return nil, fmt.Errorf("could not read secret %s metadata: %w", path.Join(rootPath, subPath), errMetadataNotFound)
+	}
+
+	md := &SecretMetadata{
+		CurrentVersion: parseVaultInt(data.Data["current_version"]),
+		OldestVersion:  parseVaultInt(data.Data["oldest_version"]),
+		CreatedTime:    parseVaultTime(data.Data["created_time"]),
+		UpdatedTime:    parseVaultTime(data.Data["updated_time"]),
+		MaxVersions:    parseVaultInt(data.Data["max_versions"]),
+	}
+
+	if cm, ok := data.Data["custom_metadata"].(map[string]interface{}); ok {
+		md.CustomMetadata = cm
+	}
+
+	if cas, ok := data.Data["cas_required"].(bool); ok {
+		md.CASRequired = cas
+	}
+
+	if d, ok := data.Data["delete_version_after"].(string); ok {
+		md.DeleteVersionAfter = d
+	}
+
+	versions, ok := data.Data["versions"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("could not read versions of secret %s", path.Join(rootPath, subPath))
+	}
+
+	md.Versions = make([]*SecretVersion, 0, len(versions))
+
+	for vStr, meta := range versions {
+		versionNr, err := strconv.Atoi(vStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version %q for secret %s: %w", vStr, path.Join(rootPath, subPath), err)
+		}
+
+		m, ok := meta.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		sv := &SecretVersion{
+			Version:     versionNr,
+			CreatedTime: parseVaultTime(m["created_time"]),
+		}
+
+		if dt := parseVaultTime(m["deletion_time"]); !dt.IsZero() {
+			sv.DeletionTime = &dt
+		}
+
+		if destroyed, ok := m["destroyed"].(bool); ok {
+			sv.Destroyed = destroyed
+		}
+
+		md.Versions = append(md.Versions, sv)
+	}
+
+	// newest version first
+	sort.Slice(md.Versions, func(i, j int) bool {
+		return md.Versions[i].Version > md.Versions[j].Version
+	})
+
+	return md, nil
+}
+
+// MetadataKey is the reserved key of exports and snapshots holding the metadata of their secrets.
+const MetadataKey = "__metadata__"
+
+// SecretSettings holds the writable metadata of a KVv2 secret.
+type SecretSettings struct {
+	CustomMetadata     map[string]interface{} `json:"custom_metadata,omitempty"`
+	MaxVersions        int                    `json:"max_versions,omitempty"`
+	CASRequired        bool                   `json:"cas_required,omitempty"`
+	DeleteVersionAfter string                 `json:"delete_version_after,omitempty"`
+}
+
+// Settings returns the writable metadata of the secret, nil if all of them have their default value.
+func (m *SecretMetadata) Settings() *SecretSettings {
+	s := &SecretSettings{
+		CustomMetadata:     m.CustomMetadata,
+		MaxVersions:        m.MaxVersions,
+		CASRequired:        m.CASRequired,
+		DeleteVersionAfter: m.DeleteVersionAfter,
+	}
+
+	if s.IsEmpty() {
+		return nil
+	}
+
+	if isZeroDuration(s.DeleteVersionAfter) {
+		s.DeleteVersionAfter = ""
+	}
+
+	return s
+}
+
+// IsEmpty reports whether all settings have their default value.
+func (s *SecretSettings) IsEmpty() bool {
+	return s == nil || (len(s.CustomMetadata) == 0 && s.MaxVersions == 0 && !s.CASRequired && isZeroDuration(s.DeleteVersionAfter))
+}
+
+// Equal reports whether both settings are the same, treating nil as the default settings.
+func (s *SecretSettings) Equal(other *SecretSettings) bool {
+	if s.IsEmpty() || other.IsEmpty() {
+		return s.IsEmpty() && other.IsEmpty()
+	}
+
+	return s.MaxVersions == other.MaxVersions &&
+		s.CASRequired == other.CASRequired &&
+		normalizeDuration(s.DeleteVersionAfter) == normalizeDuration(other.DeleteVersionAfter) &&
+		((len(s.CustomMetadata) == 0 && len(other.CustomMetadata) == 0) || reflect.DeepEqual(s.CustomMetadata, other.CustomMetadata))
+}
+
+// isZeroDuration reports whether a Vault duration string disables the setting.
+func isZeroDuration(d string) bool {
+	return d == "" || d == "0" || d == "0s"
+}
+
+// normalizeDuration returns "0s" for all durations disabling the setting.
+func normalizeDuration(d string) string {
+	if isZeroDuration(d) {
+		return "0s"
+	}
+
+	return d
+}
+
+// WriteSecretSettings replaces the writable metadata of a KVv2 secret. Nil settings reset them to their defaults.
+// A non-empty namespace ns overrides the namespace of the client for this call.
+func (v *Vault) WriteSecretSettings(ctx context.Context, ns, rootPath, subPath string, settings *SecretSettings) error {
+	v = v.WithNamespace(ns)
+
+	isV1, err := v.IsKVv1(ctx, rootPath)
+	if err != nil {
+		return err
+	}
+
+	if isV1 {
+		return fmt.Errorf("%w: cannot write the metadata of secret %s/%s", ErrKVv1NotSupported, rootPath, subPath)
+	}
+
+	if settings == nil {
+		settings = &SecretSettings{}
+	}
+
+	customMetadata := settings.CustomMetadata
+	if customMetadata == nil {
+		customMetadata = map[string]interface{}{}
+	}
+
+	_, err = v.Client.Logical().WriteWithContext(ctx, fmt.Sprintf(kvv2ListSecretsPath, rootPath, subPath), map[string]interface{}{
+		"custom_metadata":      customMetadata,
+		"max_versions":         settings.MaxVersions,
+		"cas_required":         settings.CASRequired,
+		"delete_version_after": normalizeDuration(settings.DeleteVersionAfter),
+	})
+
+	return err
+}
+
+// ParseSecretSettings returns the settings of the reserved metadata key of an export or snapshot
+// keyed by the secret paths.
+func ParseSecretSettings(m interface{}) (map[string]*SecretSettings, error) {
+	out, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := make(map[string]*SecretSettings)
+	if err := json.Unmarshal(out, &settings); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", MetadataKey, err)
+	}
+
+	return settings, nil
+}
+
+// SettingsOf returns the non-default settings of the secrets metadata keyed by the secret paths.
+func (m SecretsMetadata) SettingsOf() map[string]*SecretSettings {
+	res := make(map[string]*SecretSettings)
+
+	for p, md := range m {
+		if s := md.Settings(); s != nil {
+			res[p] = s
+		}
+	}
+
+	return res
+}
+
+// WriteCustomMetadata replaces the custom metadata of a KVv2 secret.
+func (v *Vault) WriteCustomMetadata(ctx context.Context, rootPath, subPath string, customMetadata map[string]interface{}) error {
+	isV1, err := v.IsKVv1(ctx, rootPath)
+	if err != nil {
+		return err
+	}
+
+	if isV1 {
+		return fmt.Errorf("%w: cannot write the custom metadata of secret %s/%s", ErrKVv1NotSupported, rootPath, subPath)
+	}
+
+	_, err = v.Client.Logical().WriteWithContext(ctx, fmt.Sprintf(kvv2ListSecretsPath, rootPath, subPath), map[string]interface{}{
+		"custom_metadata": customMetadata,
+	})
+
+	return err
+}
+
+// ListRecursiveMetadata recursively reads the metadata of every KVv2 secret under subPath. Unlike ListRecursive the data
+// of the secrets is not read, so that secrets whose current version has been deleted or destroyed are listed as well.
+// A subPath that does not exist contains no secrets.
+func (v *Vault) ListRecursiveMetadata(ctx context.Context, rootPath, subPath string, skipErrors bool) (SecretsMetadata, error) {
+	isV1, err := v.IsKVv1(ctx, rootPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if isV1 {
+		return nil, fmt.Errorf("%w: %q is a KVv1 engine", ErrKVv1NotSupported, rootPath)
+	}
+
+	var mu sync.Mutex
+
+	res := make(SecretsMetadata)
+
+	read := func(ctx context.Context, rootPath, p string) (*VersionedSecret, error) {
+		md, err := v.ReadMetadata(ctx, rootPath, p)
+		if errors.Is(err, errMetadataNotFound) && strings.Trim(p, utils.Delimiter) == strings.Trim(subPath, utils.Delimiter) {
+			return nil, nil //nolint: nilnil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		res[strings.Trim(p, utils.Delimiter)] = md
+
+		return nil, nil //nolint: nilnil
+	}
+
+	acc := &versionedSecretsAccumulator{secrets: make(VersionedSecrets)}
+	if err := v.listRecursiveAllVersions(ctx, newLimiter(v.workers()), rootPath, subPath, skipErrors, read, acc); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// readOptionalMetadata reads the metadata of a secret, errors are ignored since metadata is optional.
+func (v *Vault) readOptionalMetadata(ctx context.Context, l limiter, rootPath, subPath string) *SecretMetadata {
+	var (
+		md  *SecretMetadata
+		err error
+	)
+
+	l.do(func() { md, err = v.ReadMetadata(ctx, rootPath, subPath) })
+
+	if err != nil {
+		return nil
+	}
+
+	return md
+}
+
+// parseVaultInt parses a Vault number, returning 0 on empty/invalid input.
+func parseVaultInt(v interface{}) int {
+	switch n := v.(type) {
+	case json.Number:
+		i, err := n.Int64()
+		if err != nil {
+			return 0
+		}
+
+		return int(i)
+	case float64:
+		return int(n)
+	case int:
+		return n
+	case string:
+		i, err := strconv.Atoi(n)
+		if err != nil {
+			return 0
+		}
+
+		return i
+	default:
+		return 0
+	}
+}
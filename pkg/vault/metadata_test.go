@@ -0,0 +1,179 @@
+package vault
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *VaultSuite) TestReadMetadata() {
+	s.Run("read metadata", func() {
+		ctx := context.Background()
+		rootPath := "kvv2"
+
+		require.NoError(s.T(), s.client.EnableKV2Engine(ctx, rootPath))
+
+		require.NoError(s.T(), s.client.WriteSecrets(ctx, "", rootPath, "admin", map[string]interface{}{"user": "v1"}))
+		require.NoError(s.T(), s.client.WriteSecrets(ctx, "", rootPath, "admin", map[string]interface{}{"user": "v2"}))
+
+		_, err := s.client.Client.Logical().WriteWithContext(ctx, rootPath+"/metadata/admin", map[string]interface{}{
+			"custom_metadata": map[string]interface{}{"owner": "team-a"},
+			"max_versions":    5,
+		})
+		require.NoError(s.T(), err)
+
+		md, err := s.client.ReadMetadata(ctx, rootPath, "admin")
+		require.NoError(s.T(), err)
+
+		assert.Equal(s.T(), 2, md.CurrentVersion)
+		assert.Equal(s.T(), 5, md.MaxVersions)
+		assert.Equal(s.T(), map[string]interface{}{"owner": "team-a"}, md.CustomMetadata)
+		require.Len(s.T(), md.Versions, 2)
+		assert.Equal(s.T(), 2, md.Versions[0].Version)
+		assert.Equal(s.T(), md.Versions[0].CreatedTime, md.CurrentVersionCreatedTime())
+	})
+}
+
+func (s *VaultSuite) TestListSecretTreeWithMetadata() {
+	s.Run("list secret tree with metadata", func() {
+		ctx := context.Background()
+		rootPath := "kvv2"
+
+		require.NoError(s.T(), s.client.EnableKV2Engine(ctx, rootPath))
+
+		require.NoError(s.T(), s.client.WriteSecrets(ctx, "", rootPath, "admin", map[string]interface{}{"user": "v1"}))
+		require.NoError(s.T(), s.client.WriteSecrets(ctx, "", rootPath, "sub/demo", map[string]interface{}{"foo": "bar"}))
+
+		tree, err := s.client.ListSecretTree(ctx, "", rootPath, "", false, true)
+		require.NoError(s.T(), err)
+
+		expected, err := s.client.ListRecursive(ctx, "", rootPath, "", false)
+		require.NoError(s.T(), err)
+
+		metadata := tree.SecretsMetadata()
+
+		assert.Equal(s.T(), expected, tree.ToSecrets())
+		require.Contains(s.T(), metadata, "admin")
+		require.Contains(s.T(), metadata, "sub/demo")
+		assert.Equal(s.T(), 1, metadata["sub/demo"].CurrentVersion)
+	})
+}
+
+func (s *VaultSuite) TestListSecretTreeWithMetadataKVv1() {
+	s.Run("list secret tree with metadata on a KVv1 engine", func() {
+		ctx := context.Background()
+		rootPath := "kvv1"
+
+		require.NoError(s.T(), s.client.EnableKV1Engine(ctx, rootPath))
+		require.NoError(s.T(), s.client.WriteSecrets(ctx, "", rootPath, "admin", map[string]interface{}{"user": "v1"}))
+
+		tree, err := s.client.ListSecretTree(ctx, "", rootPath, "", false, true)
+		require.NoError(s.T(), err)
+		assert.Empty(s.T(), tree.SecretsMetadata())
+	})
+}
+
+func (s *VaultSuite) TestWriteCustomMetadata() {
+	s.Run("write custom metadata", func() {
+		ctx := context.Background()
+		rootPath := "kvv2"
+
+		require.NoError(s.T(), s.client.EnableKV2Engine(ctx, rootPath))
+		require.NoError(s.T(), s.client.WriteSecrets(ctx, "", rootPath, "admin", map[string]interface{}{"user": "v1"}))
+
+		require.NoError(s.T(), s.client.WriteCustomMetadata(ctx, rootPath, "admin", map[string]interface{}{"owner": "team-a"}))
+
+		md, err := s.client.ReadMetadata(ctx, rootPath, "admin")
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), map[string]interface{}{"owner": "team-a"}, md.CustomMetadata)
+	})
+}
+
+func (s *VaultSuite) TestWriteSecretSettings() {
+	s.Run("write settings", func() {
+		ctx := context.Background()
+		rootPath := "settings"
+
+		require.NoError(s.T(), s.client.EnableKV2Engine(ctx, rootPath))
+		require.NoError(s.T(), s.client.WriteSecrets(ctx, "", rootPath, "admin", map[string]interface{}{"user": "v1"}))
+
+		settings := &SecretSettings{
+			CustomMetadata:     map[string]interface{}{"owner": "team-a"},
+			MaxVersions:        5,
+			CASRequired:        true,
+			DeleteVersionAfter: "768h0m0s",
+		}
+
+		require.NoError(s.T(), s.client.WriteSecretSettings(ctx, "", rootPath, "admin", settings))
+
+		md, err := s.client.ReadMetadata(ctx, rootPath, "admin")
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), settings, md.Settings())
+
+		// nil resets the settings
+		require.NoError(s.T(), s.client.WriteSecretSettings(ctx, "", rootPath, "admin", nil))
+
+		md, err = s.client.ReadMetadata(ctx, rootPath, "admin")
+		require.NoError(s.T(), err)
+		assert.Nil(s.T(), md.Settings())
+	})
+}
+
+func TestSecretSettingsEqual(t *testing.T) {
+	testCases := []struct {
+		name     string
+		a, b     *SecretSettings
+		expected bool
+	}{
+		{
+			name:     "nil and defaults",
+			a:        nil,
+			b:        &SecretSettings{DeleteVersionAfter: "0s", CustomMetadata: map[string]interface{}{}},
+			expected: true,
+		},
+		{
+			name:     "same settings",
+			a:        &SecretSettings{MaxVersions: 3, CustomMetadata: map[string]interface{}{"owner": "team-a"}},
+			b:        &SecretSettings{MaxVersions: 3, CustomMetadata: map[string]interface{}{"owner": "team-a"}},
+			expected: true,
+		},
+		{
+			name: "different custom metadata",
+			a:    &SecretSettings{CustomMetadata: map[string]interface{}{"owner": "team-a"}},
+			b:    &SecretSettings{CustomMetadata: map[string]interface{}{"owner": "team-b"}},
+		},
+		{
+			name: "different duration",
+			a:    &SecretSettings{DeleteVersionAfter: "1h0m0s"},
+			b:    &SecretSettings{DeleteVersionAfter: "0s"},
+		},
+		{
+			name: "nil and cas required",
+			a:    nil,
+			b:    &SecretSettings{CASRequired: true},
+		},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, tc.a.Equal(tc.b), tc.name)
+		assert.Equal(t, tc.expected, tc.b.Equal(tc.a), tc.name)
+	}
+}
+
+func TestParseSecretSettings(t *testing.T) {
+	settings, err := ParseSecretSettings(map[string]interface{}{
+		"admin": map[string]interface{}{
+			"custom_metadata": map[string]interface{}{"owner": "team-a"},
+			"max_versions":    float64(5),
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]*SecretSettings{
+		"admin": {CustomMetadata: map[string]interface{}{"owner": "team-a"}, MaxVersions: 5},
+	}, settings)
+
+	_, err = ParseSecretSettings(map[string]interface{}{"admin": "invalid"})
+	require.Error(t, err)
+}
@@ -0,0 +1,153 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/FalcoSuessgott/vkv/pkg/utils"
+)
+
+// MountInfo holds the details of a secret engine mount.
+type MountInfo struct {
+	Path        string
+	Type        string
+	Version     string
+	Description string
+	Options     map[string]interface{}
+	Namespace   string
+}
+
+// IsKVv1 returns true if the mount is a KVv1 engine.
+func (m *MountInfo) IsKVv1() bool {
+	// early versions of Vaults KV engine are of type "generic"
+	return m.Type == "generic" || m.Version == "1"
+}
+
+// mountCacheMu guards the lazy creation of the mount caches, so that clients created as literal,
+// e.g. vault.Vault{Client: c}, work as well.
+var mountCacheMu sync.Mutex
+
+// mountCache caches the mount details per namespace and engine path.
+// Only successful lookups are cached, concurrent lookups of the same mount wait for the first one.
+type mountCache struct {
+	mu      sync.Mutex
+	entries map[string]*mountEntry
+}
+
+// mountCache returns the mount cache of the client, creating it on first use.
+func (v *Vault) mountCache() *mountCache {
+	mountCacheMu.Lock()
+	defer mountCacheMu.Unlock()
+
+	if v.mounts == nil {
+		v.mounts = &mountCache{}
+	}
+
+	return v.mounts
+}
+
+type mountEntry struct {
+	ready chan struct{}
+	info  *MountInfo
+	err   error
+}
+
+// GetMountInfo returns the details of the mount at rootPath.
+// The result is cached per client, so every engine is only looked up once.
+func (v *Vault) GetMountInfo(ctx context.Context, rootPath string) (*MountInfo, error) {
+	ns := v.Client.Namespace()
+	key := mountCacheKey(ns, rootPath)
+	mounts := v.mountCache()
+
+	mounts.mu.Lock()
+
+	if mounts.entries == nil {
+		mounts.entries = make(map[string]*mountEntry)
+	}
+
+	if e, ok := mounts.entries[key]; ok {
+		mounts.mu.Unlock()
+
+		select {
+		case <-e.ready:
+			return e.info, e.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	e := &mountEntry{ready: make(chan struct{})}
+	mounts.entries[key] = e
+
+	mounts.mu.Unlock()
+
+	e.info, e.err = v.readMountInfo(ctx, ns, rootPath)
+
+	// failed lookups are not cached, e.g. the engine might be enabled later on
+	if e.err != nil {
+		mounts.mu.Lock()
+		delete(mounts.entries, key)
+		mounts.mu.Unlock()
+	}
+
+	close(e.ready)
+
+	return e.info, e.err
+}
+
+// invalidateMountInfo removes the cached details of the mount at rootPath.
+func (v *Vault) invalidateMountInfo(rootPath string) {
+	mounts := v.mountCache()
+
+	mounts.mu.Lock()
+	defer mounts.mu.Unlock()
+
+	delete(mounts.entries, mountCacheKey(v.Client.Namespace(), rootPath))
+}
+
+func (v *Vault) readMountInfo(ctx context.Context, ns, rootPath string) (*MountInfo, error) {
+	data, err := v.Client.Logical().ReadWithContext(ctx, fmt.Sprintf(mountDetailsPath, rootPath))
+	if err != nil {
+		return nil, err
+	}
+
+	if data == nil || data.Data == nil {
+		return nil, errors.New("cannot lookup mount type")
+	}
+
+	info := &MountInfo{
+		Path:      utils.NormalizePath(rootPath),
+		Namespace: ns,
+	}
+
+	if p, ok := data.Data["path"].(string); ok && p != "" {
+		info.Path = p
+	}
+
+	if t, ok := data.Data["type"].(string); ok {
+		info.Type = t
+	}
+
+	if desc, ok := data.Data["description"].(string); ok {
+		info.Description = desc
+	}
+
+	// "options" is nil in "generic" type
+	if opts, ok := data.Data["options"].(map[string]interface{}); ok {
+		info.Options = opts
+
+		if version, ok := opts["version"].(string); ok {
+			info.Version = version
+		}
+	}
+
+	return info, nil
+}
+
+func mountCacheKey(ns, rootPath string) string {
+	return path.Join(strings.Trim(ns, utils.Delimiter), strings.Trim(rootPath, utils.Delimiter))
+}
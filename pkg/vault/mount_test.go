@@ -0,0 +1,128 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newMountServer returns a fake Vault server that serves the mount details of a single KV engine
+// and counts the number of mount lookups.
+func newMountServer(t *testing.T, version string, lookups *atomic.Int32) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/sys/internal/ui/mounts/secret", func(w http.ResponseWriter, r *http.Request) {
+		lookups.Add(1)
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"data":{"path":"secret/","type":"kv","description":"key/value secret storage","options":{"version":%q}}}`, version)
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestGetMountInfo(t *testing.T) {
+	var lookups atomic.Int32
+
+	srv := newMountServer(t, "2", &lookups)
+
+	v, err := NewClient(srv.URL, "token")
+	require.NoError(t, err)
+
+	info, err := v.GetMountInfo(context.Background(), "secret")
+	require.NoError(t, err)
+
+	assert.Equal(t, &MountInfo{
+		Path:        "secret/",
+		Type:        "kv",
+		Version:     "2",
+		Description: "key/value secret storage",
+		Options:     map[string]interface{}{"version": "2"},
+	}, info)
+
+	// cached lookups, including the ones done by the other vault methods
+	var wg sync.WaitGroup
+
+	for range 20 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			isV1, err := v.IsKVv1(context.Background(), "secret/")
+			assert.NoError(t, err)
+			assert.False(t, isV1)
+		}()
+	}
+
+	wg.Wait()
+
+	desc, err := v.GetEngineDescription(context.Background(), "secret")
+	require.NoError(t, err)
+	assert.Equal(t, "key/value secret storage", desc)
+
+	engineType, version, err := v.GetEngineTypeVersion(context.Background(), "secret")
+	require.NoError(t, err)
+	assert.Equal(t, "kv", engineType)
+	assert.Equal(t, "2", version)
+
+	assert.Equal(t, int32(1), lookups.Load(), "mount should only be looked up once")
+
+	// invalidated entries are looked up again
+	v.invalidateMountInfo("secret")
+
+	_, err = v.GetMountInfo(context.Background(), "secret")
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(2), lookups.Load())
+}
+
+func TestGetMountInfoErrorNotCached(t *testing.T) {
+	var lookups atomic.Int32
+
+	srv := newMountServer(t, "1", &lookups)
+
+	v, err := NewClient(srv.URL, "token")
+	require.NoError(t, err)
+
+	for range 2 {
+		_, err := v.GetMountInfo(context.Background(), "unknown")
+		require.Error(t, err)
+	}
+
+	isV1, err := v.IsKVv1(context.Background(), "secret")
+	require.NoError(t, err)
+	assert.True(t, isV1)
+}
+
+func TestGetMountInfoClientLiteral(t *testing.T) {
+	var lookups atomic.Int32
+
+	srv := newMountServer(t, "1", &lookups)
+
+	c, err := api.NewClient(&api.Config{Address: srv.URL})
+	require.NoError(t, err)
+
+	v := &Vault{Client: c}
+
+	for range 2 {
+		isV1, err := v.IsKVv1(context.Background(), "secret")
+		require.NoError(t, err)
+		assert.True(t, isV1)
+	}
+
+	assert.Equal(t, int32(1), lookups.Load(), "mount should only be looked up once")
+	assert.Same(t, v.mounts, v.WithNamespace("team").mounts, "mount cache is shared")
+}
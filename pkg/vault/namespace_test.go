@@ -1,6 +1,94 @@
 package vault
 
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
 func (s *VaultSuite) TestNamespaces() {
 	// have no longer a vault enterprise licence :(
 	s.Suite.T().Skip()
 }
+
+// newNamespaceServer returns a fake Vault server that fails every request
+// and records the namespace header sent with it.
+func newNamespaceServer(t *testing.T) (*httptest.Server, func() []string) {
+	t.Helper()
+
+	var (
+		mu         sync.Mutex
+		namespaces []string
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		namespaces = append(namespaces, r.Header.Get("X-Vault-Namespace"))
+		mu.Unlock()
+
+		http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return namespaces
+	}
+}
+
+func TestNamespaceScopedRequests(t *testing.T) {
+	srv, namespaces := newNamespaceServer(t)
+
+	v, err := NewClient(srv.URL, "token")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	// all of them fail, which used to leave the namespace set on the shared client
+	_, err = v.ListNamespaces(ctx, "ns1")
+	require.Error(t, err)
+
+	_, err = v.ListKVSecretEngines(ctx, "ns2")
+	require.Error(t, err)
+
+	require.Error(t, v.DeleteNamespace(ctx, "ns3", "child"))
+	require.NoError(t, v.CreateNamespaceErrorIfNotForced(ctx, "ns4", "child", true))
+
+	_, err = v.ReadSecrets(ctx, "ns5", "secret", "admin")
+	require.Error(t, err)
+
+	require.Error(t, v.WriteSecrets(ctx, "ns6", "secret", "admin", map[string]interface{}{"user": "admin"}))
+
+	_, err = v.ListRecursive(ctx, "ns7", "secret", "", false)
+	require.Error(t, err)
+
+	// no namespace, uses the one of the client
+	_, err = v.ReadSecrets(ctx, "", "secret", "admin")
+	require.Error(t, err)
+
+	assert.Empty(t, v.Client.Namespace(), "shared client namespace must not be modified")
+	// a call might send more than one request, e.g. for looking up the mount first
+	assert.Equal(t, []string{"ns1", "ns2", "ns3", "ns4", "ns5", "ns6", "ns7", ""}, slices.Compact(namespaces()))
+}
+
+func TestWithNamespace(t *testing.T) {
+	v, err := NewClient("http://127.0.0.1:8200", "token")
+	require.NoError(t, err)
+
+	v.Client.SetNamespace("default")
+
+	assert.Same(t, v, v.WithNamespace(""), "empty namespace keeps the client")
+
+	scoped := v.WithNamespace("team")
+	assert.Equal(t, "team", scoped.Client.Namespace())
+	assert.Equal(t, "default", v.Client.Namespace())
+	assert.Same(t, v.mounts, scoped.mounts, "mount cache is shared")
+}
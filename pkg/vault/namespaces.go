@@ -55,9 +55,7 @@ func (v *Vault) namespaceIterator(ctx context.Context, ns string, res *Namespace
 
 // ListNamespaces list the namespaces of the specified namespace.
 func (v *Vault) ListNamespaces(ctx context.Context, ns string) ([]string, error) {
-	v.Client.SetNamespace(ns)
-
-	data, err := v.Client.Logical().ListWithContext(ctx, listNamespaces)
+	data, err := v.Client.WithNamespace(ns).Logical().ListWithContext(ctx, listNamespaces)
 	if err != nil {
 		return nil, err
 	}
@@ -75,38 +73,22 @@ func (v *Vault) ListNamespaces(ctx context.Context, ns string) ([]string, error)
 		}
 	}
 
-	v.Client.SetNamespace("")
-
 	return res, nil
 }
 
 // DeleteNamespace deletes a namespace.
 func (v *Vault) DeleteNamespace(ctx context.Context, parentns, ns string) error {
-	v.Client.SetNamespace(parentns)
-
-	_, err := v.Client.Logical().DeleteWithContext(ctx, fmt.Sprintf(createNamespace, ns))
-	if err != nil {
-		return err
-	}
+	_, err := v.Client.WithNamespace(parentns).Logical().DeleteWithContext(ctx, fmt.Sprintf(createNamespace, ns))
 
-	v.Client.SetNamespace("")
-
-	return nil
+	return err
 }
 
 // CreateNamespaceErrorIfNotForced creates a namespace returns no error if force is true.
 func (v *Vault) CreateNamespaceErrorIfNotForced(ctx context.Context, parentNS, nsName string, force bool) error {
-	v.Client.SetNamespace(parentNS)
-
-	if _, err := v.Client.Logical().WriteWithContext(ctx, fmt.Sprintf(createNamespace, nsName), nil); err != nil {
-		if force {
-			return nil
-		}
-
+	_, err := v.Client.WithNamespace(parentNS).Logical().WriteWithContext(ctx, fmt.Sprintf(createNamespace, nsName), nil)
+	if err != nil && !force {
 		return err
 	}
 
-	v.Client.SetNamespace("")
-
 	return nil
 }
@@ -0,0 +1,83 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RetentionPolicy selects the versions of KVv2 secrets that are retained, a version is retained if any rule applies.
+type RetentionPolicy struct {
+	// KeepLast retains the given number of latest versions that have not been destroyed, 0 disables the rule.
+	KeepLast int
+	// OlderThan retains the versions created within the given duration, 0 disables the rule.
+	OlderThan time.Duration
+}
+
+// Prunable returns the versions of a secret outside the retention policy, newest first.
+// The current version and versions that have already been destroyed are never returned.
+func (r RetentionPolicy) Prunable(md *SecretMetadata, now time.Time) []*SecretVersion {
+	versions := []*SecretVersion{}
+
+	// the number of latest versions that have not been destroyed
+	latest := 0
+
+	// versions are ordered newest first
+	for _, sv := range md.Versions {
+		if !sv.Destroyed {
+			latest++
+		}
+
+		switch {
+		case sv.Version == md.CurrentVersion, sv.Destroyed:
+			continue
+		case r.KeepLast > 0 && latest <= r.KeepLast:
+			continue
+		case r.OlderThan > 0 && now.Sub(sv.CreatedTime) < r.OlderThan:
+			continue
+		}
+
+		versions = append(versions, sv)
+	}
+
+	return versions
+}
+
+// ListRecursivePrunable recursively reads every KVv2 secret under subPath that has versions outside the retention policy.
+// Every secret holds only these versions, without any data.
+func (v *Vault) ListRecursivePrunable(ctx context.Context, rootPath, subPath string, policy RetentionPolicy, skipErrors bool) (VersionedSecrets, error) {
+	isV1, err := v.IsKVv1(ctx, rootPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if isV1 {
+		return nil, fmt.Errorf("%w: %q is a KVv1 engine", ErrKVv1NotSupported, rootPath)
+	}
+
+	now := time.Now()
+
+	read := func(ctx context.Context, rootPath, subPath string) (*VersionedSecret, error) {
+		md, err := v.ReadMetadata(ctx, rootPath, subPath)
+		if err != nil {
+			return nil, err
+		}
+
+		versions := policy.Prunable(md, now)
+		if len(versions) == 0 {
+			return nil, nil //nolint: nilnil
+		}
+
+		return &VersionedSecret{
+			CustomMetadata: md.CustomMetadata,
+			Versions:       versions,
+		}, nil
+	}
+
+	acc := &versionedSecretsAccumulator{secrets: make(VersionedSecrets)}
+	if err := v.listRecursiveAllVersions(ctx, newLimiter(v.workers()), rootPath, subPath, skipErrors, read, acc); err != nil {
+		return nil, err
+	}
+
+	return acc.secrets, nil
+}
@@ -0,0 +1,95 @@
+package vault
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetentionPolicyPrunable(t *testing.T) {
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	// versions are ordered newest first
+	md := &SecretMetadata{
+		CurrentVersion: 5,
+		Versions: []*SecretVersion{
+			{Version: 5, CreatedTime: now.Add(-1 * 24 * time.Hour)},
+			{Version: 4, CreatedTime: now.Add(-10 * 24 * time.Hour)},
+			{Version: 3, CreatedTime: now.Add(-100 * 24 * time.Hour)},
+			{Version: 2, CreatedTime: now.Add(-200 * 24 * time.Hour), Destroyed: true},
+			{Version: 1, CreatedTime: now.Add(-300 * 24 * time.Hour)},
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		policy   RetentionPolicy
+		expected []int
+	}{
+		{
+			name:     "keep last",
+			policy:   RetentionPolicy{KeepLast: 2},
+			expected: []int{3, 1},
+		},
+		{
+			name:     "keep last does not count destroyed versions",
+			policy:   RetentionPolicy{KeepLast: 4},
+			expected: []int{},
+		},
+		{
+			name:     "older than",
+			policy:   RetentionPolicy{OlderThan: 90 * 24 * time.Hour},
+			expected: []int{3, 1},
+		},
+		{
+			name:     "keep last and older than",
+			policy:   RetentionPolicy{KeepLast: 3, OlderThan: 90 * 24 * time.Hour},
+			expected: []int{1},
+		},
+		{
+			name:     "current version is always retained",
+			policy:   RetentionPolicy{OlderThan: time.Hour},
+			expected: []int{4, 3, 1},
+		},
+		{
+			name:     "nothing to prune",
+			policy:   RetentionPolicy{KeepLast: 10},
+			expected: []int{},
+		},
+	}
+
+	for _, tc := range testCases {
+		versions := []int{}
+		for _, sv := range tc.policy.Prunable(md, now) {
+			versions = append(versions, sv.Version)
+		}
+
+		assert.Equal(t, tc.expected, versions, tc.name)
+	}
+}
+
+func TestListRecursivePrunable(t *testing.T) {
+	srv := newVersionsServer(t, map[string][]fakeVersion{
+		"admin": {
+			{created: "2026-08-01T00:00:00Z", data: map[string]interface{}{"user": "v1"}},
+			{created: "2026-08-02T00:00:00Z", data: map[string]interface{}{"user": "v2"}},
+			{created: "2026-08-03T00:00:00Z", data: map[string]interface{}{"user": "v3"}},
+		},
+		"sub/demo": {
+			{created: "2026-08-01T00:00:00Z", data: map[string]interface{}{"foo": "bar"}},
+		},
+	})
+
+	v, err := NewClient(srv.URL, "token")
+	require.NoError(t, err)
+
+	res, err := v.ListRecursivePrunable(context.Background(), "secret", "", RetentionPolicy{KeepLast: 2}, false)
+	require.NoError(t, err)
+
+	require.Len(t, res, 1)
+	require.Len(t, res["admin"].Versions, 1)
+	assert.Equal(t, 1, res["admin"].Versions[0].Version)
+}
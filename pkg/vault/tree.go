@@ -0,0 +1,283 @@
+package vault
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/FalcoSuessgott/vkv/pkg/utils"
+)
+
+// SecretTree is a directory or a secret of a KV engine. Unlike Secrets, directories and secrets
+// are told apart by their type, so that neither object values of a secret nor a secret and
+// a directory sharing their name are ambiguous.
+type SecretTree struct {
+	// Name is the last element of the path, empty for the root of a KV engine.
+	Name string
+	// Path is the path of the directory or secret within the KV engine.
+	Path string
+	// Dir reports whether the node is a directory.
+	Dir bool
+	// Data holds the key-value pairs of a secret.
+	Data map[string]interface{}
+	// Metadata holds the metadata of a KVv2 secret, nil if it has not been read.
+	Metadata *SecretMetadata
+	// Err holds the error reading a secret that has been skipped, nil if the secret has been read.
+	// A directory that cannot be listed is read as such a secret.
+	Err error
+	// Children holds the secrets and sub directories of a directory, sorted like the keys listed by Vault,
+	// i.e. by their name with a trailing "/" for directories.
+	Children []*SecretTree
+}
+
+// NewSecretDirectory returns an empty directory at the path p.
+func NewSecretDirectory(p string) *SecretTree {
+	p = strings.Trim(p, utils.Delimiter)
+
+	return &SecretTree{Name: baseName(p), Path: p, Dir: true}
+}
+
+// NewSecret returns a secret at the path p.
+func NewSecret(p string, data map[string]interface{}) *SecretTree {
+	p = strings.Trim(p, utils.Delimiter)
+
+	return &SecretTree{Name: baseName(p), Path: p, Data: data}
+}
+
+// SecretTreeFromMap converts secrets in the legacy map shape to a directory rooted at subPath. The map is either nested
+// (e.g. Secrets or a parsed legacy vkv export) or flat (keyed by secret paths, e.g. a parsed vkv export). Only keys ending
+// with a "/" are directories, all other keys are secrets whose value holds their data, thus object values of a secret are
+// never mistaken for sub paths. Values that are not objects are neither secrets nor directories and skipped.
+func SecretTreeFromMap(subPath string, m map[string]interface{}) *SecretTree {
+	t := NewSecretDirectory(subPath)
+	t.insertMap(t.Path, m)
+
+	return t
+}
+
+// insertMap inserts the directories and secrets of a nested or flat map located at dir.
+func (t *SecretTree) insertMap(dir string, m map[string]interface{}) {
+	for k, v := range m {
+		data, ok := asMap(v)
+		if !ok {
+			continue
+		}
+
+		if strings.HasSuffix(k, utils.Delimiter) {
+			t.insertMap(path.Join(dir, k), data)
+
+			continue
+		}
+
+		t.Insert(path.Join(dir, k), data)
+	}
+}
+
+// Insert adds a secret at the path p within the KV engine below the directory, creating all parent directories.
+// An existing secret at p is replaced.
+func (t *SecretTree) Insert(p string, data map[string]interface{}) {
+	rel := strings.Trim(p, utils.Delimiter)
+	if t.Path != "" {
+		rel = strings.TrimPrefix(strings.TrimPrefix(rel, t.Path), utils.Delimiter)
+	}
+
+	parts := strings.Split(rel, utils.Delimiter)
+	dir := t
+
+	for _, name := range parts[:len(parts)-1] {
+		child := dir.child(name, true)
+		if child == nil {
+			child = NewSecretDirectory(path.Join(dir.Path, name))
+			dir.addChild(child)
+		}
+
+		dir = child
+	}
+
+	secret := NewSecret(path.Join(dir.Path, parts[len(parts)-1]), data)
+
+	if existing := dir.child(secret.Name, false); existing != nil {
+		*existing = *secret
+
+		return
+	}
+
+	dir.addChild(secret)
+}
+
+// child returns the secret or directory with the given name, nil if it does not exist.
+func (t *SecretTree) child(name string, dir bool) *SecretTree {
+	i := t.search(name, dir)
+	if i < len(t.Children) && t.Children[i].Name == name && t.Children[i].Dir == dir {
+		return t.Children[i]
+	}
+
+	return nil
+}
+
+// addChild inserts a secret or directory keeping the children sorted.
+func (t *SecretTree) addChild(c *SecretTree) {
+	i := t.search(c.Name, c.Dir)
+
+	t.Children = append(t.Children, nil)
+	copy(t.Children[i+1:], t.Children[i:])
+	t.Children[i] = c
+}
+
+// search returns the index at which a secret or directory with the given name is sorted.
+func (t *SecretTree) search(name string, dir bool) int {
+	return sort.Search(len(t.Children), func(i int) bool {
+		return !less(t.Children[i].Name, t.Children[i].Dir, name, dir)
+	})
+}
+
+// sortChildren sorts the children of a directory like the keys listed by Vault.
+func (t *SecretTree) sortChildren() {
+	sort.Slice(t.Children, func(i, j int) bool {
+		return less(t.Children[i].Name, t.Children[i].Dir, t.Children[j].Name, t.Children[j].Dir)
+	})
+}
+
+// less compares two secrets or directories by their listed key, so that a secret is sorted before a directory with the same name.
+func less(a string, aDir bool, b string, bDir bool) bool {
+	return listedKey(a, aDir) < listedKey(b, bDir)
+}
+
+// listedKey returns the key of a secret or directory as listed by Vault.
+func listedKey(name string, dir bool) string {
+	if dir {
+		return name + utils.Delimiter
+	}
+
+	return name
+}
+
+// SecretNodes returns all secrets of the tree sorted by their path.
+func (t *SecretTree) SecretNodes() []*SecretTree {
+	if !t.Dir {
+		return []*SecretTree{t}
+	}
+
+	res := []*SecretTree{}
+
+	for _, c := range t.Children {
+		res = append(res, c.SecretNodes()...)
+	}
+
+	return res
+}
+
+// Flatten returns the data of all non-empty secrets keyed by their path within the KV engine.
+func (t *SecretTree) Flatten() map[string]map[string]interface{} {
+	res := make(map[string]map[string]interface{})
+
+	for _, s := range t.SecretNodes() {
+		if len(s.Data) > 0 {
+			res[s.Path] = s.Data
+		}
+	}
+
+	return res
+}
+
+// FlattenRelative returns the data of all non-empty secrets keyed by their path relative to the tree,
+// i.e. a secret at the path of the tree itself has an empty path.
+func (t *SecretTree) FlattenRelative() map[string]map[string]interface{} {
+	res := make(map[string]map[string]interface{})
+
+	for p, data := range t.Flatten() {
+		res[strings.TrimPrefix(strings.TrimPrefix(p, t.Path), utils.Delimiter)] = data
+	}
+
+	return res
+}
+
+// SecretsMetadata returns the metadata of all secrets whose metadata has been read keyed by their path.
+func (t *SecretTree) SecretsMetadata() SecretsMetadata {
+	res := make(SecretsMetadata)
+
+	for _, s := range t.SecretNodes() {
+		if s.Metadata != nil {
+			res[s.Path] = s.Metadata
+		}
+	}
+
+	return res
+}
+
+// SetMetadata sets the metadata of all secrets present in md.
+func (t *SecretTree) SetMetadata(md SecretsMetadata) {
+	for _, s := range t.SecretNodes() {
+		if m, ok := md[s.Path]; ok {
+			s.Metadata = m
+		}
+	}
+}
+
+// ToSecrets converts the tree to the legacy Secrets shape as returned by ListRecursive.
+func (t *SecretTree) ToSecrets() *Secrets {
+	if !t.Dir {
+		s := Secrets(t.Data)
+
+		return &s
+	}
+
+	s := make(Secrets, len(t.Children))
+
+	for _, c := range t.Children {
+		if c.Dir {
+			s[listedKey(c.Name, true)] = c.ToSecrets()
+		} else {
+			s[c.Name] = c.Data
+		}
+	}
+
+	return &s
+}
+
+// Map converts the tree to the legacy nested map shape, in which directories end with a "/".
+// The data of a secret is returned as is.
+func (t *SecretTree) Map() map[string]interface{} {
+	if !t.Dir {
+		return t.Data
+	}
+
+	m := make(map[string]interface{}, len(t.Children))
+
+	for _, c := range t.Children {
+		if c.Dir {
+			m[listedKey(c.Name, true)] = c.Map()
+		} else {
+			m[c.Name] = c.Data
+		}
+	}
+
+	return m
+}
+
+// asMap returns the value of a legacy map as map.
+func asMap(v interface{}) (map[string]interface{}, bool) {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return m, true
+	case Secrets:
+		return m, true
+	case *Secrets:
+		if m == nil {
+			return nil, false
+		}
+
+		return *m, true
+	default:
+		return nil, false
+	}
+}
+
+// baseName returns the last element of a path, empty for the root path.
+func baseName(p string) string {
+	if p == "" {
+		return ""
+	}
+
+	return path.Base(p)
+}
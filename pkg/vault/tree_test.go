@@ -0,0 +1,276 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *VaultSuite) TestListSecretTree() {
+	s.Run("list secret tree", func() {
+		ctx := context.Background()
+		rootPath := "tree"
+
+		require.NoError(s.T(), s.client.EnableKV2Engine(ctx, rootPath))
+
+		require.NoError(s.T(), s.client.WriteSecrets(ctx, "", rootPath, "app", map[string]interface{}{"user": "v1"}))
+		require.NoError(s.T(), s.client.WriteSecrets(ctx, "", rootPath, "app/db", map[string]interface{}{"config": map[string]interface{}{"port": 5432}}))
+
+		tree, err := s.client.ListSecretTree(ctx, "", rootPath, "", false, true)
+		require.NoError(s.T(), err)
+
+		require.Len(s.T(), tree.Children, 2)
+		assert.Equal(s.T(), "app", tree.Children[0].Path)
+		assert.False(s.T(), tree.Children[0].Dir)
+		assert.Equal(s.T(), "app", tree.Children[1].Path)
+		assert.True(s.T(), tree.Children[1].Dir)
+
+		assert.Equal(s.T(), map[string]map[string]interface{}{
+			"app":    {"user": "v1"},
+			"app/db": {"config": map[string]interface{}{"port": json.Number("5432")}},
+		}, tree.Flatten())
+
+		md := tree.SecretsMetadata()
+		require.Contains(s.T(), md, "app")
+		require.Contains(s.T(), md, "app/db")
+		assert.Equal(s.T(), 1, md["app/db"].CurrentVersion)
+
+		// the legacy shape is unchanged
+		expected, err := s.client.ListRecursive(ctx, "", rootPath, "", false)
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), expected, tree.ToSecrets())
+
+		// a sub path that is a secret
+		secret, err := s.client.ListSecretTree(ctx, "", rootPath, "app/db", false, false)
+		require.NoError(s.T(), err)
+		assert.False(s.T(), secret.Dir)
+		assert.Equal(s.T(), "db", secret.Name)
+		assert.Nil(s.T(), secret.Metadata)
+	})
+}
+
+func TestListSecretTreeAsOf(t *testing.T) {
+	asOf := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+
+	srv := newVersionsServer(t, map[string][]fakeVersion{
+		"sub/demo": {
+			{created: "2026-08-01T00:00:00Z", data: map[string]interface{}{"config": map[string]interface{}{"port": "v1"}}},
+			{created: "2026-09-15T00:00:00Z", data: map[string]interface{}{"config": map[string]interface{}{"port": "v2"}}},
+		},
+	})
+
+	v, err := NewClient(srv.URL, "token")
+	require.NoError(t, err)
+
+	tree, err := v.ListSecretTreeAsOf(context.Background(), "secret", "", asOf, false)
+	require.NoError(t, err)
+
+	assert.True(t, tree.Dir)
+	assert.Equal(t, map[string]map[string]interface{}{
+		"sub/demo": {"config": map[string]interface{}{"port": "v1"}},
+	}, tree.Flatten())
+	assert.Equal(t, 1, tree.SecretsMetadata()["sub/demo"].CurrentVersion)
+
+	// a secret whose values are objects is not mistaken for a directory
+	secret, err := v.ListSecretTreeAsOf(context.Background(), "secret", "sub/demo", asOf, false)
+	require.NoError(t, err)
+
+	assert.False(t, secret.Dir)
+	assert.Equal(t, map[string]interface{}{"config": map[string]interface{}{"port": "v1"}}, secret.Data)
+	assert.Equal(t, 1, secret.Metadata.CurrentVersion)
+}
+
+func TestListSecretTreeSkipErrors(t *testing.T) {
+	srv := newVersionsServer(t, map[string][]fakeVersion{
+		"admin": {
+			{created: "2026-08-01T00:00:00Z", data: map[string]interface{}{"user": "v1"}},
+		},
+		"empty": {
+			{created: "2026-08-01T00:00:00Z", data: map[string]interface{}{}},
+		},
+		"sub/deleted": {
+			{created: "2026-08-01T00:00:00Z", deleted: "2026-08-02T00:00:00Z"},
+		},
+	})
+
+	v, err := NewClient(srv.URL, "token")
+	require.NoError(t, err)
+
+	_, err = v.ListSecretTree(context.Background(), "", "secret", "", false, false)
+	require.Error(t, err)
+
+	tree, err := v.ListSecretTree(context.Background(), "", "secret", "", true, false)
+	require.NoError(t, err)
+
+	errs := map[string]bool{}
+	for _, s := range tree.SecretNodes() {
+		errs[s.Path] = s.Err != nil
+	}
+
+	// an empty secret has been read, unlike the deleted one
+	assert.Equal(t, map[string]bool{"admin": false, "empty": false, "sub/deleted": true}, errs)
+}
+
+func TestSecretTreeInsert(t *testing.T) {
+	tree := NewSecretDirectory("")
+
+	tree.Insert("b", map[string]interface{}{"key": "b"})
+	tree.Insert("a/c", map[string]interface{}{"key": "a/c"})
+	tree.Insert("a-b", map[string]interface{}{"key": "a-b"})
+	tree.Insert("a", map[string]interface{}{"key": "a"})
+	tree.Insert("a/c", map[string]interface{}{"key": "replaced"})
+
+	names := []string{}
+	for _, c := range tree.Children {
+		names = append(names, listedKey(c.Name, c.Dir))
+	}
+
+	// sorted like the keys listed by Vault
+	assert.Equal(t, []string{"a", "a-b", "a/", "b"}, names)
+
+	assert.Equal(t, map[string]map[string]interface{}{
+		"a":   {"key": "a"},
+		"a-b": {"key": "a-b"},
+		"a/c": {"key": "replaced"},
+		"b":   {"key": "b"},
+	}, tree.Flatten())
+
+	paths := []string{}
+	for _, s := range tree.SecretNodes() {
+		paths = append(paths, s.Path)
+	}
+
+	assert.Equal(t, []string{"a", "a-b", "a/c", "b"}, paths)
+}
+
+func TestSecretTreeFromMap(t *testing.T) {
+	testCases := []struct {
+		name    string
+		subPath string
+		m       map[string]interface{}
+		flat    map[string]map[string]interface{}
+	}{
+		{
+			name:    "nested",
+			subPath: "sub",
+			m: map[string]interface{}{
+				"demo": map[string]interface{}{"user": "admin"},
+				"sub2/": map[string]interface{}{
+					"demo": map[string]interface{}{"config": map[string]interface{}{"port": 5432}},
+				},
+			},
+			flat: map[string]map[string]interface{}{
+				"sub/demo":      {"user": "admin"},
+				"sub/sub2/demo": {"config": map[string]interface{}{"port": 5432}},
+			},
+		},
+		{
+			name: "flat",
+			m: map[string]interface{}{
+				"sub/demo": map[string]interface{}{"user": "admin"},
+				"sub":      map[string]interface{}{"user": "root"},
+			},
+			flat: map[string]map[string]interface{}{
+				"sub/demo": {"user": "admin"},
+				"sub":      {"user": "root"},
+			},
+		},
+		{
+			name: "legacy secrets",
+			m: map[string]interface{}{
+				"sub/": &Secrets{
+					"demo": map[string]interface{}{"user": "admin"},
+				},
+			},
+			flat: map[string]map[string]interface{}{
+				"sub/demo": {"user": "admin"},
+			},
+		},
+		{
+			name: "secret with object values only",
+			m: map[string]interface{}{
+				"sub/db": map[string]interface{}{
+					"config": map[string]interface{}{"port": 5432},
+					"tls":    map[string]interface{}{"enabled": true},
+				},
+			},
+			flat: map[string]map[string]interface{}{
+				"sub/db": {
+					"config": map[string]interface{}{"port": 5432},
+					"tls":    map[string]interface{}{"enabled": true},
+				},
+			},
+		},
+		{
+			name: "no secrets",
+			m:    map[string]interface{}{"user": "admin"},
+			flat: map[string]map[string]interface{}{},
+		},
+	}
+
+	for _, tc := range testCases {
+		tree := SecretTreeFromMap(tc.subPath, tc.m)
+
+		assert.True(t, tree.Dir, tc.name)
+		assert.Equal(t, tc.flat, tree.Flatten(), tc.name)
+	}
+}
+
+func TestSecretTreeFlattenRelative(t *testing.T) {
+	tree := NewSecretDirectory("sub")
+	tree.Insert("sub/demo", map[string]interface{}{"user": "admin"})
+	tree.Insert("sub/sub2/demo", map[string]interface{}{"config": map[string]interface{}{"port": 5432}})
+
+	assert.Equal(t, map[string]map[string]interface{}{
+		"demo":      {"user": "admin"},
+		"sub2/demo": {"config": map[string]interface{}{"port": 5432}},
+	}, tree.FlattenRelative())
+
+	secret := NewSecret("sub/demo", map[string]interface{}{"config": map[string]interface{}{"port": 5432}})
+
+	assert.Equal(t, map[string]map[string]interface{}{
+		"": {"config": map[string]interface{}{"port": 5432}},
+	}, secret.FlattenRelative())
+}
+
+func TestSecretTreeToSecrets(t *testing.T) {
+	tree := NewSecretDirectory("")
+	tree.Insert("app", map[string]interface{}{"user": "admin"})
+	tree.Insert("app/db", map[string]interface{}{"user": "root"})
+
+	assert.Equal(t, &Secrets{
+		"app": map[string]interface{}{"user": "admin"},
+		"app/": &Secrets{
+			"db": map[string]interface{}{"user": "root"},
+		},
+	}, tree.ToSecrets())
+
+	assert.Equal(t, map[string]interface{}{
+		"app": map[string]interface{}{"user": "admin"},
+		"app/": map[string]interface{}{
+			"db": map[string]interface{}{"user": "root"},
+		},
+	}, tree.Map())
+
+	// the tree survives the round trip through the legacy shape
+	assert.Equal(t, tree, SecretTreeFromMap("", tree.Map()))
+}
+
+func TestSecretTreeMetadata(t *testing.T) {
+	tree := NewSecretDirectory("")
+	tree.Insert("app", map[string]interface{}{"user": "admin"})
+	tree.Insert("sub/demo", map[string]interface{}{"user": "root"})
+
+	assert.Empty(t, tree.SecretsMetadata())
+
+	tree.SetMetadata(SecretsMetadata{
+		"sub/demo": {CurrentVersion: 2},
+		"missing":  {CurrentVersion: 1},
+	})
+
+	assert.Equal(t, SecretsMetadata{"sub/demo": {CurrentVersion: 2}}, tree.SecretsMetadata())
+}
@@ -2,11 +2,13 @@ package vault
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"path"
-	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/FalcoSuessgott/vkv/pkg/utils"
@@ -23,108 +25,93 @@ type SecretVersion struct {
 	Data map[string]interface{} `json:"data,omitempty"`
 }
 
-// VersionedSecret holds all versions of a single KVv2 secret plus its custom metadata.
+// VersionedSecret holds all versions of a single KVv2 secret plus its custom metadata and settings.
 type VersionedSecret struct {
 	// CustomMetadata is shared across all versions of the secret (may be nil).
 	CustomMetadata map[string]interface{} `json:"custom_metadata,omitempty"`
+	// MaxVersions, CASRequired and DeleteVersionAfter hold the settings of the secret, if they differ from their default.
+	MaxVersions        int    `json:"max_versions,omitempty"`
+	CASRequired        bool   `json:"cas_required,omitempty"`
+	DeleteVersionAfter string `json:"delete_version_after,omitempty"`
 	// Versions are ordered newest first.
 	Versions []*SecretVersion `json:"versions"`
 }
 
+// Settings returns the writable metadata of the secret, nil if all of them have their default value.
+func (s *VersionedSecret) Settings() *SecretSettings {
+	settings := &SecretSettings{
+		CustomMetadata:     s.CustomMetadata,
+		MaxVersions:        s.MaxVersions,
+		CASRequired:        s.CASRequired,
+		DeleteVersionAfter: s.DeleteVersionAfter,
+	}
+
+	if settings.IsEmpty() {
+		return nil
+	}
+
+	return settings
+}
+
 // VersionedSecrets maps a secret subPath to its versioned secret.
 type VersionedSecrets map[string]*VersionedSecret
 
+// readVersionsFunc reads the versions of a single secret, a nil secret is skipped.
+type readVersionsFunc func(ctx context.Context, rootPath, subPath string) (*VersionedSecret, error)
+
+// versionSelector selects a version of a secret, nil if the secret is skipped.
+// deleted reports whether the selected version is to be treated as deleted.
+type versionSelector func(md *SecretMetadata) (sv *SecretVersion, deleted bool)
+
+// DeletedAt reports whether the version has been deleted at t.
+func (sv *SecretVersion) DeletedAt(t time.Time) bool {
+	return sv.DeletionTime != nil && !sv.DeletionTime.After(t)
+}
+
 // ReadCurrentVersionCreatedTime returns the creation time of a secret's current (latest) version.
 func (v *Vault) ReadCurrentVersionCreatedTime(ctx context.Context, rootPath, subPath string) (time.Time, error) {
-	data, err := v.Client.Logical().ReadWithContext(ctx, fmt.Sprintf(kvv2ListSecretsPath, rootPath, subPath))
+	md, err := v.ReadMetadata(ctx, rootPath, subPath)
 	if err != nil {
 		return time.Time{}, err
 	}
 
-	if data == nil || data.Data == nil {
-		return time.Time{}, fmt.Errorf("could not read secret %s metadata", path.Join(rootPath, subPath))
-	}
-
-	// prefer the current version's created_time, fall back to the secret's updated_time
-	if cur, ok := data.Data["current_version"]; ok {
-		if versions, ok := data.Data["versions"].(map[string]interface{}); ok {
-			if m, ok := versions[fmt.Sprintf("%v", cur)].(map[string]interface{}); ok {
-				if t := parseVaultTime(m["created_time"]); !t.IsZero() {
-					return t, nil
-				}
-			}
-		}
-	}
-
-	return parseVaultTime(data.Data["updated_time"]), nil
+	return md.CurrentVersionCreatedTime(), nil
 }
 
 // ReadAllVersions returns all versions of a single KVv2 secret, newest first.
 func (v *Vault) ReadAllVersions(ctx context.Context, rootPath, subPath string) (*VersionedSecret, error) {
-	metadata, err := v.Client.Logical().ReadWithContext(ctx, fmt.Sprintf(kvv2ListSecretsPath, rootPath, subPath))
+	md, err := v.ReadMetadata(ctx, rootPath, subPath)
 	if err != nil {
 		return nil, err
 	}
 
-	if metadata == nil || metadata.Data == nil {
-		return nil, fmt.Errorf("could not read secret %s metadata", path.Join(rootPath, subPath))
-	}
-
-	versions, ok := metadata.Data["versions"].(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("could not read versions of secret %s", path.Join(rootPath, subPath))
-	}
-
 	secret := &VersionedSecret{
-		Versions: make([]*SecretVersion, 0, len(versions)),
+		CustomMetadata: md.CustomMetadata,
+		Versions:       md.Versions,
 	}
 
-	if cm, ok := metadata.Data["custom_metadata"].(map[string]interface{}); ok {
-		secret.CustomMetadata = cm
+	if settings := md.Settings(); settings != nil {
+		secret.MaxVersions = settings.MaxVersions
+		secret.CASRequired = settings.CASRequired
+		secret.DeleteVersionAfter = settings.DeleteVersionAfter
 	}
 
-	for vStr, meta := range versions {
-		versionNr, err := strconv.Atoi(vStr)
-		if err != nil {
-			return nil, fmt.Errorf("invalid version %q for secret %s: %w", vStr, path.Join(rootPath, subPath), err)
-		}
+	now := time.Now()
 
-		m, ok := meta.(map[string]interface{})
-		if !ok {
+	for _, sv := range secret.Versions {
+		// only retrievable versions have data, versions with a deletion time in the future are still readable
+		if sv.Destroyed || sv.DeletedAt(now) {
 			continue
 		}
 
-		sv := &SecretVersion{
-			Version:     versionNr,
-			CreatedTime: parseVaultTime(m["created_time"]),
-		}
-
-		if dt := parseVaultTime(m["deletion_time"]); !dt.IsZero() {
-			sv.DeletionTime = &dt
-		}
-
-		if destroyed, ok := m["destroyed"].(bool); ok {
-			sv.Destroyed = destroyed
-		}
-
-		// only retrievable versions have data
-		if !sv.Destroyed && sv.DeletionTime == nil {
-			data, err := v.readSecretVersionData(ctx, rootPath, subPath, versionNr)
-			if err != nil {
-				return nil, err
-			}
-
-			sv.Data = data
+		data, err := v.readSecretVersionData(ctx, rootPath, subPath, sv.Version)
+		if err != nil {
+			return nil, err
 		}
 
-		secret.Versions = append(secret.Versions, sv)
+		sv.Data = data
 	}
 
-	// newest version first
-	sort.Slice(secret.Versions, func(i, j int) bool {
-		return secret.Versions[i].Version > secret.Versions[j].Version
-	})
-
 	return secret, nil
 }
 
@@ -161,20 +148,181 @@ func (v *Vault) ListRecursiveAllVersions(ctx context.Context, rootPath, subPath
 		return nil, fmt.Errorf("--all-versions is only supported for KVv2 engines, %q is a KVv1 engine", rootPath)
 	}
 
-	result := make(VersionedSecrets)
-	if err := v.listRecursiveAllVersions(ctx, rootPath, subPath, skipErrors, result); err != nil {
+	acc := &versionedSecretsAccumulator{secrets: make(VersionedSecrets)}
+	if err := v.listRecursiveAllVersions(ctx, newLimiter(v.workers()), rootPath, subPath, skipErrors, v.ReadAllVersions, acc); err != nil {
+		return nil, err
+	}
+
+	return acc.secrets, nil
+}
+
+// ListSecretTreeAsOf recursively reads every KVv2 secret under subPath in the version that was current at t.
+// Secrets created after t are skipped and secrets whose version was deleted at t have no data.
+// The metadata of the secrets describes the selected versions.
+func (v *Vault) ListSecretTreeAsOf(ctx context.Context, rootPath, subPath string, t time.Time, skipErrors bool) (*SecretTree, error) {
+	return v.listSelectedTree(ctx, rootPath, subPath, skipErrors, func(md *SecretMetadata) (*SecretVersion, bool) {
+		// versions are ordered newest first
+		for _, sv := range md.Versions {
+			if !sv.CreatedTime.After(t) {
+				return sv, sv.DeletedAt(t)
+			}
+		}
+
+		return nil, false
+	})
+}
+
+// ListSecretTreeVersionsBack recursively reads every KVv2 secret under subPath in the version n versions before its current one.
+// Secrets without such a version are skipped and secrets whose version has been deleted have no data.
+// The metadata of the secrets describes the selected versions.
+func (v *Vault) ListSecretTreeVersionsBack(ctx context.Context, rootPath, subPath string, n int, skipErrors bool) (*SecretTree, error) {
+	now := time.Now()
+
+	return v.listSelectedTree(ctx, rootPath, subPath, skipErrors, func(md *SecretMetadata) (*SecretVersion, bool) {
+		for _, sv := range md.Versions {
+			if sv.Version == md.CurrentVersion-n {
+				return sv, sv.DeletedAt(now)
+			}
+		}
+
+		return nil, false
+	})
+}
+
+// listSelectedTree recursively reads every KVv2 secret under subPath in the version chosen by sel.
+// The metadata tells a secret at subPath apart from a directory.
+func (v *Vault) listSelectedTree(ctx context.Context, rootPath, subPath string, skipErrors bool, sel versionSelector) (*SecretTree, error) {
+	isV1, err := v.IsKVv1(ctx, rootPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if isV1 {
+		return nil, fmt.Errorf("reading previous versions is only supported for KVv2 engines, %q is a KVv1 engine", rootPath)
+	}
+
+	read := func(ctx context.Context, rootPath, subPath string) (*VersionedSecret, error) {
+		return v.readSelectedVersion(ctx, rootPath, subPath, sel)
+	}
+
+	acc := &versionedSecretsAccumulator{secrets: make(VersionedSecrets)}
+	if err := v.listRecursiveAllVersions(ctx, newLimiter(v.workers()), rootPath, subPath, skipErrors, read, acc); err != nil {
+		return nil, err
+	}
+
+	tree := NewSecretDirectory(subPath)
+	md := make(SecretsMetadata)
+
+	// subPath itself is a secret
+	if _, ok := acc.secrets[tree.Path]; ok && tree.Path != "" {
+		tree = NewSecret(tree.Path, nil)
+	}
+
+	for p, vs := range acc.secrets {
+		sv := vs.Versions[0]
+
+		data := sv.Data
+		if data == nil {
+			data = make(map[string]interface{})
+		}
+
+		switch {
+		case !tree.Dir && p == tree.Path:
+			tree.Data = data
+		case !tree.Dir:
+			continue
+		default:
+			tree.Insert(p, data)
+		}
+
+		md[p] = &SecretMetadata{
+			CurrentVersion: sv.Version,
+			CreatedTime:    sv.CreatedTime,
+			UpdatedTime:    sv.CreatedTime,
+			CustomMetadata: vs.CustomMetadata,
+			Versions: []*SecretVersion{{
+				Version:      sv.Version,
+				CreatedTime:  sv.CreatedTime,
+				DeletionTime: sv.DeletionTime,
+				Destroyed:    sv.Destroyed,
+			}},
+		}
+	}
+
+	tree.SetMetadata(md)
+
+	return tree, nil
+}
+
+// readSelectedVersion reads the version of a secret chosen by sel.
+// The secret is nil if no version was selected, the data is nil if the version is treated as deleted.
+func (v *Vault) readSelectedVersion(ctx context.Context, rootPath, subPath string, sel versionSelector) (*VersionedSecret, error) {
+	md, err := v.ReadMetadata(ctx, rootPath, subPath)
+	if err != nil {
 		return nil, err
 	}
 
-	return result, nil
+	sv, deleted := sel(md)
+	if sv == nil {
+		return nil, nil //nolint: nilnil
+	}
+
+	if !deleted {
+		if sv.Destroyed || sv.DeletedAt(time.Now()) {
+			return nil, fmt.Errorf("version %d of %s has been deleted or destroyed, its data can no longer be read", sv.Version, path.Join(rootPath, subPath))
+		}
+
+		data, err := v.readSecretVersionData(ctx, rootPath, subPath, sv.Version)
+		if err != nil {
+			return nil, err
+		}
+
+		sv.Data = data
+	}
+
+	return &VersionedSecret{
+		CustomMetadata: md.CustomMetadata,
+		Versions:       []*SecretVersion{sv},
+	}, nil
+}
+
+// versionedSecretsAccumulator collects the secrets read by concurrent traversals.
+type versionedSecretsAccumulator struct {
+	mu      sync.Mutex
+	secrets VersionedSecrets
+}
+
+func (a *versionedSecretsAccumulator) add(p string, secret *VersionedSecret) {
+	if secret == nil {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.secrets[p] = secret
 }
 
 // nolint: cyclop
-func (v *Vault) listRecursiveAllVersions(ctx context.Context, rootPath, subPath string, skipErrors bool, acc VersionedSecrets) error {
-	keys, err := v.ListKeys(ctx, rootPath, subPath)
+func (v *Vault) listRecursiveAllVersions(ctx context.Context, l limiter, rootPath, subPath string, skipErrors bool, read readVersionsFunc, acc *versionedSecretsAccumulator) error {
+	var (
+		keys []string
+		err  error
+	)
+
+	l.do(func() { keys, err = v.ListKeys(ctx, rootPath, subPath) })
+
+	// the root of an empty engine cannot be a secret
+	if errors.Is(err, errNoKeys) && strings.Trim(subPath, utils.Delimiter) == "" {
+		return nil
+	}
+
 	if err != nil {
 		// no sub directories, treat subPath as a leaf secret
-		secret, err := v.ReadAllVersions(ctx, rootPath, subPath)
+		var secret *VersionedSecret
+
+		l.do(func() { secret, err = read(ctx, rootPath, subPath) })
+
 		if err != nil {
 			if skipErrors {
 				return nil
@@ -183,32 +331,54 @@ func (v *Vault) listRecursiveAllVersions(ctx context.Context, rootPath, subPath
 			return fmt.Errorf("could not read secret versions from %s: %w.\n\nYou can skip this error using --skip-errors", path.Join(rootPath, subPath), err)
 		}
 
-		acc[strings.TrimSuffix(subPath, utils.Delimiter)] = secret
+		acc.add(strings.TrimSuffix(subPath, utils.Delimiter), secret)
 
 		return nil
 	}
 
-	for _, k := range keys {
-		nextPath := path.Join(subPath, k)
+	errs := make([]error, len(keys))
+
+	var wg sync.WaitGroup
+
+	for i, k := range keys {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
 
-		if strings.HasSuffix(k, utils.Delimiter) {
-			if err := v.listRecursiveAllVersions(ctx, rootPath, nextPath, skipErrors, acc); err != nil {
-				return err
+			nextPath := path.Join(subPath, k)
+
+			if strings.HasSuffix(k, utils.Delimiter) {
+				errs[i] = v.listRecursiveAllVersions(ctx, l, rootPath, nextPath, skipErrors, read, acc)
+
+				return
 			}
 
-			continue
-		}
+			var (
+				secret *VersionedSecret
+				err    error
+			)
 
-		secret, err := v.ReadAllVersions(ctx, rootPath, nextPath)
-		if err != nil {
-			if skipErrors {
-				continue
+			l.do(func() { secret, err = read(ctx, rootPath, nextPath) })
+
+			if err != nil {
+				if !skipErrors {
+					errs[i] = err
+				}
+
+				return
 			}
 
+			acc.add(nextPath, secret)
+		}()
+	}
+
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
 			return err
 		}
-
-		acc[nextPath] = secret
 	}
 
 	return nil
@@ -228,3 +398,47 @@ func parseVaultTime(v interface{}) time.Time {
 
 	return t
 }
+
+// IsVersionedSecrets reports whether parsed input is an export created using --all-versions,
+// i.e. every secret has a list of versions and optionally custom metadata and settings.
+func IsVersionedSecrets(m map[string]interface{}) bool {
+	if len(m) == 0 {
+		return false
+	}
+
+	for _, v := range m {
+		secret, ok := v.(map[string]interface{})
+		if !ok {
+			return false
+		}
+
+		if _, ok := secret["versions"].([]interface{}); !ok {
+			return false
+		}
+
+		for k := range secret {
+			switch k {
+			case "versions", "custom_metadata", "max_versions", "cas_required", "delete_version_after":
+			default:
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// ParseVersionedSecrets converts parsed input of an export created using --all-versions to VersionedSecrets.
+func ParseVersionedSecrets(m map[string]interface{}) (VersionedSecrets, error) {
+	out, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := make(VersionedSecrets)
+	if err := json.Unmarshal(out, &secrets); err != nil {
+		return nil, fmt.Errorf("invalid versioned secrets: %w", err)
+	}
+
+	return secrets, nil
+}
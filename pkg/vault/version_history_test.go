@@ -0,0 +1,388 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeVersion is a single version of a secret served by newVersionsServer.
+type fakeVersion struct {
+	created   string
+	deleted   string
+	destroyed bool
+	data      map[string]interface{}
+}
+
+// newVersionsServer returns a fake Vault server that serves a KVv2 engine "secret" containing the given secrets.
+func newVersionsServer(t *testing.T, secrets map[string][]fakeVersion) *httptest.Server {
+	t.Helper()
+
+	respond := func(w http.ResponseWriter, data interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		//nolint: errcheck
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/sys/internal/ui/mounts/secret", func(w http.ResponseWriter, r *http.Request) {
+		respond(w, map[string]interface{}{"path": "secret/", "type": "kv", "options": map[string]interface{}{"version": "2"}})
+	})
+
+	mux.HandleFunc("/v1/secret/metadata/", func(w http.ResponseWriter, r *http.Request) {
+		p := strings.TrimPrefix(r.URL.Path, "/v1/secret/metadata/")
+
+		if r.Method == "LIST" || r.URL.Query().Get("list") == "true" {
+			keys := map[string]bool{}
+
+			if p != "" && !strings.HasSuffix(p, "/") {
+				p += "/"
+			}
+
+			for s := range secrets {
+				if rest, ok := strings.CutPrefix(s, p); ok && rest != "" {
+					if i := strings.Index(rest, "/"); i >= 0 {
+						rest = rest[:i+1]
+					}
+
+					keys[rest] = true
+				}
+			}
+
+			if len(keys) == 0 {
+				notFound(w)
+
+				return
+			}
+
+			res := []string{}
+			for k := range keys {
+				res = append(res, k)
+			}
+
+			respond(w, map[string]interface{}{"keys": res})
+
+			return
+		}
+
+		versions, ok := secrets[p]
+		if !ok {
+			notFound(w)
+
+			return
+		}
+
+		vs := map[string]interface{}{}
+		for i, v := range versions {
+			vs[strconv.Itoa(i+1)] = map[string]interface{}{"created_time": v.created, "deletion_time": v.deleted, "destroyed": v.destroyed}
+		}
+
+		respond(w, map[string]interface{}{"current_version": len(versions), "versions": vs})
+	})
+
+	mux.HandleFunc("/v1/secret/data/", func(w http.ResponseWriter, r *http.Request) {
+		versions := secrets[strings.TrimPrefix(r.URL.Path, "/v1/secret/data/")]
+
+		// the current version is read, if no version is specified
+		i := len(versions)
+		if version := r.URL.Query().Get("version"); version != "" {
+			i, _ = strconv.Atoi(version)
+		}
+
+		if i < 1 || i > len(versions) {
+			notFound(w)
+
+			return
+		}
+
+		v := versions[i-1]
+
+		// deleted and destroyed versions have no data
+		if v.data == nil {
+			respond(w, map[string]interface{}{"data": nil})
+
+			return
+		}
+
+		respond(w, map[string]interface{}{"data": v.data})
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func notFound(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	w.Write([]byte(`{"errors":[]}`)) //nolint: errcheck
+}
+
+func TestListSecretTreeAsOfVersions(t *testing.T) {
+	asOf := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+
+	secrets := map[string][]fakeVersion{
+		"admin": {
+			{created: "2026-08-01T00:00:00Z", data: map[string]interface{}{"user": "v1"}},
+			{created: "2026-09-15T00:00:00Z", data: map[string]interface{}{"user": "v2"}},
+		},
+		// created after the point in time
+		"new": {
+			{created: "2026-09-10T00:00:00Z", data: map[string]interface{}{"user": "new"}},
+		},
+		// deleted before the point in time
+		"gone": {
+			{created: "2026-08-01T00:00:00Z", deleted: "2026-08-15T00:00:00Z"},
+		},
+		"sub/demo": {
+			{created: "2026-07-01T00:00:00Z", data: map[string]interface{}{"foo": "bar"}},
+		},
+	}
+
+	srv := newVersionsServer(t, secrets)
+
+	v, err := NewClient(srv.URL, "token")
+	require.NoError(t, err)
+
+	tree, err := v.ListSecretTreeAsOf(context.Background(), "secret", "", asOf, false)
+	require.NoError(t, err)
+
+	assert.Equal(t, &Secrets{
+		"admin": map[string]interface{}{"user": "v1"},
+		"gone":  map[string]interface{}{},
+		"sub/": &Secrets{
+			"demo": map[string]interface{}{"foo": "bar"},
+		},
+	}, tree.ToSecrets())
+
+	md := tree.SecretsMetadata()
+	require.Len(t, md, 3)
+	assert.Equal(t, 1, md["admin"].CurrentVersion)
+	assert.True(t, md["gone"].Current().DeletedAt(asOf))
+	assert.False(t, md["sub/demo"].Current().DeletedAt(asOf))
+
+	t.Run("sub path", func(t *testing.T) {
+		tree, err := v.ListSecretTreeAsOf(context.Background(), "secret", "sub", asOf, false)
+		require.NoError(t, err)
+
+		assert.Equal(t, &Secrets{"demo": map[string]interface{}{"foo": "bar"}}, tree.ToSecrets())
+	})
+
+	t.Run("version deleted since", func(t *testing.T) {
+		secrets["late"] = []fakeVersion{
+			{created: "2026-08-01T00:00:00Z", deleted: "2026-09-20T00:00:00Z"},
+		}
+
+		_, err := v.ListSecretTreeAsOf(context.Background(), "secret", "", asOf, false)
+		require.ErrorContains(t, err, "its data can no longer be read")
+
+		tree, err := v.ListSecretTreeAsOf(context.Background(), "secret", "", asOf, true)
+		require.NoError(t, err)
+		assert.NotContains(t, tree.SecretsMetadata(), "late")
+	})
+}
+
+func TestListSecretTreeVersionsBack(t *testing.T) {
+	srv := newVersionsServer(t, map[string][]fakeVersion{
+		"admin": {
+			{created: "2026-08-01T00:00:00Z", data: map[string]interface{}{"user": "v1"}},
+			{created: "2026-08-02T00:00:00Z", data: map[string]interface{}{"user": "v2"}},
+			{created: "2026-08-03T00:00:00Z", data: map[string]interface{}{"user": "v3"}},
+		},
+		// no version 2 versions back
+		"demo": {
+			{created: "2026-08-01T00:00:00Z", data: map[string]interface{}{"foo": "bar"}},
+		},
+	})
+
+	v, err := NewClient(srv.URL, "token")
+	require.NoError(t, err)
+
+	tree, err := v.ListSecretTreeVersionsBack(context.Background(), "secret", "", 2, false)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]map[string]interface{}{"admin": {"user": "v1"}}, tree.Flatten())
+	assert.Equal(t, 1, tree.SecretsMetadata()["admin"].CurrentVersion)
+	assert.NotContains(t, tree.SecretsMetadata(), "demo")
+
+	// a sub path that is a secret
+	secret, err := v.ListSecretTreeVersionsBack(context.Background(), "secret", "admin", 2, false)
+	require.NoError(t, err)
+	assert.False(t, secret.Dir)
+	assert.Equal(t, map[string]interface{}{"user": "v1"}, secret.Data)
+}
+
+func TestListRecursiveDeleted(t *testing.T) {
+	srv := newVersionsServer(t, map[string][]fakeVersion{
+		"admin": {
+			{created: "2026-08-01T00:00:00Z", data: map[string]interface{}{"user": "v1"}},
+			{created: "2026-08-02T00:00:00Z", deleted: "2026-08-03T00:00:00Z"},
+		},
+		// only a previous version has been deleted
+		"demo": {
+			{created: "2026-08-01T00:00:00Z", deleted: "2026-08-02T00:00:00Z"},
+			{created: "2026-08-02T00:00:00Z", data: map[string]interface{}{"foo": "bar"}},
+		},
+		"sub/destroyed": {
+			{created: "2026-08-01T00:00:00Z", destroyed: true},
+		},
+	})
+
+	v, err := NewClient(srv.URL, "token")
+	require.NoError(t, err)
+
+	res, err := v.ListRecursiveDeleted(context.Background(), "secret", "", false)
+	require.NoError(t, err)
+
+	require.Len(t, res, 2)
+	assert.Equal(t, 2, res["admin"].Versions[0].Version)
+	assert.False(t, res["admin"].Versions[0].Destroyed)
+	assert.True(t, res["sub/destroyed"].Versions[0].Destroyed)
+}
+
+func TestListRecursiveMetadata(t *testing.T) {
+	srv := newVersionsServer(t, map[string][]fakeVersion{
+		"admin": {
+			{created: "2026-08-01T00:00:00Z", data: map[string]interface{}{"user": "v1"}},
+			{created: "2026-08-02T00:00:00Z", deleted: "2026-08-03T00:00:00Z"},
+		},
+		"sub/destroyed": {
+			{created: "2026-08-01T00:00:00Z", destroyed: true},
+		},
+	})
+
+	v, err := NewClient(srv.URL, "token")
+	require.NoError(t, err)
+
+	// deleted and destroyed secrets cannot be read, but are listed by their metadata
+	res, err := v.ListRecursiveMetadata(context.Background(), "secret", "", false)
+	require.NoError(t, err)
+
+	require.Len(t, res, 2)
+	assert.Equal(t, 2, res["admin"].CurrentVersion)
+	assert.Equal(t, 1, res["sub/destroyed"].CurrentVersion)
+
+	res, err = v.ListRecursiveMetadata(context.Background(), "secret", "sub/destroyed", false)
+	require.NoError(t, err)
+
+	assert.Contains(t, res, "sub/destroyed")
+
+	// a path that does not exist has no secrets
+	res, err = v.ListRecursiveMetadata(context.Background(), "secret", "missing", false)
+	require.NoError(t, err)
+
+	assert.Empty(t, res)
+
+	// an empty engine has no secrets
+	empty, err := NewClient(newVersionsServer(t, map[string][]fakeVersion{}).URL, "token")
+	require.NoError(t, err)
+
+	res, err = empty.ListRecursiveMetadata(context.Background(), "secret", "", false)
+	require.NoError(t, err)
+
+	assert.Empty(t, res)
+}
+
+func TestIsVersionedSecrets(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    map[string]interface{}
+		expected bool
+	}{
+		{
+			name: "all versions export",
+			input: map[string]interface{}{
+				"sub/demo": map[string]interface{}{
+					"custom_metadata": map[string]interface{}{"owner": "team-a"},
+					"versions": []interface{}{
+						map[string]interface{}{"version": 1, "data": map[string]interface{}{"user": "admin"}},
+					},
+				},
+			},
+			expected: true,
+		},
+		{
+			name: "all versions export with settings",
+			input: map[string]interface{}{
+				"sub/demo": map[string]interface{}{
+					"max_versions": 5,
+					"cas_required": true,
+					"versions":     []interface{}{},
+				},
+			},
+			expected: true,
+		},
+		{
+			name: "flat export",
+			input: map[string]interface{}{
+				"sub/demo": map[string]interface{}{"user": "admin"},
+			},
+		},
+		{
+			name: "secret with a versions key",
+			input: map[string]interface{}{
+				"sub/demo": map[string]interface{}{"versions": []interface{}{"1"}, "user": "admin"},
+			},
+		},
+		{
+			name: "empty",
+		},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, IsVersionedSecrets(tc.input), tc.name)
+	}
+}
+
+func TestParseVersionedSecrets(t *testing.T) {
+	secrets, err := ParseVersionedSecrets(map[string]interface{}{
+		"sub/demo": map[string]interface{}{
+			"max_versions": 5,
+			"versions": []interface{}{
+				map[string]interface{}{"version": 2, "created_time": "2024-05-28T05:57:52Z", "deletion_time": "2024-05-28T06:00:00Z"},
+				map[string]interface{}{"version": 1, "created_time": "2024-05-28T04:47:54Z", "data": map[string]interface{}{"user": "admin"}},
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, secrets["sub/demo"].Versions, 2)
+
+	assert.Nil(t, secrets["sub/demo"].Versions[0].Data)
+	assert.NotNil(t, secrets["sub/demo"].Versions[0].DeletionTime)
+	assert.Equal(t, map[string]interface{}{"user": "admin"}, secrets["sub/demo"].Versions[1].Data)
+	assert.Equal(t, &SecretSettings{MaxVersions: 5}, secrets["sub/demo"].Settings())
+
+	_, err = ParseVersionedSecrets(map[string]interface{}{
+		"sub/demo": map[string]interface{}{"versions": "invalid"},
+	})
+	require.Error(t, err)
+}
+
+func TestReadAllVersionsFutureDeletion(t *testing.T) {
+	srv := newVersionsServer(t, map[string][]fakeVersion{
+		"admin": {
+			{created: "2026-08-01T00:00:00Z", deleted: "2026-08-02T00:00:00Z"},
+			// deleted automatically due to delete_version_after, but still readable
+			{created: "2026-08-02T00:00:00Z", deleted: time.Now().Add(time.Hour).UTC().Format(time.RFC3339), data: map[string]interface{}{"user": "v2"}},
+		},
+	})
+
+	v, err := NewClient(srv.URL, "token")
+	require.NoError(t, err)
+
+	secret, err := v.ReadAllVersions(context.Background(), "secret", "admin")
+	require.NoError(t, err)
+
+	require.Len(t, secret.Versions, 2)
+	assert.Equal(t, map[string]interface{}{"user": "v2"}, secret.Versions[0].Data)
+	assert.Nil(t, secret.Versions[1].Data)
+}
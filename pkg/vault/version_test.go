@@ -16,8 +16,8 @@ func (s *VaultSuite) TestReadAllVersions() {
 		require.NoError(s.T(), s.client.EnableKV2Engine(ctx, rootPath))
 
 		// write two versions of the same secret
-		require.NoError(s.T(), s.client.WriteSecrets(ctx, rootPath, subPath, map[string]interface{}{"user": "v1"}))
-		require.NoError(s.T(), s.client.WriteSecrets(ctx, rootPath, subPath, map[string]interface{}{"user": "v2", "extra": "x"}))
+		require.NoError(s.T(), s.client.WriteSecrets(ctx, "", rootPath, subPath, map[string]interface{}{"user": "v1"}))
+		require.NoError(s.T(), s.client.WriteSecrets(ctx, "", rootPath, subPath, map[string]interface{}{"user": "v2", "extra": "x"}))
 
 		secret, err := s.client.ReadAllVersions(ctx, rootPath, subPath)
 		require.NoError(s.T(), err)
@@ -44,11 +44,11 @@ func (s *VaultSuite) TestReadAllVersionsDeleted() {
 
 		require.NoError(s.T(), s.client.EnableKV2Engine(ctx, rootPath))
 
-		require.NoError(s.T(), s.client.WriteSecrets(ctx, rootPath, subPath, map[string]interface{}{"user": "v1"}))
-		require.NoError(s.T(), s.client.WriteSecrets(ctx, rootPath, subPath, map[string]interface{}{"user": "v2"}))
+		require.NoError(s.T(), s.client.WriteSecrets(ctx, "", rootPath, subPath, map[string]interface{}{"user": "v1"}))
+		require.NoError(s.T(), s.client.WriteSecrets(ctx, "", rootPath, subPath, map[string]interface{}{"user": "v2"}))
 
 		// soft-delete version 1 (metadata remains, data is gone)
-		require.NoError(s.T(), s.deleteVersion(ctx, rootPath, subPath, 1))
+		require.NoError(s.T(), s.client.DeleteSecret(ctx, rootPath, subPath, []int{1}))
 
 		secret, err := s.client.ReadAllVersions(ctx, rootPath, subPath)
 		require.NoError(s.T(), err)
@@ -69,9 +69,9 @@ func (s *VaultSuite) TestListRecursiveAllVersions() {
 
 		require.NoError(s.T(), s.client.EnableKV2Engine(ctx, rootPath))
 
-		require.NoError(s.T(), s.client.WriteSecrets(ctx, rootPath, "admin", map[string]interface{}{"user": "v1"}))
-		require.NoError(s.T(), s.client.WriteSecrets(ctx, rootPath, "admin", map[string]interface{}{"user": "v2"}))
-		require.NoError(s.T(), s.client.WriteSecrets(ctx, rootPath, "sub/demo", map[string]interface{}{"foo": "bar"}))
+		require.NoError(s.T(), s.client.WriteSecrets(ctx, "", rootPath, "admin", map[string]interface{}{"user": "v1"}))
+		require.NoError(s.T(), s.client.WriteSecrets(ctx, "", rootPath, "admin", map[string]interface{}{"user": "v2"}))
+		require.NoError(s.T(), s.client.WriteSecrets(ctx, "", rootPath, "sub/demo", map[string]interface{}{"foo": "bar"}))
 
 		vs, err := s.client.ListRecursiveAllVersions(ctx, rootPath, "", false)
 		require.NoError(s.T(), err)
@@ -89,19 +89,9 @@ func (s *VaultSuite) TestListRecursiveAllVersionsKVv1() {
 		rootPath := "kvv1"
 
 		require.NoError(s.T(), s.client.EnableKV1Engine(ctx, rootPath))
-		require.NoError(s.T(), s.client.WriteSecrets(ctx, rootPath, "admin", map[string]interface{}{"user": "v1"}))
+		require.NoError(s.T(), s.client.WriteSecrets(ctx, "", rootPath, "admin", map[string]interface{}{"user": "v1"}))
 
 		_, err := s.client.ListRecursiveAllVersions(ctx, rootPath, "", false)
 		require.Error(s.T(), err, "--all-versions should fail on a KVv1 engine")
 	})
 }
-
-// deleteVersion soft-deletes a single KVv2 secret version.
-func (s *VaultSuite) deleteVersion(ctx context.Context, rootPath, subPath string, version int) error {
-	_, err := s.client.Client.Logical().WriteWithContext(ctx,
-		rootPath+"/delete/"+subPath,
-		map[string]interface{}{"versions": []int{version}},
-	)
-
-	return err
-}